@@ -0,0 +1,368 @@
+package iputil
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/ericyan/iputil/uint128"
+)
+
+// An Addr is an IP address represented as a fixed-size value. Unlike
+// net.IP, an Addr is comparable, so that it can be used as a map key
+// or compared with ==. The zero Addr is not a valid address.
+type Addr struct {
+	af uint
+	x  uint128.Int
+}
+
+// AddrFromIP returns the Addr for ip. As net.IP does not distinguish
+// between IPv4 addresses and their IPv4-mapped IPv6 form, the latter is
+// converted to an IPv4 address. If ip is invalid, it returns the zero
+// Addr.
+func AddrFromIP(ip net.IP) Addr {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	return addrFromSlice(ip)
+}
+
+// addrFromSlice returns the Addr for ip, keeping the address family
+// implied by its length.
+func addrFromSlice(ip net.IP) Addr {
+	af := AddressFamily(ip)
+	if af == 0 {
+		return Addr{}
+	}
+
+	x, _ := uint128.NewFromBytes(ip)
+	return Addr{af, x}
+}
+
+// AddrFrom4 returns the IPv4 address given by the bytes in b.
+func AddrFrom4(b [4]byte) Addr {
+	return addrFromSlice(b[:])
+}
+
+// AddrFrom16 returns the IPv6 address given by the bytes in b. An
+// IPv4-mapped address remains an IPv6 address.
+func AddrFrom16(b [16]byte) Addr {
+	return addrFromSlice(b[:])
+}
+
+// AddrFromInt returns the address of family af whose integer value is
+// x. It returns an error if af is invalid or x does not fit in af.
+func AddrFromInt(x uint128.Int, af uint) (Addr, error) {
+	switch af {
+	case IPv4:
+		if x.BitLen() > IPv4BitLen {
+			return Addr{}, errors.New("address out of range")
+		}
+	case IPv6:
+	default:
+		return Addr{}, errors.New("invalid address family")
+	}
+
+	return Addr{af, x}, nil
+}
+
+// ParseAddr parses s as an IP address in the syntax of Parse. Unlike
+// AddrFromIP, an IPv4-mapped address remains an IPv6 address.
+func ParseAddr(s string) (Addr, error) {
+	ip, err := Parse(s, 0)
+	if err != nil {
+		return Addr{}, err
+	}
+
+	return addrFromSlice(ip), nil
+}
+
+// IsValid reports whether a is a valid address, rather than the zero
+// Addr.
+func (a Addr) IsValid() bool {
+	return a.af != 0
+}
+
+// Family returns the address family of a: 1 for IPv4, 2 for IPv6, and
+// 0 for the zero Addr.
+func (a Addr) Family() uint {
+	return a.af
+}
+
+// BitLen returns the length of a in bits, or 0 for the zero Addr.
+func (a Addr) BitLen() int {
+	switch a.af {
+	case IPv4:
+		return IPv4BitLen
+	case IPv6:
+		return IPv6BitLen
+	default:
+		return 0
+	}
+}
+
+// Int returns the integer value of a.
+func (a Addr) Int() uint128.Int {
+	return a.x
+}
+
+// IP returns a as a net.IP of 4 or 16 bytes. It returns nil for the
+// zero Addr.
+func (a Addr) IP() net.IP {
+	if a.af == 0 {
+		return nil
+	}
+
+	return net.IP(a.x.Bytes()[16-a.BitLen()/8:])
+}
+
+// Compare returns an integer comparing a and b. The zero Addr sorts
+// before IPv4 addresses, which sort before IPv6 addresses, and
+// addresses of the same family are ordered by their integer values. The
+// result is -1 if a < b, 0 if a == b, and +1 if a > b.
+func (a Addr) Compare(b Addr) int {
+	switch {
+	case a.af < b.af:
+		return -1
+	case a.af > b.af:
+		return 1
+	default:
+		return a.x.Cmp(b.x)
+	}
+}
+
+// Less reports whether a sorts before b, as defined by Compare.
+func (a Addr) Less(b Addr) bool {
+	return a.Compare(b) < 0
+}
+
+// String returns the canonical text form of a, as described in RFC 5952
+// for IPv6 addresses. It returns "<nil>" for the zero Addr.
+func (a Addr) String() string {
+	if a.af == 0 {
+		return "<nil>"
+	}
+
+	return formatCanonical(a.IP())
+}
+
+// MarshalText implements encoding.TextMarshaler. The zero Addr is
+// encoded as empty text.
+func (a Addr) MarshalText() ([]byte, error) {
+	if a.af == 0 {
+		return []byte{}, nil
+	}
+
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the
+// syntax of ParseAddr, and empty text for the zero Addr.
+func (a *Addr) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*a = Addr{}
+		return nil
+	}
+
+	v, err := ParseAddr(string(text))
+	if err != nil {
+		return err
+	}
+
+	*a = v
+	return nil
+}
+
+// A Prefix is an IP network prefix represented as a fixed-size,
+// comparable value. The address of a Prefix always has its host bits
+// cleared. The zero Prefix is not a valid prefix.
+type Prefix struct {
+	addr Addr
+	bits int
+}
+
+// NewPrefix returns the prefix of length bits that contains addr. It
+// returns an error if addr is invalid or bits is out of range.
+func NewPrefix(addr Addr, bits int) (Prefix, error) {
+	if !addr.IsValid() || bits < 0 || bits > addr.BitLen() {
+		return Prefix{}, errors.New("invalid prefix")
+	}
+
+	// Clear the host bits, which are the low bits of the integer value.
+	if n := addr.BitLen() - bits; n > 0 {
+		addr.x = addr.x.Rsh(uint(n)).Lsh(uint(n))
+	}
+
+	return Prefix{addr, bits}, nil
+}
+
+// PrefixFromIPNet returns the Prefix for n. An IPv4 subnet whose IP is
+// in 16-byte form, such as one built from the result of net.ParseIP, is
+// converted to an IPv4 prefix as long as its mask is 4 bytes long.
+func PrefixFromIPNet(n *net.IPNet) (Prefix, error) {
+	if n == nil {
+		return Prefix{}, errors.New("invalid prefix")
+	}
+
+	ones, bits := n.Mask.Size()
+	addr := addrFromSlice(n.IP)
+	if bits == IPv4BitLen {
+		addr = AddrFromIP(n.IP)
+	}
+	if bits == 0 || bits != addr.BitLen() {
+		return Prefix{}, errors.New("invalid prefix")
+	}
+
+	return NewPrefix(addr, ones)
+}
+
+// ParsePrefix parses s as a prefix in CIDR notation, such as
+// "192.0.2.0/24" or "2001:db8::/32". Host bits are cleared, and a
+// missing prefix length means a single-address prefix.
+func ParsePrefix(s string) (Prefix, error) {
+	ip, mask, err := parseInet(s)
+	if err != nil {
+		return Prefix{}, err
+	}
+
+	ones, _ := mask.Size()
+	return NewPrefix(addrFromSlice(ip), ones)
+}
+
+// IsValid reports whether p is a valid prefix, rather than the zero
+// Prefix.
+func (p Prefix) IsValid() bool {
+	return p.addr.IsValid()
+}
+
+// Addr returns the network address of p.
+func (p Prefix) Addr() Addr {
+	return p.addr
+}
+
+// Bits returns the prefix length of p.
+func (p Prefix) Bits() int {
+	return p.bits
+}
+
+// Contains reports whether the prefix includes addr. Addresses of a
+// different family are never included.
+func (p Prefix) Contains(addr Addr) bool {
+	if !p.IsValid() || addr.af != p.addr.af {
+		return false
+	}
+
+	n := uint(p.addr.BitLen() - p.bits)
+	return addr.x.Rsh(n) == p.addr.x.Rsh(n)
+}
+
+// IPNet returns p as a *net.IPNet. It returns nil for the zero Prefix.
+func (p Prefix) IPNet() *net.IPNet {
+	if !p.IsValid() {
+		return nil
+	}
+
+	bits := p.addr.BitLen()
+	return &net.IPNet{
+		IP:   p.addr.IP(),
+		Mask: net.CIDRMask(p.bits, bits),
+	}
+}
+
+// Compare returns an integer comparing p and q. Prefixes are ordered by
+// their addresses as defined by Addr.Compare, then by their lengths, so
+// that a prefix sorts before the longer prefixes it contains. The result
+// is -1 if p < q, 0 if p == q, and +1 if p > q.
+func (p Prefix) Compare(q Prefix) int {
+	if c := p.addr.Compare(q.addr); c != 0 {
+		return c
+	}
+
+	switch {
+	case p.bits < q.bits:
+		return -1
+	case p.bits > q.bits:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Less reports whether p sorts before q, as defined by Compare.
+func (p Prefix) Less(q Prefix) bool {
+	return p.Compare(q) < 0
+}
+
+// String returns the CIDR notation of p. It returns "<nil>" for the
+// zero Prefix.
+func (p Prefix) String() string {
+	if !p.IsValid() {
+		return "<nil>"
+	}
+
+	return p.addr.String() + "/" + strconv.Itoa(p.bits)
+}
+
+// MarshalText implements encoding.TextMarshaler. The zero Prefix is
+// encoded as empty text.
+func (p Prefix) MarshalText() ([]byte, error) {
+	if !p.IsValid() {
+		return []byte{}, nil
+	}
+
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the
+// syntax of ParsePrefix, and empty text for the zero Prefix.
+func (p *Prefix) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*p = Prefix{}
+		return nil
+	}
+
+	v, err := ParsePrefix(string(text))
+	if err != nil {
+		return err
+	}
+
+	*p = v
+	return nil
+}
+
+// SortAddrs sorts addrs in the order defined by Addr.Compare, so that
+// IPv4 addresses come before IPv6 addresses.
+func SortAddrs(addrs []Addr) {
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i].Less(addrs[j])
+	})
+}
+
+// SortPrefixes sorts prefixes in the order defined by Prefix.Compare,
+// that is by address, then by prefix length.
+func SortPrefixes(prefixes []Prefix) {
+	sort.Slice(prefixes, func(i, j int) bool {
+		return prefixes[i].Less(prefixes[j])
+	})
+}
+
+// SortIPs sorts ips in the order defined by Addr.Compare. The 4-byte
+// and 16-byte forms of an IPv4 address are considered equal, and both
+// sort before IPv6 addresses. Invalid addresses come first.
+func SortIPs(ips []net.IP) {
+	sort.SliceStable(ips, func(i, j int) bool {
+		return AddrFromIP(ips[i]).Less(AddrFromIP(ips[j]))
+	})
+}
+
+// SortIPNets sorts subnets in the order defined by Prefix.Compare.
+// Invalid subnets come first.
+func SortIPNets(subnets []*net.IPNet) {
+	sort.SliceStable(subnets, func(i, j int) bool {
+		p, _ := PrefixFromIPNet(subnets[i])
+		q, _ := PrefixFromIPNet(subnets[j])
+		return p.Less(q)
+	})
+}
@@ -0,0 +1,286 @@
+package iputil
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestAddrFromIP(t *testing.T) {
+	cases := []struct {
+		ip  net.IP
+		af  uint
+		out string
+	}{
+		{ParseIPv4("192.168.0.1"), IPv4, "192.168.0.1"},
+		{net.ParseIP("192.168.0.1"), IPv4, "192.168.0.1"},
+		{ParseIPv6("2001:db8::1"), IPv6, "2001:db8::1"},
+		{net.IP{1, 2, 3}, 0, "<nil>"},
+		{nil, 0, "<nil>"},
+	}
+
+	for _, c := range cases {
+		a := AddrFromIP(c.ip)
+		if a.Family() != c.af {
+			t.Errorf("unexpected family for %s: got %d, want %d", c.ip, a.Family(), c.af)
+		}
+		if a.String() != c.out {
+			t.Errorf("unexpected address for %s: got %s, want %s", c.ip, a, c.out)
+		}
+		if c.af != 0 && !a.IP().Equal(c.ip) {
+			t.Errorf("unexpected round trip for %s: got %s", c.ip, a.IP())
+		}
+	}
+
+	if AddrFromIP(net.ParseIP("10.0.0.1")) != AddrFrom4([4]byte{10, 0, 0, 1}) {
+		t.Error("unexpected inequality of 4-byte and 16-byte forms")
+	}
+
+	mapped := AddrFrom16([16]byte{10: 0xff, 11: 0xff, 12: 10, 15: 1})
+	if mapped.Family() != IPv6 || mapped.String() != "::ffff:10.0.0.1" {
+		t.Errorf("unexpected mapped address: %s", mapped)
+	}
+}
+
+func TestParseAddr(t *testing.T) {
+	cases := []struct {
+		in  string
+		af  uint
+		err bool
+	}{
+		{"192.168.0.1", IPv4, false},
+		{"2001:db8::1", IPv6, false},
+		{"::ffff:192.168.0.1", IPv6, false},
+		{"192.168.0.256", 0, true},
+		{"", 0, true},
+	}
+
+	for _, c := range cases {
+		a, err := ParseAddr(c.in)
+		if c.err {
+			if err == nil {
+				t.Errorf("error expected for %q", c.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", c.in, err)
+		}
+		if a.Family() != c.af || a.String() != c.in {
+			t.Errorf("unexpected address for %q: got %s (family %d)", c.in, a, a.Family())
+		}
+	}
+}
+
+func TestAddrCompare(t *testing.T) {
+	cases := []struct {
+		a, b string
+		out  int
+	}{
+		{"10.0.0.1", "10.0.0.1", 0},
+		{"10.0.0.1", "10.0.0.2", -1},
+		{"10.0.0.2", "10.0.0.1", 1},
+		{"255.255.255.255", "::", -1},
+		{"::", "0.0.0.0", 1},
+		{"::ffff:10.0.0.1", "10.0.0.1", 1},
+		{"2001:db8::1", "2001:db8::", 1},
+	}
+
+	for _, c := range cases {
+		a, _ := ParseAddr(c.a)
+		b, _ := ParseAddr(c.b)
+		if out := a.Compare(b); out != c.out {
+			t.Errorf("unexpected result for %s vs %s: got %d, want %d", c.a, c.b, out, c.out)
+		}
+		if a.Less(b) != (c.out < 0) {
+			t.Errorf("unexpected Less for %s vs %s", c.a, c.b)
+		}
+	}
+
+	if !(Addr{}).Less(AddrFrom4([4]byte{})) {
+		t.Error("zero Addr should sort first")
+	}
+}
+
+func TestAddrFromInt(t *testing.T) {
+	a, _ := ParseAddr("192.168.0.1")
+	if b, err := AddrFromInt(a.Int(), IPv4); err != nil || b != a {
+		t.Errorf("unexpected round trip for %s: got %s, %v", a, b, err)
+	}
+
+	a, _ = ParseAddr("2001:db8::1")
+	if _, err := AddrFromInt(a.Int(), IPv4); err == nil {
+		t.Error("error expected for out of range IPv4 address")
+	}
+	if _, err := AddrFromInt(a.Int(), 3); err == nil {
+		t.Error("error expected for invalid family")
+	}
+}
+
+func TestAddrMapKey(t *testing.T) {
+	m := make(map[Addr]int)
+	for _, ip := range []net.IP{
+		net.ParseIP("192.168.0.1"),
+		ParseIPv4("192.168.0.1"),
+		net.ParseIP("2001:db8::1"),
+	} {
+		m[AddrFromIP(ip)]++
+	}
+
+	if len(m) != 2 || m[AddrFrom4([4]byte{192, 168, 0, 1})] != 2 {
+		t.Errorf("unexpected map: %v", m)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	cases := []struct {
+		in   string
+		out  string
+		bits int
+		err  bool
+	}{
+		{"192.168.0.1/24", "192.168.0.0/24", 24, false},
+		{"192.168.0.1", "192.168.0.1/32", 32, false},
+		{"0.0.0.0/0", "0.0.0.0/0", 0, false},
+		{"2001:db8::1/32", "2001:db8::/32", 32, false},
+		{"::/0", "::/0", 0, false},
+		{"192.168.0.0/33", "", 0, true},
+		{"2001:db8::/", "", 0, true},
+	}
+
+	for _, c := range cases {
+		p, err := ParsePrefix(c.in)
+		if c.err {
+			if err == nil {
+				t.Errorf("error expected for %q", c.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", c.in, err)
+		}
+		if p.String() != c.out || p.Bits() != c.bits {
+			t.Errorf("unexpected prefix for %q: got %s, want %s", c.in, p, c.out)
+		}
+		if q, err := PrefixFromIPNet(p.IPNet()); err != nil || q != p {
+			t.Errorf("unexpected round trip for %s: got %s, %v", p, q, err)
+		}
+	}
+}
+
+func TestPrefixFromIPNet(t *testing.T) {
+	n := &net.IPNet{IP: net.ParseIP("192.168.0.0"), Mask: net.CIDRMask(16, 32)}
+	if p, err := PrefixFromIPNet(n); err != nil || p.String() != "192.168.0.0/16" {
+		t.Errorf("unexpected prefix for %s: got %s, %v", n, p, err)
+	}
+
+	n = &net.IPNet{IP: ParseIPv4("192.168.0.0"), Mask: net.CIDRMask(16, 128)}
+	if _, err := PrefixFromIPNet(n); err == nil {
+		t.Errorf("error expected for %s", n)
+	}
+	if _, err := PrefixFromIPNet(nil); err == nil {
+		t.Error("error expected for nil")
+	}
+}
+
+func TestPrefixContains(t *testing.T) {
+	cases := []struct {
+		prefix string
+		addr   string
+		out    bool
+	}{
+		{"192.168.0.0/24", "192.168.0.1", true},
+		{"192.168.0.0/24", "192.168.1.1", false},
+		{"0.0.0.0/0", "10.0.0.1", true},
+		{"0.0.0.0/0", "::1", false},
+		{"::/0", "::ffff:10.0.0.1", true},
+		{"::/0", "10.0.0.1", false},
+		{"2001:db8::/32", "2001:db8:ffff::1", true},
+	}
+
+	for _, c := range cases {
+		p, _ := ParsePrefix(c.prefix)
+		a, _ := ParseAddr(c.addr)
+		if out := p.Contains(a); out != c.out {
+			t.Errorf("unexpected result for %s in %s: got %t, want %t", c.addr, c.prefix, out, c.out)
+		}
+	}
+}
+
+func TestSortAddrs(t *testing.T) {
+	var addrs []Addr
+	for _, s := range []string{"2001:db8::1", "10.0.0.2", "::", "10.0.0.1", "0.0.0.0"} {
+		a, _ := ParseAddr(s)
+		addrs = append(addrs, a)
+	}
+	addrs = append(addrs, Addr{})
+
+	SortAddrs(addrs)
+
+	var out []string
+	for _, a := range addrs {
+		out = append(out, a.String())
+	}
+	want := []string{"<nil>", "0.0.0.0", "10.0.0.1", "10.0.0.2", "::", "2001:db8::1"}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("unexpected order: got %v, want %v", out, want)
+	}
+}
+
+func TestSortPrefixes(t *testing.T) {
+	var prefixes []Prefix
+	for _, s := range []string{"2001:db8::/32", "10.0.0.0/16", "10.0.0.0/8", "::/0", "10.1.0.0/16"} {
+		p, _ := ParsePrefix(s)
+		prefixes = append(prefixes, p)
+	}
+
+	SortPrefixes(prefixes)
+
+	var out []string
+	for _, p := range prefixes {
+		out = append(out, p.String())
+	}
+	want := []string{"10.0.0.0/8", "10.0.0.0/16", "10.1.0.0/16", "::/0", "2001:db8::/32"}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("unexpected order: got %v, want %v", out, want)
+	}
+}
+
+func TestSortIPs(t *testing.T) {
+	ips := []net.IP{
+		net.ParseIP("2001:db8::1"),
+		net.ParseIP("10.0.0.2"),
+		ParseIPv4("10.0.0.1"),
+		net.ParseIP("::1"),
+	}
+
+	SortIPs(ips)
+
+	var out []string
+	for _, ip := range ips {
+		out = append(out, ip.String())
+	}
+	want := []string{"10.0.0.1", "10.0.0.2", "::1", "2001:db8::1"}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("unexpected order: got %v, want %v", out, want)
+	}
+}
+
+func TestSortIPNets(t *testing.T) {
+	var subnets []*net.IPNet
+	for _, s := range []string{"2001:db8::/32", "10.0.0.0/16", "10.0.0.0/8"} {
+		_, n, _ := net.ParseCIDR(s)
+		subnets = append(subnets, n)
+	}
+
+	SortIPNets(subnets)
+
+	var out []string
+	for _, n := range subnets {
+		out = append(out, n.String())
+	}
+	want := []string{"10.0.0.0/8", "10.0.0.0/16", "2001:db8::/32"}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("unexpected order: got %v, want %v", out, want)
+	}
+}
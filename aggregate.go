@@ -0,0 +1,84 @@
+package iputil
+
+import (
+	"sort"
+
+	"github.com/ericyan/iputil/uint128"
+)
+
+// AggregatePrefixes returns the shortest list of prefixes that covers
+// exactly the addresses covered by prefixes, sorted as by SortPrefixes.
+// Overlapping prefixes are merged, as are adjacent prefixes that can be
+// combined. Invalid prefixes are ignored.
+func AggregatePrefixes(prefixes []Prefix) []Prefix {
+	sorted := make([]Prefix, 0, len(prefixes))
+	for _, p := range prefixes {
+		if p.IsValid() {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Less(sorted[j]) })
+
+	var results []Prefix
+	var af uint
+	var first, last uint128.Int
+	for i, p := range sorted {
+		start, end := p.addr.x, p.addr.x.Or(hostMask(p))
+
+		// Prefixes are sorted by their first address, so p extends the
+		// current run if it starts no later than right after its end.
+		if i > 0 && p.addr.af == af {
+			if next, overflow := last.AddOverflow(uint128.One); overflow || !next.IsLessThan(start) {
+				if last.IsLessThan(end) {
+					last = end
+				}
+				continue
+			}
+		}
+
+		if i > 0 {
+			results = appendRange(results, af, first, last)
+		}
+		af, first, last = p.addr.af, start, end
+	}
+	if len(sorted) > 0 {
+		results = appendRange(results, af, first, last)
+	}
+
+	return results
+}
+
+// hostMask returns the mask of the host bits of p.
+func hostMask(p Prefix) uint128.Int {
+	return uint128.Max.Rsh(uint(128 - p.addr.BitLen() + p.bits))
+}
+
+// appendRange appends the prefixes covering the addresses from first to
+// last of family af to prefixes.
+func appendRange(prefixes []Prefix, af uint, first, last uint128.Int) []Prefix {
+	bitLen := IPv4BitLen
+	if af == IPv6 {
+		bitLen = IPv6BitLen
+	}
+
+	cur := first
+	for {
+		// Find the largest block starting at cur that ends within the
+		// range.
+		n := cur.TrailingZeros()
+		if n > bitLen {
+			n = bitLen
+		}
+		for n > 0 && last.IsLessThan(cur.Or(uint128.Max.Rsh(uint(128-n)))) {
+			n--
+		}
+
+		prefixes = append(prefixes, Prefix{Addr{af, cur}, bitLen - n})
+
+		end := cur.Or(uint128.Max.Rsh(uint(128 - n)))
+		if end == last {
+			return prefixes
+		}
+		cur = end.Add(uint128.One)
+	}
+}
@@ -0,0 +1,49 @@
+package iputil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAggregatePrefixes(t *testing.T) {
+	cases := []struct {
+		in  []string
+		out []string
+	}{
+		{nil, nil},
+		{[]string{"192.0.2.0/24"}, []string{"192.0.2.0/24"}},
+		{[]string{"192.0.2.0/25", "192.0.2.128/25"}, []string{"192.0.2.0/24"}},
+		{[]string{"192.0.2.128/25", "192.0.2.0/24", "192.0.2.64/26"}, []string{"192.0.2.0/24"}},
+		{[]string{"10.0.0.1/32", "10.0.0.2/32"}, []string{"10.0.0.1/32", "10.0.0.2/32"}},
+		{[]string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.0/32"}, []string{"10.0.0.0/30"}},
+		{[]string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24"}, []string{"10.0.0.0/23", "10.0.2.0/24"}},
+		{[]string{"10.0.1.0/24", "10.0.2.0/24"}, []string{"10.0.1.0/24", "10.0.2.0/24"}},
+		{[]string{"0.0.0.0/1", "128.0.0.0/1", "255.255.255.255/32"}, []string{"0.0.0.0/0"}},
+		{[]string{"::/1", "8000::/1", "2001:db8::/32"}, []string{"::/0"}},
+		{
+			[]string{"2001:db8::/33", "10.0.0.0/9", "2001:db8:8000::/33", "10.128.0.0/9"},
+			[]string{"10.0.0.0/8", "2001:db8::/32"},
+		},
+		{[]string{"0.0.0.0/0", "::/0"}, []string{"0.0.0.0/0", "::/0"}},
+	}
+
+	for _, c := range cases {
+		var in []Prefix
+		for _, s := range c.in {
+			p, err := ParsePrefix(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			in = append(in, p)
+		}
+
+		var out []string
+		for _, p := range AggregatePrefixes(append(in, Prefix{})) {
+			out = append(out, p.String())
+		}
+
+		if strings.Join(out, " ") != strings.Join(c.out, " ") {
+			t.Errorf("unexpected result for %v: got %v, want %v", c.in, out, c.out)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Package cloud parses the IP address ranges published by cloud
+// providers, and looks up addresses in them.
+package cloud
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ericyan/iputil"
+)
+
+// Provider names.
+const (
+	AWS        = "AWS"
+	Google     = "Google"
+	Azure      = "Azure"
+	Cloudflare = "Cloudflare"
+)
+
+// A Range is a prefix of a provider, tagged with the service and region
+// it is used by, if known.
+type Range struct {
+	Prefix   iputil.Prefix
+	Provider string
+	Service  string
+	Region   string
+}
+
+// String returns the prefix followed by the provider, service and
+// region, separated by spaces, leaving out those that are unknown.
+func (r *Range) String() string {
+	s := r.Prefix.String() + " " + r.Provider
+	for _, v := range []string{r.Service, r.Region} {
+		if v != "" {
+			s += " " + v
+		}
+	}
+
+	return s
+}
+
+// A Parser parses ranges in the format of a provider.
+type Parser func(io.Reader) ([]*Range, error)
+
+// ReadFile parses the named file with parse.
+func ReadFile(name string, parse Parser) ([]*Range, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parse(f)
+}
+
+func parsePrefix(provider, s string) (iputil.Prefix, error) {
+	p, err := iputil.ParsePrefix(s)
+	if err != nil {
+		return iputil.Prefix{}, fmt.Errorf("cloud: invalid %s prefix %q", provider, s)
+	}
+
+	return p, nil
+}
+
+// ParseAWS parses the ip-ranges.json file of AWS. Prefixes of the AMAZON
+// service, which includes all others, are returned first, so that the
+// more specific services take precedence when added to a Table.
+func ParseAWS(r io.Reader) ([]*Range, error) {
+	var v struct {
+		Prefixes []struct {
+			IPPrefix string `json:"ip_prefix"`
+			Region   string `json:"region"`
+			Service  string `json:"service"`
+		} `json:"prefixes"`
+		IPv6Prefixes []struct {
+			IPv6Prefix string `json:"ipv6_prefix"`
+			Region     string `json:"region"`
+			Service    string `json:"service"`
+		} `json:"ipv6_prefixes"`
+	}
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var ranges []*Range
+	add := func(s, service, region string) error {
+		p, err := parsePrefix(AWS, s)
+		if err != nil {
+			return err
+		}
+		if region == "GLOBAL" {
+			region = ""
+		}
+
+		ranges = append(ranges, &Range{p, AWS, service, region})
+		return nil
+	}
+
+	for _, e := range v.Prefixes {
+		if err := add(e.IPPrefix, e.Service, e.Region); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range v.IPv6Prefixes {
+		if err := add(e.IPv6Prefix, e.Service, e.Region); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].Service == "AMAZON" && ranges[j].Service != "AMAZON"
+	})
+
+	return ranges, nil
+}
+
+// ParseGoogle parses the cloud.json file of Google Cloud, which has the
+// service and region of each prefix, or the goog.json file of Google,
+// which has neither.
+func ParseGoogle(r io.Reader) ([]*Range, error) {
+	var v struct {
+		Prefixes []struct {
+			IPv4Prefix string `json:"ipv4Prefix"`
+			IPv6Prefix string `json:"ipv6Prefix"`
+			Service    string `json:"service"`
+			Scope      string `json:"scope"`
+		} `json:"prefixes"`
+	}
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, err
+	}
+
+	ranges := make([]*Range, 0, len(v.Prefixes))
+	for _, e := range v.Prefixes {
+		s := e.IPv4Prefix
+		if s == "" {
+			s = e.IPv6Prefix
+		}
+
+		p, err := parsePrefix(Google, s)
+		if err != nil {
+			return nil, err
+		}
+
+		ranges = append(ranges, &Range{p, Google, e.Service, e.Scope})
+	}
+
+	return ranges, nil
+}
+
+// ParseAzure parses the Service Tags JSON file of Azure. The service of
+// a range is the service tag without the region suffix, such as
+// "Storage" for "Storage.WestUS".
+func ParseAzure(r io.Reader) ([]*Range, error) {
+	var v struct {
+		Values []struct {
+			Name       string `json:"name"`
+			Properties struct {
+				Region          string   `json:"region"`
+				AddressPrefixes []string `json:"addressPrefixes"`
+			} `json:"properties"`
+		} `json:"values"`
+	}
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, err
+	}
+	if v.Values == nil {
+		return nil, errors.New("cloud: no azure service tags")
+	}
+
+	var ranges []*Range
+	for _, tag := range v.Values {
+		service, _, _ := strings.Cut(tag.Name, ".")
+		for _, s := range tag.Properties.AddressPrefixes {
+			p, err := parsePrefix(Azure, s)
+			if err != nil {
+				return nil, err
+			}
+
+			ranges = append(ranges, &Range{p, Azure, service, tag.Properties.Region})
+		}
+	}
+
+	return ranges, nil
+}
+
+// ParseCloudflare parses the plain-text lists of Cloudflare, ips-v4 and
+// ips-v6, which have one prefix per line.
+func ParseCloudflare(r io.Reader) ([]*Range, error) {
+	var ranges []*Range
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		p, err := parsePrefix(Cloudflare, line)
+		if err != nil {
+			return nil, err
+		}
+
+		ranges = append(ranges, &Range{Prefix: p, Provider: Cloudflare})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return ranges, nil
+}
@@ -0,0 +1,120 @@
+package cloud
+
+import (
+	"strings"
+	"testing"
+)
+
+func rangeStrings(ranges []*Range) []string {
+	var s []string
+	for _, r := range ranges {
+		s = append(s, r.String())
+	}
+
+	return s
+}
+
+func TestParsers(t *testing.T) {
+	cases := []struct {
+		file   string
+		parse  Parser
+		ranges []string
+	}{
+		{
+			"testdata/ip-ranges.json",
+			ParseAWS,
+			[]string{
+				"3.5.140.0/22 AWS AMAZON ap-northeast-2",
+				"52.94.0.0/16 AWS AMAZON us-east-1",
+				"52.94.76.0/22 AWS AMAZON us-west-2",
+				"3.5.140.0/22 AWS S3 ap-northeast-2",
+				"13.32.0.0/15 AWS CLOUDFRONT",
+				"2600:1f14::/35 AWS EC2 us-west-2",
+			},
+		},
+		{
+			"testdata/cloud.json",
+			ParseGoogle,
+			[]string{
+				"34.1.208.0/20 Google Google Cloud africa-south1",
+				"2600:1900:8000::/44 Google Google Cloud africa-south1",
+				"35.185.128.0/19 Google Google Cloud asia-east1",
+			},
+		},
+		{
+			"testdata/goog.json",
+			ParseGoogle,
+			[]string{
+				"8.8.4.0/24 Google",
+				"8.8.8.0/24 Google",
+				"2001:4860::/32 Google",
+				"34.0.0.0/15 Google",
+			},
+		},
+		{
+			"testdata/ServiceTags_Public.json",
+			ParseAzure,
+			[]string{
+				"20.36.32.0/19 Azure AzureCloud australiacentral",
+				"2603:1010:300::/40 Azure AzureCloud australiacentral",
+				"20.60.34.0/23 Azure Storage westus",
+				"4.145.74.52/30 Azure ActionGroup",
+			},
+		},
+		{
+			"testdata/ips-v4",
+			ParseCloudflare,
+			[]string{
+				"173.245.48.0/20 Cloudflare",
+				"103.21.244.0/22 Cloudflare",
+				"104.16.0.0/13 Cloudflare",
+			},
+		},
+		{
+			"testdata/ips-v6",
+			ParseCloudflare,
+			[]string{
+				"2400:cb00::/32 Cloudflare",
+				"2606:4700::/32 Cloudflare",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		ranges, err := ReadFile(c.file, c.parse)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", c.file, err)
+			continue
+		}
+
+		if got, want := strings.Join(rangeStrings(ranges), "\n"), strings.Join(c.ranges, "\n"); got != want {
+			t.Errorf("unexpected ranges for %s: got\n%s\nwant\n%s", c.file, got, want)
+		}
+	}
+}
+
+func TestParserErrors(t *testing.T) {
+	cases := []struct {
+		parse Parser
+		input string
+	}{
+		{ParseAWS, `{"prefixes": [{"ip_prefix": "3.5.140.0/33"}]}`},
+		{ParseAWS, `{"ipv6_prefixes": [{"ipv6_prefix": "2600:1f14::/129"}]}`},
+		{ParseAWS, `[`},
+		{ParseGoogle, `{"prefixes": [{"service": "Google Cloud"}]}`},
+		{ParseGoogle, `{"prefixes": {}}`},
+		{ParseAzure, `{"values": [{"name": "AzureCloud", "properties": {"addressPrefixes": ["x"]}}]}`},
+		{ParseAzure, `{}`},
+		{ParseCloudflare, "173.245.48.0/20\n<html>\n"},
+	}
+
+	for _, c := range cases {
+		if _, err := c.parse(strings.NewReader(c.input)); err == nil {
+			t.Errorf("error expected for %q", c.input)
+		}
+	}
+
+	if _, err := ReadFile("testdata/missing.json", ParseAWS); err == nil {
+		t.Error("error expected for missing file")
+	}
+}
@@ -0,0 +1,66 @@
+package cloud
+
+import (
+	"net"
+
+	"github.com/ericyan/iputil"
+	"github.com/ericyan/iputil/internal/radix"
+)
+
+// A Table looks up the ranges containing an address. The zero Table is
+// empty and ready to use.
+type Table struct {
+	tree *radix.Tree
+}
+
+// Add adds ranges to the table.
+func (t *Table) Add(ranges ...*Range) {
+	if t.tree == nil {
+		t.tree = radix.NewTree()
+	}
+
+	for _, r := range ranges {
+		k, n := radix.PrefixKey(r.Prefix)
+
+		var list []*Range
+		if v, err := t.tree.GetPrefix(k, n); err == nil {
+			list = v.([]*Range)
+		}
+		t.tree.SetPrefix(k, n, append(list, r))
+	}
+}
+
+// LookupAll returns the ranges containing ip, the longest prefixes
+// first. Among ranges of the same prefix, the last added comes first.
+func (t *Table) LookupAll(ip net.IP) []*Range {
+	addr := iputil.AddrFromIP(ip)
+	if t.tree == nil || !addr.IsValid() {
+		return nil
+	}
+
+	p, _ := iputil.NewPrefix(addr, addr.BitLen())
+	k, n := radix.PrefixKey(p)
+	values, _ := t.tree.Overlapping(k, n)
+
+	var ranges []*Range
+	for i := len(values) - 1; i >= 0; i-- {
+		list := values[i].([]*Range)
+		for j := len(list) - 1; j >= 0; j-- {
+			ranges = append(ranges, list[j])
+		}
+	}
+
+	return ranges
+}
+
+// Lookup returns the range with the longest prefix containing ip, or
+// nil if there is none. If several ranges have that prefix, the last
+// added is returned.
+func (t *Table) Lookup(ip net.IP) *Range {
+	ranges := t.LookupAll(ip)
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	return ranges[0]
+}
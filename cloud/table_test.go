@@ -0,0 +1,73 @@
+package cloud
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	var table Table
+	if r := table.Lookup(net.ParseIP("3.5.140.1")); r != nil {
+		t.Errorf("unexpected range in empty table: %s", r)
+	}
+
+	for _, f := range []struct {
+		name  string
+		parse Parser
+	}{
+		{"testdata/ip-ranges.json", ParseAWS},
+		{"testdata/cloud.json", ParseGoogle},
+		{"testdata/ServiceTags_Public.json", ParseAzure},
+		{"testdata/ips-v4", ParseCloudflare},
+		{"testdata/ips-v6", ParseCloudflare},
+	} {
+		ranges, err := ReadFile(f.name, f.parse)
+		if err != nil {
+			t.Fatal(err)
+		}
+		table.Add(ranges...)
+	}
+
+	cases := []struct {
+		ip    string
+		match string
+		all   []string
+	}{
+		{
+			"3.5.140.1",
+			"3.5.140.0/22 AWS S3 ap-northeast-2",
+			[]string{"3.5.140.0/22 AWS S3 ap-northeast-2", "3.5.140.0/22 AWS AMAZON ap-northeast-2"},
+		},
+		{
+			"52.94.77.1",
+			"52.94.76.0/22 AWS AMAZON us-west-2",
+			[]string{"52.94.76.0/22 AWS AMAZON us-west-2", "52.94.0.0/16 AWS AMAZON us-east-1"},
+		},
+		{"::ffff:104.16.1.1", "104.16.0.0/13 Cloudflare", nil},
+		{"2603:1010:3ff::1", "2603:1010:300::/40 Azure AzureCloud australiacentral", nil},
+		{"35.185.130.1", "35.185.128.0/19 Google Google Cloud asia-east1", nil},
+		{"192.0.2.1", "", nil},
+		{"2001:db8::1", "", nil},
+	}
+
+	for _, c := range cases {
+		var match string
+		if r := table.Lookup(net.ParseIP(c.ip)); r != nil {
+			match = r.String()
+		}
+		if match != c.match {
+			t.Errorf("unexpected match for %s: got %q, want %q", c.ip, match, c.match)
+		}
+
+		if c.all != nil {
+			if got, want := strings.Join(rangeStrings(table.LookupAll(net.ParseIP(c.ip))), ", "), strings.Join(c.all, ", "); got != want {
+				t.Errorf("unexpected matches for %s: got %s, want %s", c.ip, got, want)
+			}
+		}
+	}
+
+	if r := table.LookupAll(nil); r != nil {
+		t.Errorf("unexpected matches for nil address: %v", r)
+	}
+}
@@ -0,0 +1,292 @@
+package iputil
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var errInvalidEncoding = errors.New("invalid binary encoding")
+
+// ParseRange parses s as an IP address range in the form returned by
+// Range.String, "first - last". The spaces around the hyphen are
+// optional.
+func ParseRange(s string) (*Range, error) {
+	i := strings.IndexByte(s, '-')
+	if i < 0 {
+		return nil, errors.New("invalid range")
+	}
+
+	first, err := Parse(strings.TrimSpace(s[:i]), 0)
+	if err != nil {
+		return nil, err
+	}
+	last, err := Parse(strings.TrimSpace(s[i+1:]), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRange(first, last)
+}
+
+// MarshalText implements encoding.TextMarshaler. The text form of a
+// range is the same as returned by String.
+func (r *Range) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the
+// syntax of ParseRange.
+func (r *Range) UnmarshalText(text []byte) error {
+	v, err := ParseRange(string(text))
+	if err != nil {
+		return err
+	}
+
+	*r = *v
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. A range is encoded as a JSON
+// string of its text form.
+func (r *Range) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *Range) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	return r.UnmarshalText([]byte(s))
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The binary form of
+// a range is its address family in one byte, followed by its first and
+// last addresses, resulting in 9 bytes for IPv4 and 33 bytes for IPv6.
+func (r *Range) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 1+2*net.IPv6len)
+	b = append(b, byte(r.af))
+	b = append(b, r.First()...)
+	b = append(b, r.Last()...)
+
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (r *Range) UnmarshalBinary(data []byte) error {
+	var n int
+	switch {
+	case len(data) == 1+2*net.IPv4len && data[0] == IPv4:
+		n = net.IPv4len
+	case len(data) == 1+2*net.IPv6len && data[0] == IPv6:
+		n = net.IPv6len
+	default:
+		return errInvalidEncoding
+	}
+
+	v, err := NewRange(net.IP(data[1:1+n]), net.IP(data[1+n:]))
+	if err != nil {
+		return err
+	}
+
+	*r = *v
+	return nil
+}
+
+// An IPNet wraps a *net.IPNet to implement the encoding interfaces,
+// using the CIDR notation as its text form.
+type IPNet struct {
+	*net.IPNet
+}
+
+// prefix returns the address of n as 4 bytes for an IPv4 mask and 16
+// bytes for an IPv6 mask, and its prefix length. It reports false if the
+// mask is not in canonical form or does not fit the address.
+func (n IPNet) prefix() (net.IP, int, bool) {
+	ones, bits := n.Mask.Size()
+	ip := n.IP.To4()
+	if bits == IPv6BitLen {
+		ip = n.IP.To16()
+	}
+	if bits == 0 || ip == nil {
+		return nil, 0, false
+	}
+
+	return ip, ones, true
+}
+
+// MarshalText implements encoding.TextMarshaler. Unlike
+// net.IPNet.String, IPv6 prefixes of IPv4-mapped addresses retain their
+// address family, as in "::ffff:192.168.0.0/120".
+func (n IPNet) MarshalText() ([]byte, error) {
+	if n.IPNet == nil {
+		return []byte{}, nil
+	}
+
+	ip, ones, ok := n.prefix()
+	if !ok {
+		return nil, errInvalidEncoding
+	}
+
+	return []byte(formatCanonical(ip) + "/" + strconv.Itoa(ones)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the CIDR
+// notation as parsed by net.ParseCIDR; host bits are cleared. Empty text
+// results in a nil *net.IPNet.
+func (n *IPNet) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		n.IPNet = nil
+		return nil
+	}
+
+	_, ipnet, err := net.ParseCIDR(string(text))
+	if err != nil {
+		return err
+	}
+
+	n.IPNet = ipnet
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. A nil *net.IPNet is encoded as
+// null.
+func (n IPNet) MarshalJSON() ([]byte, error) {
+	if n.IPNet == nil {
+		return []byte("null"), nil
+	}
+
+	text, err := n.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *IPNet) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		n.IPNet = nil
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	return n.UnmarshalText([]byte(s))
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The binary form of
+// an IPNet is its prefix length in one byte, followed by the 4 or 16
+// bytes of its network address.
+func (n IPNet) MarshalBinary() ([]byte, error) {
+	if n.IPNet == nil {
+		return []byte{}, nil
+	}
+
+	ip, ones, ok := n.prefix()
+	if !ok {
+		return nil, errInvalidEncoding
+	}
+
+	return append([]byte{byte(ones)}, ip.Mask(n.Mask)...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (n *IPNet) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		n.IPNet = nil
+		return nil
+	}
+
+	bits := (len(data) - 1) * 8
+	if bits != IPv4BitLen && bits != IPv6BitLen || int(data[0]) > bits {
+		return errInvalidEncoding
+	}
+
+	mask := net.CIDRMask(int(data[0]), bits)
+	n.IPNet = &net.IPNet{
+		IP:   net.IP(data[1:]).Mask(mask),
+		Mask: mask,
+	}
+
+	return nil
+}
+
+// An IPMask wraps a net.IPMask to implement the encoding interfaces,
+// using the IP address notation of the mask, such as "255.255.255.0" or
+// "ffff:ffff:ffff:ffff::", as its text form. This notation preserves the
+// address family, and can represent non-canonical masks.
+type IPMask net.IPMask
+
+// MarshalText implements encoding.TextMarshaler.
+func (m IPMask) MarshalText() ([]byte, error) {
+	if len(m) == 0 {
+		return []byte{}, nil
+	}
+	if AddressFamily(net.IP(m)) == 0 {
+		return nil, errors.New("invalid mask")
+	}
+
+	return []byte(formatCanonical(net.IP(m))), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *IPMask) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*m = nil
+		return nil
+	}
+
+	ip, err := Parse(string(text), 0)
+	if err != nil {
+		return err
+	}
+
+	*m = IPMask(ip)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m IPMask) MarshalJSON() ([]byte, error) {
+	text, err := m.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *IPMask) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	return m.UnmarshalText([]byte(s))
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The binary form of
+// an IPMask is its 4 or 16 bytes.
+func (m IPMask) MarshalBinary() ([]byte, error) {
+	return append([]byte{}, m...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (m *IPMask) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 && AddressFamily(net.IP(data)) == 0 {
+		return errInvalidEncoding
+	}
+
+	*m = append(IPMask(nil), data...)
+	return nil
+}
@@ -0,0 +1,180 @@
+package iputil
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	cases := []struct {
+		s   string
+		out string
+		err bool
+	}{
+		{"192.168.0.100 - 192.168.0.199", "192.168.0.100 - 192.168.0.199", false},
+		{"192.168.0.100-192.168.0.199", "192.168.0.100 - 192.168.0.199", false},
+		{"2001:db8::1234:0 - 2001:db8::5678:0", "2001:db8::1234:0 - 2001:db8::5678:0", false},
+		{"::ffff:192.168.0.100 - ::ffff:192.168.0.199", "::ffff:192.168.0.100 - ::ffff:192.168.0.199", false},
+		{"192.168.0.100", "", true},
+		{"192.168.0.199 - 192.168.0.100", "", true},
+		{"192.168.0.100 - 2001:db8::1", "", true},
+		{"192.168.0.100 - foo", "", true},
+	}
+
+	for _, c := range cases {
+		r, err := ParseRange(c.s)
+		if (err != nil) != c.err {
+			t.Errorf("unexpected error for %q: %v", c.s, err)
+		}
+		if err == nil && r.String() != c.out {
+			t.Errorf("unexpected range for %q: got %s, want %s", c.s, r, c.out)
+		}
+	}
+}
+
+func TestRangeEncoding(t *testing.T) {
+	type config struct {
+		Range *Range `json:"range"`
+	}
+
+	mapped, err := NewRange(net.ParseIP("192.168.0.100"), net.ParseIP("192.168.0.199"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range []*Range{ipv4Range, ipv6Range, mapped} {
+		data, err := json.Marshal(config{r})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"range":"` + r.String() + `"}`; string(data) != want {
+			t.Errorf("unexpected json: got %s, want %s", data, want)
+		}
+
+		var c config
+		if err := json.Unmarshal(data, &c); err != nil || *c.Range != *r {
+			t.Errorf("unexpected json round trip for %s: got %s, %v", r, c.Range, err)
+		}
+
+		bin, err := r.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := 1 + 2*len(r.First()); len(bin) != want {
+			t.Errorf("unexpected binary length for %s: got %d, want %d", r, len(bin), want)
+		}
+
+		var r2 Range
+		if err := r2.UnmarshalBinary(bin); err != nil || r2.String() != r.String() {
+			t.Errorf("unexpected binary round trip for %s: got %s, %v", r, &r2, err)
+		}
+	}
+
+	var r Range
+	if err := r.UnmarshalBinary([]byte{IPv6, 1, 2, 3, 4, 5, 6, 7, 8}); err == nil {
+		t.Error("error expected for invalid binary encoding")
+	}
+	if err := json.Unmarshal([]byte(`"foo"`), &r); err == nil {
+		t.Error("error expected for invalid json encoding")
+	}
+}
+
+func TestIPNetEncoding(t *testing.T) {
+	type config struct {
+		Net IPNet `json:"net"`
+	}
+
+	for _, cidr := range []string{"192.168.0.0/24", "2001:db8::/32", "0.0.0.0/0"} {
+		_, ipnet, _ := net.ParseCIDR(cidr)
+
+		data, err := json.Marshal(config{IPNet{ipnet}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"net":"` + cidr + `"}`; string(data) != want {
+			t.Errorf("unexpected json: got %s, want %s", data, want)
+		}
+
+		var c config
+		if err := json.Unmarshal(data, &c); err != nil || c.Net.String() != cidr {
+			t.Errorf("unexpected json round trip for %s: got %v, %v", cidr, c.Net, err)
+		}
+
+		bin, err := IPNet{ipnet}.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n IPNet
+		if err := n.UnmarshalBinary(bin); err != nil || n.String() != cidr {
+			t.Errorf("unexpected binary round trip for %s: got %v, %v", cidr, n, err)
+		}
+	}
+
+	// An IPv4-mapped prefix remains an IPv6 prefix.
+	mapped := &net.IPNet{IP: net.ParseIP("192.168.0.0"), Mask: net.CIDRMask(120, IPv6BitLen)}
+	data, err := json.Marshal(config{IPNet{mapped}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"net":"::ffff:192.168.0.0/120"}`; string(data) != want {
+		t.Errorf("unexpected json: got %s, want %s", data, want)
+	}
+	var m config
+	if err := json.Unmarshal(data, &m); err != nil || !m.Net.IP.Equal(mapped.IP) || m.Net.Mask.String() != mapped.Mask.String() {
+		t.Errorf("unexpected json round trip for %s: got %v, %v", data, m.Net, err)
+	}
+
+	var c config
+	if err := json.Unmarshal([]byte(`{"net":"192.168.0.1/24"}`), &c); err != nil || c.Net.String() != "192.168.0.0/24" {
+		t.Errorf("unexpected result for non-canonical cidr: %v, %v", c.Net, err)
+	}
+	if err := json.Unmarshal([]byte(`{"net":null}`), &c); err != nil || c.Net.IPNet != nil {
+		t.Errorf("unexpected result for null: %v, %v", c.Net, err)
+	}
+	if data, _ := json.Marshal(c); string(data) != `{"net":null}` {
+		t.Errorf("unexpected json for nil net: %s", data)
+	}
+	if err := json.Unmarshal([]byte(`{"net":"192.168.0.0/33"}`), &c); err == nil {
+		t.Error("error expected for invalid cidr")
+	}
+}
+
+func TestIPMaskEncoding(t *testing.T) {
+	cases := []struct {
+		mask net.IPMask
+		text string
+	}{
+		{net.CIDRMask(24, IPv4BitLen), "255.255.255.0"},
+		{net.CIDRMask(64, IPv6BitLen), "ffff:ffff:ffff:ffff::"},
+		{net.IPv4Mask(255, 0, 255, 0), "255.0.255.0"},
+	}
+
+	for _, c := range cases {
+		text, err := IPMask(c.mask).MarshalText()
+		if err != nil || string(text) != c.text {
+			t.Errorf("unexpected text for %s: got %s, %v", c.mask, text, err)
+		}
+
+		var m IPMask
+		if err := m.UnmarshalText(text); err != nil || !bytes.Equal(m, c.mask) {
+			t.Errorf("unexpected text round trip for %s: got %s, %v", c.mask, net.IPMask(m), err)
+		}
+
+		data, err := json.Marshal(IPMask(c.mask))
+		if err != nil || string(data) != `"`+c.text+`"` {
+			t.Errorf("unexpected json for %s: got %s, %v", c.mask, data, err)
+		}
+
+		bin, _ := IPMask(c.mask).MarshalBinary()
+		if err := m.UnmarshalBinary(bin); err != nil || !bytes.Equal(m, c.mask) {
+			t.Errorf("unexpected binary round trip for %s: got %s, %v", c.mask, net.IPMask(m), err)
+		}
+	}
+
+	var m IPMask
+	if err := m.UnmarshalBinary([]byte{1, 2, 3}); err == nil {
+		t.Error("error expected for invalid binary encoding")
+	}
+}
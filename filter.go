@@ -0,0 +1,134 @@
+package iputil
+
+import "errors"
+
+var errNoAddr = errors.New("no suitable address")
+
+// An AddrFilter reports whether an interface address should be kept.
+// The predicate methods of InterfaceAddr, such as (*InterfaceAddr).IsUp,
+// can be used as filters directly.
+type AddrFilter func(addr *InterfaceAddr) bool
+
+// FilterAddrs returns the addresses in addrs that satisfy all of the
+// filters, in their original order.
+func FilterAddrs(addrs []*InterfaceAddr, filters ...AddrFilter) []*InterfaceAddr {
+	var results []*InterfaceAddr
+
+next:
+	for _, addr := range addrs {
+		for _, f := range filters {
+			if !f(addr) {
+				continue next
+			}
+		}
+
+		results = append(results, addr)
+	}
+
+	return results
+}
+
+// Not returns a filter that keeps the addresses rejected by f.
+func Not(f AddrFilter) AddrFilter {
+	return func(addr *InterfaceAddr) bool {
+		return !f(addr)
+	}
+}
+
+// Family returns a filter that keeps addresses of family af, 1 for IPv4
+// and 2 for IPv6. IPv4-mapped IPv6 addresses are considered IPv4.
+func Family(af uint) AddrFilter {
+	return func(addr *InterfaceAddr) bool {
+		return addr.Family() == af
+	}
+}
+
+// InterfaceName returns a filter that keeps addresses whose interface
+// name matches any of the patterns, in the syntax of path.Match.
+func InterfaceName(patterns ...string) AddrFilter {
+	return func(addr *InterfaceAddr) bool {
+		return matchName(addr.InterfaceName, patterns)
+	}
+}
+
+// A Policy scores interface addresses for default address selection.
+// The address with the highest score is preferred, and addresses with a
+// negative score are never selected.
+type Policy func(addr *InterfaceAddr) int
+
+// DefaultPolicy is the Policy used by DefaultIPv4 and DefaultIPv6. It
+// excludes addresses that are unusable, namely those that are loopback,
+// on interfaces that are down, or tentative, and prefers, in decreasing
+// order of importance:
+//
+//   - global addresses over unique local addresses, and those over
+//     link-local addresses;
+//   - addresses on physical interfaces over those on VirtualInterfaces;
+//   - addresses that are not deprecated;
+//   - addresses on interfaces that are not point-to-point;
+//   - stable addresses over temporary ones.
+//
+// Ties are broken by interface order.
+func DefaultPolicy(addr *InterfaceAddr) int {
+	if !addr.IsUp() || addr.IsLoopback() || addr.IsTentative() || addr.IP.IsUnspecified() {
+		return -1
+	}
+
+	score := 0
+	switch {
+	case addr.IsLinkLocal():
+	case addr.IsUniqueLocal():
+		score += 1 << 5
+	default:
+		score += 2 << 5
+	}
+	if !addr.IsVirtual() {
+		score += 1 << 3
+	}
+	if !addr.IsDeprecated() {
+		score += 1 << 2
+	}
+	if !addr.IsPointToPoint() {
+		score += 1 << 1
+	}
+	if !addr.IsTemporary() {
+		score += 1
+	}
+
+	return score
+}
+
+// SelectAddr returns the address in addrs with the highest score under
+// policy. Of addresses with the same score, the first one is returned.
+// It returns an error if no address has a non-negative score.
+func SelectAddr(addrs []*InterfaceAddr, policy Policy) (*InterfaceAddr, error) {
+	var best *InterfaceAddr
+	var bestScore int
+
+	for _, addr := range addrs {
+		score := policy(addr)
+		if score < 0 {
+			continue
+		}
+		if best == nil || score > bestScore {
+			best, bestScore = addr, score
+		}
+	}
+
+	if best == nil {
+		return nil, errNoAddr
+	}
+
+	return best, nil
+}
+
+// DefaultAddr returns the system's interface address of family af, 1
+// for IPv4 and 2 for IPv6, preferred by policy.
+func DefaultAddr(af uint, policy Policy) (*InterfaceAddr, error) {
+	addrs, err := InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+
+	return SelectAddr(FilterAddrs(addrs, Family(af)), policy)
+}
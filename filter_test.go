@@ -0,0 +1,138 @@
+package iputil
+
+import (
+	"net"
+	"testing"
+)
+
+// testAddrs are interface addresses of a typical host with containers.
+var testAddrs = []*InterfaceAddr{
+	newTestAddr("lo", "127.0.0.1/8", net.FlagUp|net.FlagLoopback, 0),
+	newTestAddr("lo", "::1/128", net.FlagUp|net.FlagLoopback, AddrPermanent),
+	newTestAddr("docker0", "172.17.0.1/16", net.FlagUp|net.FlagBroadcast, 0),
+	newTestAddr("eth0", "192.168.1.10/24", net.FlagUp|net.FlagBroadcast, 0),
+	newTestAddr("eth0", "fe80::1/64", net.FlagUp|net.FlagBroadcast, AddrPermanent),
+	newTestAddr("eth0", "2001:db8::1122:3344/64", net.FlagUp|net.FlagBroadcast, AddrTemporary),
+	newTestAddr("eth0", "2001:db8::10/64", net.FlagUp|net.FlagBroadcast, 0),
+	newTestAddr("eth0", "fd00::10/64", net.FlagUp|net.FlagBroadcast, 0),
+	newTestAddr("eth1", "10.0.0.10/8", net.FlagBroadcast, 0),
+	newTestAddr("tun0", "10.8.0.2/32", net.FlagUp|net.FlagPointToPoint, 0),
+}
+
+func newTestAddr(name, cidr string, flags net.Flags, addrFlags AddrFlags) *InterfaceAddr {
+	ip, ipnet, _ := net.ParseCIDR(cidr)
+	ipnet.IP = ip
+
+	return &InterfaceAddr{IPNet: ipnet, InterfaceName: name, Flags: flags, AddrFlags: addrFlags}
+}
+
+func addrStrings(addrs []*InterfaceAddr) []string {
+	var s []string
+	for _, addr := range addrs {
+		s = append(s, addr.InterfaceName+" "+addr.IP.String())
+	}
+
+	return s
+}
+
+func TestFilterAddrs(t *testing.T) {
+	cases := []struct {
+		filters []AddrFilter
+		out     []string
+	}{
+		{
+			[]AddrFilter{Family(IPv4), (*InterfaceAddr).IsUp, Not((*InterfaceAddr).IsLoopback)},
+			[]string{"docker0 172.17.0.1", "eth0 192.168.1.10", "tun0 10.8.0.2"},
+		},
+		{
+			[]AddrFilter{Family(IPv6), Not((*InterfaceAddr).IsLinkLocal), Not((*InterfaceAddr).IsTemporary)},
+			[]string{"lo ::1", "eth0 2001:db8::10", "eth0 fd00::10"},
+		},
+		{
+			[]AddrFilter{(*InterfaceAddr).IsUniqueLocal},
+			[]string{"eth0 fd00::10"},
+		},
+		{
+			[]AddrFilter{(*InterfaceAddr).IsVirtual},
+			[]string{"docker0 172.17.0.1"},
+		},
+		{
+			[]AddrFilter{InterfaceName("eth*", "tun*"), (*InterfaceAddr).IsPointToPoint},
+			[]string{"tun0 10.8.0.2"},
+		},
+		{
+			[]AddrFilter{Not((*InterfaceAddr).IsUp)},
+			[]string{"eth1 10.0.0.10"},
+		},
+		{
+			[]AddrFilter{Family(0)},
+			nil,
+		},
+	}
+
+	for i, c := range cases {
+		out := addrStrings(FilterAddrs(testAddrs, c.filters...))
+		if len(out) != len(c.out) {
+			t.Errorf("unexpected result for case %d: got %v, want %v", i, out, c.out)
+			continue
+		}
+		for j := range out {
+			if out[j] != c.out[j] {
+				t.Errorf("unexpected result for case %d: got %v, want %v", i, out, c.out)
+				break
+			}
+		}
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	cases := []struct {
+		af  uint
+		out string
+	}{
+		{IPv4, "eth0 192.168.1.10"},
+		{IPv6, "eth0 2001:db8::10"},
+	}
+
+	for _, c := range cases {
+		addr, err := SelectAddr(FilterAddrs(testAddrs, Family(c.af)), DefaultPolicy)
+		if err != nil {
+			t.Errorf("unexpected error for family %d: %v", c.af, err)
+			continue
+		}
+		if out := addrStrings([]*InterfaceAddr{addr})[0]; out != c.out {
+			t.Errorf("unexpected address for family %d: got %s, want %s", c.af, out, c.out)
+		}
+	}
+
+	deprecated := newTestAddr("eth0", "2001:db8::20/64", net.FlagUp, AddrDeprecated)
+	linkLocal := newTestAddr("eth0", "fe80::1/64", net.FlagUp, 0)
+	if DefaultPolicy(deprecated) <= DefaultPolicy(linkLocal) {
+		t.Error("deprecated global address should be preferred to link-local address")
+	}
+
+	tentative := newTestAddr("eth0", "2001:db8::30/64", net.FlagUp, AddrTentative)
+	if DefaultPolicy(tentative) >= 0 {
+		t.Error("tentative address should be excluded")
+	}
+}
+
+func TestSelectAddr(t *testing.T) {
+	// A custom policy preferring the VPN tunnel.
+	policy := func(addr *InterfaceAddr) int {
+		if addr.IsPointToPoint() {
+			return 100
+		}
+		return DefaultPolicy(addr)
+	}
+
+	addr, err := SelectAddr(testAddrs, policy)
+	if err != nil || addr.InterfaceName != "tun0" {
+		t.Errorf("unexpected address: got %v, %v", addr, err)
+	}
+
+	loopback := FilterAddrs(testAddrs, (*InterfaceAddr).IsLoopback)
+	if _, err := SelectAddr(loopback, DefaultPolicy); err == nil {
+		t.Error("error expected for loopback addresses only")
+	}
+}
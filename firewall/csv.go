@@ -0,0 +1,25 @@
+package firewall
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// WriteCSV writes the set as CSV with a header row, and a row for each
+// prefix or range with its first and last address. The prefix column is
+// empty for ranges that are not prefixes.
+func WriteCSV(w io.Writer, s *Set) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"first", "last", "prefix"})
+
+	for _, e := range s.elements(true) {
+		prefix := ""
+		if e.r == nil {
+			prefix = e.prefix.String()
+		}
+		cw.Write([]string{e.first().String(), e.last().String(), prefix})
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
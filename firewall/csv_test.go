@@ -0,0 +1,23 @@
+package firewall
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	s := testSet(t, "2001:db8::/32", "10.0.0.1-10.0.0.6", "192.0.2.0/24", "192.0.3.0/24")
+
+	var b strings.Builder
+	if err := WriteCSV(&b, s); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "first,last,prefix\n" +
+		"10.0.0.1,10.0.0.6,\n" +
+		"192.0.2.0,192.0.3.255,192.0.2.0/23\n" +
+		"2001:db8::,2001:db8:ffff:ffff:ffff:ffff:ffff:ffff,2001:db8::/32\n"
+	if b.String() != want {
+		t.Errorf("unexpected output: got %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,172 @@
+package firewall
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ericyan/iputil"
+	"github.com/ericyan/iputil/uint128"
+)
+
+// IPSetType is the type of an ipset.
+type IPSetType string
+
+// Supported ipset types.
+const (
+	HashNet  IPSetType = "hash:net"
+	BitmapIP IPSetType = "bitmap:ip"
+)
+
+// Limits of ipset.
+const (
+	// defaultMaxElem is the default maximum number of elements of a hash
+	// set.
+	defaultMaxElem = 65536
+
+	// maxBitmapSize is the maximum number of addresses of a bitmap set.
+	maxBitmapSize = 65536
+)
+
+// WriteIPSet writes the set as a script for "ipset restore", which
+// creates an ipset of the given name and type, and adds the set to it.
+//
+// A hash:net ipset holds prefixes of either family, other than those of
+// length zero, which are written as their two halves. A bitmap:ip ipset
+// holds ranges of IPv4 addresses, spanning no more than 65536 addresses.
+func WriteIPSet(w io.Writer, name string, typ IPSetType, s *Set) error {
+	if err := checkName(name, ipsetMaxNameLen); err != nil {
+		return err
+	}
+
+	afs := s.families()
+	if len(afs) > 1 {
+		return errors.New("firewall: ipset with both IPv4 and IPv6 addresses")
+	}
+
+	var create string
+	var elems []element
+	switch typ {
+	case HashNet:
+		family := "inet"
+		if len(afs) == 1 && afs[0] == iputil.IPv6 {
+			family = "inet6"
+		}
+
+		elems = splitDefault(s.elements(false))
+		create = fmt.Sprintf("create %s %s family %s", name, typ, family)
+		if len(elems) > defaultMaxElem {
+			create += fmt.Sprintf(" maxelem %d", len(elems))
+		}
+	case BitmapIP:
+		if len(afs) == 0 || afs[0] != iputil.IPv4 {
+			return errors.New("firewall: bitmap:ip ipset without IPv4 addresses")
+		}
+
+		spans := s.spans[index(iputil.IPv4)]
+		first, last := spans[0].first, spans[len(spans)-1].last
+		if size := last.Sub(first); !size.IsLessThan(uint128.NewFromUint64(maxBitmapSize)) {
+			return errors.New("firewall: bitmap:ip ipset spanning more than 65536 addresses")
+		}
+
+		elems = s.elements(true)
+		span := element{r: newRange(iputil.IPv4, first, last)}
+		create = fmt.Sprintf("create %s %s range %s", name, typ, span)
+	default:
+		return fmt.Errorf("firewall: unsupported ipset type %q", typ)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, create)
+	for _, e := range elems {
+		fmt.Fprintf(bw, "add %s %s\n", name, e)
+	}
+
+	return bw.Flush()
+}
+
+// splitDefault returns elems with any prefix of length zero, which
+// hash:net ipsets reject, replaced by the two prefixes of length one.
+func splitDefault(elems []element) []element {
+	var split []element
+	for _, e := range elems {
+		if e.r != nil || e.prefix.Bits() > 0 {
+			split = append(split, e)
+			continue
+		}
+
+		addr := e.prefix.Addr()
+		high, _ := iputil.AddrFromInt(uint128.One.Lsh(uint(addr.BitLen()-1)), addr.Family())
+		for _, a := range []iputil.Addr{addr, high} {
+			p, _ := iputil.NewPrefix(a, 1)
+			split = append(split, element{prefix: p})
+		}
+	}
+
+	return split
+}
+
+// ParseIPSet parses the output of "ipset save", and returns the sets of
+// addresses by name. Only ipsets of the hash:ip, hash:net and bitmap:ip
+// types are returned; the others, such as hash:net,port, are skipped.
+// Elements with the nomatch option exclude their addresses from their
+// sets. As in hash:net ipsets, the most specific element that contains
+// an address decides whether it is in the set.
+func ParseIPSet(r io.Reader) (map[string]*Set, error) {
+	types := make(map[string]string)
+	tables := make(map[string]*matchTable)
+
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("firewall: line %d: too few fields", line)
+		}
+
+		name := fields[1]
+		switch fields[0] {
+		case "create":
+			types[name] = fields[2]
+			switch fields[2] {
+			case "hash:ip", string(HashNet), string(BitmapIP):
+				tables[name] = newMatchTable()
+			}
+		case "add":
+			typ, ok := types[name]
+			if !ok {
+				return nil, fmt.Errorf("firewall: line %d: unknown ipset %q", line, name)
+			}
+			table, ok := tables[name]
+			if !ok {
+				continue
+			}
+
+			nomatch := false
+			for _, opt := range fields[3:] {
+				if opt == "nomatch" {
+					nomatch = true
+				}
+			}
+			if err := table.add(fields[2], nomatch); err != nil {
+				return nil, fmt.Errorf("firewall: line %d: %s in %s ipset", line, err, typ)
+			}
+		default:
+			return nil, fmt.Errorf("firewall: line %d: unknown command %q", line, fields[0])
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	sets := make(map[string]*Set)
+	for name, table := range tables {
+		sets[name] = table.set()
+	}
+
+	return sets, nil
+}
@@ -0,0 +1,149 @@
+package firewall
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteIPSet(t *testing.T) {
+	cases := []struct {
+		typ  IPSetType
+		in   []string
+		out  string
+		fail bool
+	}{
+		{HashNet, nil, "create blocked hash:net family inet\n", false},
+		{
+			HashNet,
+			[]string{"10.0.0.1-10.0.0.6", "192.0.2.0/24"},
+			"create blocked hash:net family inet\n" +
+				"add blocked 10.0.0.1\n" +
+				"add blocked 10.0.0.2/31\n" +
+				"add blocked 10.0.0.4/31\n" +
+				"add blocked 10.0.0.6\n" +
+				"add blocked 192.0.2.0/24\n",
+			false,
+		},
+		{
+			HashNet,
+			[]string{"2001:db8::/32"},
+			"create blocked hash:net family inet6\n" +
+				"add blocked 2001:db8::/32\n",
+			false,
+		},
+		{HashNet, []string{"10.0.0.0/8", "2001:db8::/32"}, "", true},
+		{
+			HashNet,
+			[]string{"0.0.0.0-255.255.255.255"},
+			"create blocked hash:net family inet\n" +
+				"add blocked 0.0.0.0/1\n" +
+				"add blocked 128.0.0.0/1\n",
+			false,
+		},
+		{
+			HashNet,
+			[]string{"::/0"},
+			"create blocked hash:net family inet6\n" +
+				"add blocked ::/1\n" +
+				"add blocked 8000::/1\n",
+			false,
+		},
+		{
+			BitmapIP,
+			[]string{"10.0.0.1-10.0.0.6", "10.0.1.0/24", "10.0.2.7/32"},
+			"create blocked bitmap:ip range 10.0.0.1-10.0.2.7\n" +
+				"add blocked 10.0.0.1-10.0.0.6\n" +
+				"add blocked 10.0.1.0/24\n" +
+				"add blocked 10.0.2.7\n",
+			false,
+		},
+		{BitmapIP, []string{"10.0.0.0/16"}, "create blocked bitmap:ip range 10.0.0.0-10.0.255.255\nadd blocked 10.0.0.0/16\n", false},
+		{BitmapIP, []string{"10.0.0.0/16", "10.1.0.0/32"}, "", true},
+		{BitmapIP, []string{"2001:db8::/120"}, "", true},
+		{BitmapIP, nil, "", true},
+		{"hash:ip", nil, "", true},
+	}
+
+	for _, c := range cases {
+		var b strings.Builder
+		err := WriteIPSet(&b, "blocked", c.typ, testSet(t, c.in...))
+		if c.fail {
+			if err == nil {
+				t.Errorf("expected error for %s %v", c.typ, c.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %s %v: %s", c.typ, c.in, err)
+			continue
+		}
+
+		if b.String() != c.out {
+			t.Errorf("unexpected output for %s %v: got %q, want %q", c.typ, c.in, b.String(), c.out)
+		}
+	}
+}
+
+func TestWriteIPSetName(t *testing.T) {
+	for _, name := range []string{"", "a b", "a\nflush", strings.Repeat("a", 32)} {
+		if err := WriteIPSet(io.Discard, name, HashNet, new(Set)); err == nil {
+			t.Errorf("expected error for name %q", name)
+		}
+	}
+}
+
+func TestParseIPSet(t *testing.T) {
+	f, err := os.Open("testdata/ipset.save")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	sets, err := ParseIPSet(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"blocked":  {"192.0.2.0/24", "198.51.100.0/25", "203.0.113.7/32"},
+		"blocked6": {"2001:db8::/32"},
+		"lab":      {"10.0.0.1/32", "10.0.0.2/31"},
+	}
+	if len(sets) != len(want) {
+		t.Errorf("unexpected number of sets: got %d, want %d", len(sets), len(want))
+	}
+	for name, prefixes := range want {
+		if got := testPrefixes(sets[name]); fmt.Sprint(got) != fmt.Sprint(prefixes) {
+			t.Errorf("unexpected prefixes of %s: got %v, want %v", name, got, prefixes)
+		}
+	}
+
+	// The most specific element decides, whatever the order of elements.
+	nested := []string{"10.0.0.0/16", "10.1.2.0/24", "10.2.0.0/15", "10.4.0.0/14", "10.8.0.0/13", "10.16.0.0/12", "10.32.0.0/11", "10.64.0.0/10", "10.128.0.0/9"}
+	for _, in := range []string{
+		"create nested hash:net\nadd nested 10.0.0.0/8\nadd nested 10.1.0.0/16 nomatch\nadd nested 10.1.2.0/24\n",
+		"create nested hash:net\nadd nested 10.1.2.0/24\nadd nested 10.1.0.0/16 nomatch\nadd nested 10.0.0.0/8\n",
+	} {
+		sets, err := ParseIPSet(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := testPrefixes(sets["nested"]); fmt.Sprint(got) != fmt.Sprint(nested) {
+			t.Errorf("unexpected prefixes for %q: got %v, want %v", in, got, nested)
+		}
+	}
+
+	for _, in := range []string{
+		"add blocked 192.0.2.0/24\n",
+		"create blocked hash:net\nadd blocked 192.0.2.0/33\n",
+		"create blocked hash:net\nadd blocked\n",
+		"flush blocked\n",
+	} {
+		if _, err := ParseIPSet(strings.NewReader(in)); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+}
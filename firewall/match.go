@@ -0,0 +1,78 @@
+package firewall
+
+import (
+	"github.com/ericyan/iputil"
+	"github.com/ericyan/iputil/internal/radix"
+)
+
+// A matchEntry is an entry of a matchTable.
+type matchEntry struct {
+	prefix iputil.Prefix
+	match  bool
+}
+
+// A matchTable holds the entries of a pf table or an ipset, each of
+// which matches its addresses or, if negated, does not. As in pf and
+// hash:net ipsets, the most specific entry that contains an address
+// decides whether it matches, whatever the order of the entries.
+type matchTable struct {
+	tree    *radix.Tree
+	entries []*matchEntry
+}
+
+func newMatchTable() *matchTable {
+	return &matchTable{tree: radix.NewTree()}
+}
+
+// add adds the element e, written as for Set.addElement, as entries
+// that match its addresses, or do not if negated is true. An entry
+// replaces any earlier entry of the same prefix.
+func (t *matchTable) add(e string, negated bool) error {
+	var s Set
+	if err := s.addElement(e); err != nil {
+		return err
+	}
+
+	for _, p := range s.Prefixes() {
+		key, n := radix.PrefixKey(p)
+		if v, err := t.tree.GetPrefix(key, n); err == nil {
+			v.(*matchEntry).match = !negated
+			continue
+		}
+
+		entry := &matchEntry{p, !negated}
+		t.tree.SetPrefix(key, n, entry)
+		t.entries = append(t.entries, entry)
+	}
+
+	return nil
+}
+
+// set returns the set of the addresses that match. These are the
+// addresses of each matching entry, except those of the more specific
+// entries it contains, which decide for themselves.
+func (t *matchTable) set() *Set {
+	s := new(Set)
+	for _, e := range t.entries {
+		if !e.match {
+			continue
+		}
+
+		var own, nested Set
+		own.AddPrefix(e.prefix)
+
+		key, n := radix.PrefixKey(e.prefix)
+		values, _ := t.tree.Overlapping(key, n)
+		for _, v := range values {
+			if o := v.(*matchEntry); o.prefix.Bits() > e.prefix.Bits() {
+				nested.AddPrefix(o.prefix)
+			}
+		}
+
+		for i, spans := range own.Difference(&nested).spans {
+			s.spans[i] = append(s.spans[i], spans...)
+		}
+	}
+
+	return s
+}
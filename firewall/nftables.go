@@ -0,0 +1,140 @@
+package firewall
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ericyan/iputil"
+)
+
+// WriteNFTSet writes the set as the definition of an nftables set of the
+// given name, with the interval flag so that it holds prefixes and
+// ranges. The definition goes in a table of the ip, ip6 or inet family.
+func WriteNFTSet(w io.Writer, name string, s *Set) error {
+	if err := checkName(name, nftMaxNameLen); err != nil {
+		return err
+	}
+
+	afs := s.families()
+	if len(afs) > 1 {
+		return errors.New("firewall: nftables set with both IPv4 and IPv6 addresses")
+	}
+
+	typ := "ipv4_addr"
+	if len(afs) == 1 && afs[0] == iputil.IPv6 {
+		typ = "ipv6_addr"
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "set %s {\n", name)
+	fmt.Fprintf(bw, "\ttype %s\n", typ)
+	fmt.Fprintf(bw, "\tflags interval\n")
+
+	// An empty list of elements is a syntax error.
+	if elems := s.elements(true); len(elems) > 0 {
+		fmt.Fprintf(bw, "\telements = {")
+		for i, e := range elems {
+			sep := ","
+			if i == len(elems)-1 {
+				sep = " }"
+			}
+			fmt.Fprintf(bw, "\n\t\t%s%s", e, sep)
+		}
+		fmt.Fprintln(bw)
+	}
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+// ParseNFTSets parses the JSON output of "nft -j list set" or "nft -j
+// list sets", and returns the sets of addresses by name. Sets of other
+// types than ipv4_addr and ipv6_addr are skipped.
+func ParseNFTSets(r io.Reader) (map[string]*Set, error) {
+	var v struct {
+		Nftables []struct {
+			Set *struct {
+				Name string            `json:"name"`
+				Type json.RawMessage   `json:"type"`
+				Elem []json.RawMessage `json:"elem"`
+			} `json:"set"`
+		} `json:"nftables"`
+	}
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, err
+	}
+	if v.Nftables == nil {
+		return nil, errors.New("firewall: no nftables objects")
+	}
+
+	sets := make(map[string]*Set)
+	for _, obj := range v.Nftables {
+		if obj.Set == nil {
+			continue
+		}
+
+		// The type of a set of concatenations is an array.
+		var typ string
+		json.Unmarshal(obj.Set.Type, &typ)
+		if typ != "ipv4_addr" && typ != "ipv6_addr" {
+			continue
+		}
+
+		name := obj.Set.Name
+		if _, ok := sets[name]; ok {
+			return nil, fmt.Errorf("firewall: more than one nftables set named %q", name)
+		}
+
+		set := new(Set)
+		for _, raw := range obj.Set.Elem {
+			e, err := parseNFTElem(raw)
+			if err != nil {
+				return nil, fmt.Errorf("firewall: nftables set %q: %s", name, err)
+			}
+			if err := set.addElement(e); err != nil {
+				return nil, fmt.Errorf("firewall: nftables set %q: %s", name, err)
+			}
+		}
+		sets[name] = set
+	}
+
+	return sets, nil
+}
+
+// parseNFTElem returns the text form of an element of a set in JSON,
+// which is an address, a prefix or range object, or an elem object for
+// an element with options such as a timeout.
+func parseNFTElem(raw json.RawMessage) (string, error) {
+	var addr string
+	if err := json.Unmarshal(raw, &addr); err == nil {
+		return addr, nil
+	}
+
+	var v struct {
+		Prefix *struct {
+			Addr string `json:"addr"`
+			Len  int    `json:"len"`
+		} `json:"prefix"`
+		Range []string `json:"range"`
+		Elem  *struct {
+			Val json.RawMessage `json:"val"`
+		} `json:"elem"`
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", err
+	}
+
+	switch {
+	case v.Prefix != nil:
+		return fmt.Sprintf("%s/%d", v.Prefix.Addr, v.Prefix.Len), nil
+	case len(v.Range) == 2:
+		return v.Range[0] + "-" + v.Range[1], nil
+	case v.Elem != nil:
+		return parseNFTElem(v.Elem.Val)
+	default:
+		return "", fmt.Errorf("unsupported element %s", raw)
+	}
+}
@@ -0,0 +1,106 @@
+package firewall
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteNFTSet(t *testing.T) {
+	cases := []struct {
+		in   []string
+		out  string
+		fail bool
+	}{
+		{nil, "set blocked {\n\ttype ipv4_addr\n\tflags interval\n}\n", false},
+		{
+			[]string{"10.0.0.1-10.0.0.6", "192.0.2.0/24", "198.51.100.7/32"},
+			"set blocked {\n" +
+				"\ttype ipv4_addr\n" +
+				"\tflags interval\n" +
+				"\telements = {\n" +
+				"\t\t10.0.0.1-10.0.0.6,\n" +
+				"\t\t192.0.2.0/24,\n" +
+				"\t\t198.51.100.7 }\n" +
+				"}\n",
+			false,
+		},
+		{
+			[]string{"2001:db8::/32"},
+			"set blocked {\n" +
+				"\ttype ipv6_addr\n" +
+				"\tflags interval\n" +
+				"\telements = {\n" +
+				"\t\t2001:db8::/32 }\n" +
+				"}\n",
+			false,
+		},
+		{[]string{"10.0.0.0/8", "2001:db8::/32"}, "", true},
+	}
+
+	for _, c := range cases {
+		var b strings.Builder
+		err := WriteNFTSet(&b, "blocked", testSet(t, c.in...))
+		if c.fail {
+			if err == nil {
+				t.Errorf("expected error for %v", c.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %v: %s", c.in, err)
+			continue
+		}
+
+		if b.String() != c.out {
+			t.Errorf("unexpected output for %v: got %q, want %q", c.in, b.String(), c.out)
+		}
+	}
+}
+
+func TestWriteNFTSetName(t *testing.T) {
+	for _, name := range []string{"", "a b", "a }", "a\nflush ruleset"} {
+		if err := WriteNFTSet(io.Discard, name, new(Set)); err == nil {
+			t.Errorf("expected error for name %q", name)
+		}
+	}
+}
+
+func TestParseNFTSets(t *testing.T) {
+	f, err := os.Open("testdata/nft.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	sets, err := ParseNFTSets(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"blocked":  {"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6/32", "192.0.2.0/24", "198.51.100.0/24", "203.0.113.7/32"},
+		"blocked6": {"2001:db8::/32"},
+	}
+	if len(sets) != len(want) {
+		t.Errorf("unexpected number of sets: got %d, want %d", len(sets), len(want))
+	}
+	for name, prefixes := range want {
+		if got := testPrefixes(sets[name]); fmt.Sprint(got) != fmt.Sprint(prefixes) {
+			t.Errorf("unexpected prefixes of %s: got %v, want %v", name, got, prefixes)
+		}
+	}
+
+	for _, in := range []string{
+		`{}`,
+		`{"nftables": [{"set": {"name": "a", "type": "ipv4_addr", "elem": ["192.0.2.256"]}}]}`,
+		`{"nftables": [{"set": {"name": "a", "type": "ipv4_addr", "elem": [{"set": []}]}}]}`,
+		`{"nftables": [{"set": {"name": "a", "type": "ipv4_addr"}}, {"set": {"name": "a", "type": "ipv6_addr"}}]}`,
+	} {
+		if _, err := ParseNFTSets(strings.NewReader(in)); err == nil {
+			t.Errorf("expected error for %s", in)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package firewall
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WritePFTable writes the set as the definition of a persistent pf table
+// of the given name, for pf.conf. Tables hold prefixes of both families,
+// but no ranges.
+func WritePFTable(w io.Writer, name string, s *Set) error {
+	if err := checkName(name, pfMaxNameLen); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "table <%s> persist {", name)
+
+	elems := s.elements(false)
+	for i, e := range elems {
+		sep := ","
+		if i == len(elems)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(bw, " \\\n\t%s%s", e, sep)
+	}
+	if len(elems) > 0 {
+		fmt.Fprint(bw, " \\\n")
+	}
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+// ParsePFTable parses the output of "pfctl -t name -T show", with or
+// without the -v flag for statistics. Negated entries, such as
+// "!192.0.2.1", exclude their addresses from the set. As in pf, the most
+// specific entry that contains an address decides whether it is in the
+// set.
+func ParsePFTable(r io.Reader) (*Set, error) {
+	table := newMatchTable()
+
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := s.Text()
+		if strings.HasPrefix(text, "\t") {
+			// Statistics of the previous entry.
+			continue
+		}
+
+		e := strings.TrimSpace(text)
+		if e == "" {
+			continue
+		}
+
+		negated := e[0] == '!'
+		if negated {
+			e = e[1:]
+		}
+		if err := table.add(e, negated); err != nil {
+			return nil, fmt.Errorf("firewall: line %d: %s", line, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return table.set(), nil
+}
@@ -0,0 +1,94 @@
+package firewall
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWritePFTable(t *testing.T) {
+	cases := []struct {
+		in  []string
+		out string
+	}{
+		{nil, "table <blocked> persist {}\n"},
+		{
+			[]string{"2001:db8::/32", "10.0.0.1-10.0.0.3", "192.0.2.0/24"},
+			"table <blocked> persist { \\\n" +
+				"\t10.0.0.1, \\\n" +
+				"\t10.0.0.2/31, \\\n" +
+				"\t192.0.2.0/24, \\\n" +
+				"\t2001:db8::/32 \\\n" +
+				"}\n",
+		},
+	}
+
+	for _, c := range cases {
+		var b strings.Builder
+		if err := WritePFTable(&b, "blocked", testSet(t, c.in...)); err != nil {
+			t.Errorf("unexpected error for %v: %s", c.in, err)
+			continue
+		}
+
+		if b.String() != c.out {
+			t.Errorf("unexpected output for %v: got %q, want %q", c.in, b.String(), c.out)
+		}
+	}
+}
+
+func TestWritePFTableName(t *testing.T) {
+	for _, name := range []string{"", "a b", "a>", "a> persist {}\npass"} {
+		if err := WritePFTable(io.Discard, name, new(Set)); err == nil {
+			t.Errorf("expected error for name %q", name)
+		}
+	}
+}
+
+func TestParsePFTable(t *testing.T) {
+	f, err := os.Open("testdata/pf.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	set, err := ParsePFTable(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"10.0.0.0/16", "10.2.0.0/15", "10.4.0.0/14", "10.8.0.0/13", "10.16.0.0/12", "10.32.0.0/11", "10.64.0.0/10", "10.128.0.0/9", "192.0.2.1/32", "2001:db8::/32"}
+	if got := testPrefixes(set); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("unexpected prefixes: got %v, want %v", got, want)
+	}
+
+	// The live table is missing 192.0.2.2, and has 192.0.2.1 in excess.
+	intended := testSet(t, "10.0.0.0/8", "192.0.2.2", "2001:db8::/32")
+	missing, excess := intended.Difference(set), set.Difference(intended)
+	if got, want := testPrefixes(missing), []string{"10.1.0.0/16", "192.0.2.2/32"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("unexpected missing prefixes: got %v, want %v", got, want)
+	}
+	if got, want := testPrefixes(excess), []string{"192.0.2.1/32"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("unexpected excess prefixes: got %v, want %v", got, want)
+	}
+
+	// The most specific entry decides, whatever the order of entries.
+	nested := []string{"10.0.0.0/16", "10.1.2.0/24", "10.2.0.0/15", "10.4.0.0/14", "10.8.0.0/13", "10.16.0.0/12", "10.32.0.0/11", "10.64.0.0/10", "10.128.0.0/9"}
+	for _, in := range []string{
+		"   10.0.0.0/8\n   !10.1.0.0/16\n   10.1.2.0/24\n",
+		"   10.1.2.0/24\n   !10.1.0.0/16\n   10.0.0.0/8\n",
+	} {
+		set, err := ParsePFTable(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := testPrefixes(set); fmt.Sprint(got) != fmt.Sprint(nested) {
+			t.Errorf("unexpected prefixes for %q: got %v, want %v", in, got, nested)
+		}
+	}
+
+	if _, err := ParsePFTable(strings.NewReader("   em0\n")); err == nil {
+		t.Error("expected error for interface name")
+	}
+}
@@ -0,0 +1,291 @@
+// Package firewall exports sets of addresses as firewall configuration
+// for ipset, nftables and pf, and as CSV. It also reads the sets loaded
+// into those firewalls back from their listings.
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/ericyan/iputil"
+	"github.com/ericyan/iputil/uint128"
+)
+
+// A span is a range of addresses as integers.
+type span struct {
+	first, last uint128.Int
+}
+
+// A Set is a set of IPv4 and IPv6 addresses, built from ranges and
+// prefixes. The zero Set is empty and ready to use.
+type Set struct {
+	// spans are the IPv4 and IPv6 spans, which are merged when the set
+	// is used.
+	spans  [2][]span
+	merged bool
+}
+
+// index returns the index of af in spans.
+func index(af uint) int {
+	if af == iputil.IPv6 {
+		return 1
+	}
+
+	return 0
+}
+
+func (s *Set) add(af uint, first, last uint128.Int) {
+	i := index(af)
+	s.spans[i] = append(s.spans[i], span{first, last})
+	s.merged = false
+}
+
+// AddRange adds the addresses of r to the set.
+func (s *Set) AddRange(r *iputil.Range) {
+	first, last := iputil.AddrFromIP(r.First()), iputil.AddrFromIP(r.Last())
+	s.add(first.Family(), first.Int(), last.Int())
+}
+
+// AddPrefix adds the addresses of p to the set. Invalid prefixes are
+// ignored.
+func (s *Set) AddPrefix(p iputil.Prefix) {
+	if !p.IsValid() {
+		return
+	}
+
+	n := uint(128 - p.Addr().BitLen() + p.Bits())
+	first := p.Addr().Int()
+	s.add(p.Addr().Family(), first, first.Or(uint128.Max.Rsh(n)))
+}
+
+// addElement adds an element written as an address, a prefix, or a
+// range of the first and last address separated by a hyphen.
+func (s *Set) addElement(e string) error {
+	if strings.Contains(e, "-") {
+		r, err := iputil.ParseRange(e)
+		if err != nil {
+			return fmt.Errorf("invalid range %q", e)
+		}
+
+		s.AddRange(r)
+		return nil
+	}
+
+	p, err := iputil.ParsePrefix(e)
+	if err != nil {
+		return fmt.Errorf("invalid address or prefix %q", e)
+	}
+
+	s.AddPrefix(p)
+	return nil
+}
+
+// merge sorts the spans of each family, and merges those that overlap
+// or are adjacent.
+func (s *Set) merge() {
+	if s.merged {
+		return
+	}
+
+	for i, spans := range s.spans {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].first.IsLessThan(spans[j].first) })
+
+		var merged []span
+		for _, sp := range spans {
+			if n := len(merged); n > 0 {
+				prev := &merged[n-1]
+				if next, overflow := prev.last.AddOverflow(uint128.One); overflow || !next.IsLessThan(sp.first) {
+					if prev.last.IsLessThan(sp.last) {
+						prev.last = sp.last
+					}
+					continue
+				}
+			}
+			merged = append(merged, sp)
+		}
+		s.spans[i] = merged
+	}
+	s.merged = true
+}
+
+// Difference returns a new set of the addresses in s that are not in t.
+func (s *Set) Difference(t *Set) *Set {
+	s.merge()
+	t.merge()
+
+	d := &Set{merged: true}
+	for i, spans := range s.spans {
+		others := t.spans[i]
+
+		j := 0
+		for _, sp := range spans {
+			first, covered := sp.first, false
+			for ; j < len(others) && !others[j].first.IsGreaterThan(sp.last); j++ {
+				o := others[j]
+				if o.last.IsLessThan(first) {
+					continue
+				}
+
+				if first.IsLessThan(o.first) {
+					d.spans[i] = append(d.spans[i], span{first, o.first.Sub(uint128.One)})
+				}
+
+				// The span of t may cover the next span of s as well, so
+				// it is kept for that.
+				if !o.last.IsLessThan(sp.last) {
+					covered = true
+					break
+				}
+				first = o.last.Add(uint128.One)
+			}
+
+			if !covered {
+				d.spans[i] = append(d.spans[i], span{first, sp.last})
+			}
+		}
+	}
+
+	return d
+}
+
+// families returns the address families of the set, IPv4 first.
+func (s *Set) families() []uint {
+	s.merge()
+
+	var afs []uint
+	for _, af := range []uint{iputil.IPv4, iputil.IPv6} {
+		if len(s.spans[index(af)]) > 0 {
+			afs = append(afs, af)
+		}
+	}
+
+	return afs
+}
+
+// ranges returns the ranges of family af in the set, in ascending order.
+func (s *Set) ranges(af uint) []*iputil.Range {
+	s.merge()
+
+	var ranges []*iputil.Range
+	for _, sp := range s.spans[index(af)] {
+		ranges = append(ranges, newRange(af, sp.first, sp.last))
+	}
+
+	return ranges
+}
+
+// newRange returns the range of family af from first to last.
+func newRange(af uint, first, last uint128.Int) *iputil.Range {
+	a, _ := iputil.AddrFromInt(first, af)
+	b, _ := iputil.AddrFromInt(last, af)
+	r, _ := iputil.NewRange(a.IP(), b.IP())
+
+	return r
+}
+
+// Ranges returns the set as the fewest ranges, IPv4 ranges first, each
+// in ascending order.
+func (s *Set) Ranges() []*iputil.Range {
+	return append(s.ranges(iputil.IPv4), s.ranges(iputil.IPv6)...)
+}
+
+// Prefixes returns the set as the fewest prefixes, IPv4 prefixes first,
+// each in ascending order.
+func (s *Set) Prefixes() []iputil.Prefix {
+	var prefixes []iputil.Prefix
+	for _, e := range s.elements(false) {
+		prefixes = append(prefixes, e.prefix)
+	}
+
+	return prefixes
+}
+
+// An element is an element of an exported set, which is a prefix or a
+// range that is not a prefix.
+type element struct {
+	prefix iputil.Prefix
+	r      *iputil.Range
+}
+
+// String returns the prefix in CIDR notation, leaving out the length of
+// single-address prefixes, or the range as the first and last address
+// separated by a hyphen.
+func (e element) String() string {
+	switch {
+	case e.r != nil:
+		return e.r.First().String() + "-" + e.r.Last().String()
+	case e.prefix.Bits() == e.prefix.Addr().BitLen():
+		return e.prefix.Addr().String()
+	default:
+		return e.prefix.String()
+	}
+}
+
+// first and last return the first and last address of e.
+func (e element) first() net.IP {
+	if e.r != nil {
+		return e.r.First()
+	}
+
+	return iputil.NetworkAddr(e.prefix.IPNet())
+}
+
+func (e element) last() net.IP {
+	if e.r != nil {
+		return e.r.Last()
+	}
+
+	return iputil.BroadcastAddr(e.prefix.IPNet())
+}
+
+// elements returns the elements of the set, IPv4 elements first. If
+// ranges is true, ranges that would take more than one prefix are
+// returned as they are, so that there are as few elements as possible.
+func (s *Set) elements(ranges bool) []element {
+	var elems []element
+	for _, r := range s.Ranges() {
+		cidrs := r.CIDR()
+		if ranges && len(cidrs) > 1 {
+			elems = append(elems, element{r: r})
+			continue
+		}
+
+		for _, n := range cidrs {
+			p, _ := iputil.PrefixFromIPNet(n)
+			elems = append(elems, element{prefix: p})
+		}
+	}
+
+	return elems
+}
+
+// Maximum lengths of the names of ipsets, nftables sets and pf tables,
+// which are stored with a terminating NUL byte.
+const (
+	ipsetMaxNameLen = 31
+	nftMaxNameLen   = 255
+	pfMaxNameLen    = 31
+)
+
+// checkName returns an error unless name is at most maxLen bytes long,
+// and is a letter or an underscore followed by letters, digits,
+// underscores, hyphens and dots. Names are written unquoted, so other
+// characters could inject commands or configuration.
+func checkName(name string, maxLen int) error {
+	if name == "" || len(name) > maxLen {
+		return fmt.Errorf("firewall: invalid name %q", name)
+	}
+
+	for i, c := range []byte(name) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', c == '_':
+		case i > 0 && ('0' <= c && c <= '9' || c == '-' || c == '.'):
+		default:
+			return fmt.Errorf("firewall: invalid name %q", name)
+		}
+	}
+
+	return nil
+}
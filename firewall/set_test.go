@@ -0,0 +1,149 @@
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/ericyan/iputil"
+)
+
+// testSet returns a set of ranges, written as "first-last", and prefixes.
+func testSet(t *testing.T, elems ...string) *Set {
+	t.Helper()
+
+	var s Set
+	for _, e := range elems {
+		first, last, ok := strings.Cut(e, "-")
+		if !ok {
+			p, err := iputil.ParsePrefix(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.AddPrefix(p)
+			continue
+		}
+
+		r, err := iputil.NewRange(net.ParseIP(first), net.ParseIP(last))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.AddRange(r)
+	}
+
+	return &s
+}
+
+func TestSet(t *testing.T) {
+	cases := []struct {
+		in       []string
+		ranges   []string
+		prefixes []string
+	}{
+		{nil, nil, nil},
+		{
+			[]string{"10.0.0.0/24", "10.0.1.0/24", "10.0.0.128/25"},
+			[]string{"10.0.0.0 - 10.0.1.255"},
+			[]string{"10.0.0.0/23"},
+		},
+		{
+			[]string{"2001:db8::/32", "10.0.0.5-10.0.0.9", "10.0.0.1-10.0.0.6", "192.0.2.1/32"},
+			[]string{"10.0.0.1 - 10.0.0.9", "192.0.2.1 - 192.0.2.1", "2001:db8:: - 2001:db8:ffff:ffff:ffff:ffff:ffff:ffff"},
+			[]string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/30", "10.0.0.8/31", "192.0.2.1/32", "2001:db8::/32"},
+		},
+		{
+			[]string{"::/1", "8000::/1", "0.0.0.0/0"},
+			[]string{"0.0.0.0 - 255.255.255.255", ":: - ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"},
+			[]string{"0.0.0.0/0", "::/0"},
+		},
+	}
+
+	for _, c := range cases {
+		s := testSet(t, c.in...)
+
+		var ranges []string
+		for _, r := range s.Ranges() {
+			ranges = append(ranges, r.String())
+		}
+		if fmt.Sprint(ranges) != fmt.Sprint(c.ranges) {
+			t.Errorf("unexpected ranges of %v: got %v, want %v", c.in, ranges, c.ranges)
+		}
+
+		var prefixes []string
+		for _, p := range s.Prefixes() {
+			prefixes = append(prefixes, p.String())
+		}
+		if fmt.Sprint(prefixes) != fmt.Sprint(c.prefixes) {
+			t.Errorf("unexpected prefixes of %v: got %v, want %v", c.in, prefixes, c.prefixes)
+		}
+	}
+}
+
+// testPrefixes returns the prefixes of s as strings.
+func testPrefixes(s *Set) []string {
+	var prefixes []string
+	for _, p := range s.Prefixes() {
+		prefixes = append(prefixes, p.String())
+	}
+
+	return prefixes
+}
+
+func TestDifference(t *testing.T) {
+	cases := []struct {
+		s, t []string
+		want []string
+	}{
+		{nil, []string{"10.0.0.0/8"}, nil},
+		{[]string{"10.0.0.0/8"}, nil, []string{"10.0.0.0/8"}},
+		{[]string{"10.0.0.0/8"}, []string{"10.0.0.0/8"}, nil},
+		{[]string{"10.0.0.0/8"}, []string{"0.0.0.0/0"}, nil},
+		{[]string{"10.0.0.0/8"}, []string{"2001:db8::/32"}, []string{"10.0.0.0/8"}},
+		{[]string{"10.0.0.0/8"}, []string{"10.128.0.0/9"}, []string{"10.0.0.0/9"}},
+		{
+			[]string{"10.0.0.0/30", "10.0.0.8/30"},
+			[]string{"10.0.0.1", "10.0.0.3-10.0.0.9"},
+			[]string{"10.0.0.0/32", "10.0.0.2/32", "10.0.0.10/31"},
+		},
+		{
+			[]string{"10.0.0.0/31", "10.0.0.4/31", "10.0.0.8/31"},
+			[]string{"10.0.0.1-10.0.0.8"},
+			[]string{"10.0.0.0/32", "10.0.0.9/32"},
+		},
+		{[]string{"::/0"}, []string{"::/1"}, []string{"8000::/1"}},
+		{[]string{"::/0"}, []string{"8000::/1", "::/2"}, []string{"4000::/2"}},
+	}
+
+	for _, c := range cases {
+		got := testPrefixes(testSet(t, c.s...).Difference(testSet(t, c.t...)))
+		if fmt.Sprint(got) != fmt.Sprint(c.want) {
+			t.Errorf("unexpected difference of %v and %v: got %v, want %v", c.s, c.t, got, c.want)
+		}
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	cases := []struct {
+		name string
+		ok   bool
+	}{
+		{"blocked", true},
+		{"_blocked-v4.2", true},
+		{"", false},
+		{"4blocked", false},
+		{"-blocked", false},
+		{"blocked list", false},
+		{"blocked\n", false},
+		{"blocked}", false},
+		{"blocked>", false},
+		{strings.Repeat("a", 31), true},
+		{strings.Repeat("a", 32), false},
+	}
+
+	for _, c := range cases {
+		if err := checkName(c.name, 31); (err == nil) != c.ok {
+			t.Errorf("unexpected result for %q: %v", c.name, err)
+		}
+	}
+}
@@ -0,0 +1,435 @@
+package iputil
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/ericyan/iputil/uint128"
+)
+
+// A Style specifies a textual representation of an IP address.
+type Style int
+
+// Textual representations supported by Format.
+const (
+	// StyleCanonical is the dotted-decimal notation for IPv4 addresses
+	// and the RFC 5952 recommended notation for IPv6 addresses.
+	StyleCanonical Style = iota
+
+	// StyleExpanded is the fully expanded IPv6 notation, with leading
+	// zeros in each group and no "::" compression. IPv4 addresses are
+	// formatted as IPv4-mapped IPv6 addresses.
+	StyleExpanded
+
+	// StyleDecimal is the address as a base 10 integer.
+	StyleDecimal
+
+	// StyleHex is the address as a 0x-prefixed base 16 integer, padded
+	// to the full address width.
+	StyleHex
+
+	// StyleOctal is the address as a 0-prefixed base 8 integer, padded
+	// to the full address width.
+	StyleOctal
+
+	// StyleBinary is the address as a 0b-prefixed base 2 integer,
+	// padded to the full address width.
+	StyleBinary
+
+	// StyleDottedHex is the IPv4 dotted notation with each octet in
+	// 0x-prefixed hexadecimal.
+	StyleDottedHex
+
+	// StyleDottedOctal is the IPv4 dotted notation with each octet in
+	// 0-prefixed octal.
+	StyleDottedOctal
+
+	// StyleDottedBinary is the IPv4 dotted notation with each octet as
+	// eight binary digits.
+	StyleDottedBinary
+)
+
+// Format returns the textual representation of ip in the given style.
+// The dotted styles are only defined for IPv4 addresses, including
+// IPv4-mapped IPv6 addresses. If ip is invalid or the style does not
+// apply to it, Format returns an empty string.
+func Format(ip net.IP, style Style) string {
+	switch style {
+	case StyleCanonical:
+		return formatCanonical(ip)
+	case StyleExpanded:
+		return formatExpanded(ip)
+	case StyleDecimal:
+		if AddressFamily(ip) == 0 {
+			return ""
+		}
+		return DecimalString(ip)
+	case StyleHex:
+		return formatInt(ip, "0x", 4)
+	case StyleOctal:
+		return formatInt(ip, "0", 3)
+	case StyleBinary:
+		return formatInt(ip, "0b", 1)
+	case StyleDottedHex:
+		return formatDotted(ip, "0x", 16, 0)
+	case StyleDottedOctal:
+		return formatDotted(ip, "0", 8, 0)
+	case StyleDottedBinary:
+		return formatDotted(ip, "", 2, 8)
+	default:
+		return ""
+	}
+}
+
+// formatCanonical formats ip in dotted-decimal notation if it is a
+// 4-byte IPv4 address, or in the RFC 5952 notation otherwise.
+func formatCanonical(ip net.IP) string {
+	switch AddressFamily(ip) {
+	case IPv4:
+		return ip.String()
+	case IPv6:
+	default:
+		return ""
+	}
+
+	// Section 5 of RFC 5952 recommends the mixed notation for
+	// IPv4-mapped addresses.
+	if IsIPv4Mapped(ip) {
+		return "::ffff:" + ip.To4().String()
+	}
+
+	// Find the longest run of two or more zero groups. Ties are broken
+	// in favour of the first run, as required by section 4.2.3.
+	start, length := -1, 1
+	for i := 0; i < 8; i++ {
+		j := i
+		for j < 8 && ip[2*j] == 0 && ip[2*j+1] == 0 {
+			j++
+		}
+		if j-i > length {
+			start, length = i, j-i
+		}
+		if j > i {
+			i = j
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < 8; i++ {
+		if i == start {
+			b.WriteString("::")
+			i += length - 1
+			continue
+		}
+		if i > 0 && i != start+length {
+			b.WriteByte(':')
+		}
+		b.WriteString(strconv.FormatUint(uint64(ip[2*i])<<8|uint64(ip[2*i+1]), 16))
+	}
+
+	return b.String()
+}
+
+// formatExpanded formats ip as eight colon-separated groups of four
+// hexadecimal digits.
+func formatExpanded(ip net.IP) string {
+	ip = ip.To16()
+	if ip == nil {
+		return ""
+	}
+
+	const hexDigits = "0123456789abcdef"
+	buf := make([]byte, 0, 39)
+	for i := 0; i < net.IPv6len; i++ {
+		if i > 0 && i%2 == 0 {
+			buf = append(buf, ':')
+		}
+		buf = append(buf, hexDigits[ip[i]>>4], hexDigits[ip[i]&0xf])
+	}
+
+	return string(buf)
+}
+
+// formatInt formats ip as a single integer with the given prefix, using
+// a base of 2**bitsPerDigit, zero-padded to the full address width.
+func formatInt(ip net.IP, prefix string, bitsPerDigit uint) string {
+	bitLen := uint(len(ip)) * 8
+	if AddressFamily(ip) == 0 {
+		return ""
+	}
+
+	x, _ := uint128.NewFromBytes(ip)
+	mask := uint128.One.Lsh(bitsPerDigit).Sub(uint128.One)
+
+	n := (bitLen + bitsPerDigit - 1) / bitsPerDigit
+	digits := make([]byte, n)
+	for i := int(n) - 1; i >= 0; i-- {
+		digits[i] = "0123456789abcdef"[x.And(mask).Lo]
+		x = x.Rsh(bitsPerDigit)
+	}
+
+	return prefix + string(digits)
+}
+
+// formatDotted formats the IPv4 address ip in dotted notation with each
+// octet in the given base, optionally zero-padded to width digits.
+func formatDotted(ip net.IP, prefix string, base int, width int) string {
+	ip = ip.To4()
+	if ip == nil {
+		return ""
+	}
+
+	parts := make([]string, net.IPv4len)
+	for i, b := range ip {
+		s := strconv.FormatUint(uint64(b), base)
+		if len(s) < width {
+			s = strings.Repeat("0", width-len(s)) + s
+		}
+		if b == 0 && prefix == "0" {
+			parts[i] = s
+			continue
+		}
+		parts[i] = prefix + s
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// ParseAny parses s as an IP address in any of the styles produced by
+// Format, as well as the legacy forms accepted by inet_aton(3): each
+// dot-separated part may be decimal, 0x-prefixed hexadecimal or
+// 0-prefixed octal, and the shorthands "a", "a.b" and "a.b.c" fill the
+// last part up to the remaining address width, so that "10.1" means
+// 10.0.0.1. Four parts of exactly eight binary digits are interpreted
+// as dotted binary.
+//
+// A single decimal integer is an IPv4 address if it fits in 32 bits,
+// and an IPv6 address otherwise. A single 0x-, 0b- or 0-prefixed
+// integer is an IPv4 address if it has no more digits than needed to
+// write a 32-bit value in its base, and an IPv6 address otherwise.
+// IPv6 addresses are returned as 16-byte slices, IPv4 addresses as
+// 4-byte slices. If s is invalid, ParseAny returns nil.
+func ParseAny(s string) net.IP {
+	return parseAny(s, false)
+}
+
+// ParseAnyStrict is like ParseAny, but rejects forms that different
+// parsers are known to interpret differently: inet_aton(3) shorthands,
+// parts with leading zeros, hexadecimal parts, dotted binary and bare
+// decimal integers. Single 0x- or 0b-prefixed integers are accepted
+// only when padded to the full width of an IPv4 or IPv6 address.
+func ParseAnyStrict(s string) net.IP {
+	return parseAny(s, true)
+}
+
+func parseAny(s string, strict bool) net.IP {
+	if s == "" {
+		return nil
+	}
+
+	if strings.IndexByte(s, ':') >= 0 {
+		return ParseIPv6(s)
+	}
+
+	if !strings.ContainsRune(s, '.') {
+		return parseInt(s, strict)
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > net.IPv4len {
+		return nil
+	}
+
+	if !strict && isDottedBinary(parts) {
+		ip := make(net.IP, net.IPv4len)
+		for i, p := range parts {
+			v, _ := strconv.ParseUint(p, 2, 8)
+			ip[i] = byte(v)
+		}
+		return ip
+	}
+
+	if strict {
+		if len(parts) != net.IPv4len {
+			return nil
+		}
+		for _, p := range parts {
+			if len(p) == 0 || len(p) > 1 && p[0] == '0' {
+				return nil
+			}
+		}
+	}
+
+	return parseInetAton(parts)
+}
+
+// parseInetAton parses the dot-separated parts of an IPv4 address
+// following the rules of inet_aton(3).
+func parseInetAton(parts []string) net.IP {
+	vals := make([]uint64, len(parts))
+	for i, p := range parts {
+		v, ok := parseInetAtonPart(p)
+		if !ok {
+			return nil
+		}
+		vals[i] = v
+	}
+
+	// All but the last part are single octets; the last part fills the
+	// remaining bytes.
+	var x uint64
+	for _, v := range vals[:len(vals)-1] {
+		if v > 0xff {
+			return nil
+		}
+		x = x<<8 | v
+	}
+	remaining := uint(net.IPv4len-len(vals)+1) * 8
+	last := vals[len(vals)-1]
+	if last >= 1<<remaining {
+		return nil
+	}
+	x = x<<remaining | last
+
+	return net.IPv4(byte(x>>24), byte(x>>16), byte(x>>8), byte(x)).To4()
+}
+
+// parseInetAtonPart parses a single part of an inet_aton(3) address,
+// which may be decimal, 0x-prefixed hexadecimal or 0-prefixed octal. As
+// in inet_aton(3), a bare "0x" is 0.
+func parseInetAtonPart(p string) (uint64, bool) {
+	base := 10
+	switch {
+	case p == "0x" || p == "0X":
+		return 0, true
+	case len(p) > 2 && (p[:2] == "0x" || p[:2] == "0X"):
+		base, p = 16, p[2:]
+	case len(p) > 1 && p[0] == '0':
+		base, p = 8, p[1:]
+	}
+
+	v, err := strconv.ParseUint(p, base, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return v, true
+}
+
+// isDottedBinary reports whether parts are four octets each written as
+// exactly eight binary digits.
+func isDottedBinary(parts []string) bool {
+	if len(parts) != net.IPv4len {
+		return false
+	}
+
+	for _, p := range parts {
+		if len(p) != 8 || strings.Trim(p, "01") != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseInt parses s as a single integer in decimal, 0x-prefixed
+// hexadecimal, 0b-prefixed binary or 0-prefixed octal. A decimal integer
+// is an IPv4 address if its value fits in 32 bits; for the other bases,
+// the address family is determined by the number of digits. As in
+// inet_aton(3), a bare "0x" is 0.0.0.0 unless strict is true.
+func parseInt(s string, strict bool) net.IP {
+	var digits string
+	var bitsPerDigit uint
+	switch {
+	case s == "0x" || s == "0X":
+		if strict {
+			return nil
+		}
+		return intToIP(uint128.Zero, IPv4)
+	case len(s) > 2 && (s[:2] == "0x" || s[:2] == "0X"):
+		digits, bitsPerDigit = s[2:], 4
+	case len(s) > 2 && (s[:2] == "0b" || s[:2] == "0B"):
+		digits, bitsPerDigit = s[2:], 1
+	case len(s) > 1 && s[0] == '0':
+		if strict {
+			return nil
+		}
+		digits, bitsPerDigit = s[1:], 3
+	default:
+		if strict {
+			return nil
+		}
+
+		x, err := uint128.Atoi(s)
+		if err != nil {
+			return nil
+		}
+		if x.BitLen() <= IPv4BitLen {
+			return intToIP(x, IPv4)
+		}
+		return intToIP(x, IPv6)
+	}
+
+	x, ok := parseBase(digits, bitsPerDigit)
+	if !ok {
+		return nil
+	}
+
+	n := uint(len(digits))
+	v4Width := (IPv4BitLen + bitsPerDigit - 1) / bitsPerDigit
+	v6Width := (IPv6BitLen + bitsPerDigit - 1) / bitsPerDigit
+	switch {
+	case strict && n == v4Width, !strict && n <= v4Width:
+		return intToIP(x, IPv4)
+	case strict && n == v6Width, !strict && n <= v6Width:
+		return intToIP(x, IPv6)
+	default:
+		return nil
+	}
+}
+
+// parseBase parses digits in base 2**bitsPerDigit. It reports false if
+// digits is invalid or overflows 128 bits.
+func parseBase(digits string, bitsPerDigit uint) (uint128.Int, bool) {
+	if len(digits) == 0 {
+		return uint128.Zero, false
+	}
+
+	x := uint128.Zero
+	for _, c := range []byte(digits) {
+		var d uint64
+		switch {
+		case '0' <= c && c <= '9':
+			d = uint64(c - '0')
+		case 'a' <= c && c <= 'f':
+			d = uint64(c - 'a' + 10)
+		case 'A' <= c && c <= 'F':
+			d = uint64(c - 'A' + 10)
+		default:
+			return uint128.Zero, false
+		}
+		if d >= 1<<bitsPerDigit || x.LeadingZeros() < int(bitsPerDigit) {
+			return uint128.Zero, false
+		}
+		x = x.Lsh(bitsPerDigit).Or(uint128.Int{Hi: 0, Lo: d})
+	}
+
+	return x, true
+}
+
+// intToIP converts x to an IP address of the given address family. It
+// returns nil if x does not fit in the address family.
+func intToIP(x uint128.Int, af uint) net.IP {
+	switch af {
+	case IPv4:
+		if x.BitLen() > IPv4BitLen {
+			return nil
+		}
+		return net.IP(x.Bytes()[16-net.IPv4len:])
+	case IPv6:
+		return net.IP(x.Bytes())
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,118 @@
+package iputil
+
+import (
+	"net"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		ip    net.IP
+		style Style
+		s     string
+	}{
+		{nil, StyleCanonical, ""},
+		{ParseIPv4("10.0.0.1"), StyleCanonical, "10.0.0.1"},
+		{ParseIPv6("10.0.0.1"), StyleCanonical, "::ffff:10.0.0.1"},
+		{ParseIPv6("2001:db8:0:0:1:0:0:1"), StyleCanonical, "2001:db8::1:0:0:1"},
+		{ParseIPv6("2001:db8:0:1:1:1:1:1"), StyleCanonical, "2001:db8:0:1:1:1:1:1"},
+		{ParseIPv6("2001:0:0:1:0:0:0:1"), StyleCanonical, "2001:0:0:1::1"},
+		{ParseIPv6("::"), StyleCanonical, "::"},
+		{ParseIPv6("::1"), StyleCanonical, "::1"},
+		{ParseIPv6("fe80::"), StyleCanonical, "fe80::"},
+		{ParseIPv6("2001:db8::1"), StyleExpanded, "2001:0db8:0000:0000:0000:0000:0000:0001"},
+		{ParseIPv4("10.0.0.1"), StyleExpanded, "0000:0000:0000:0000:0000:ffff:0a00:0001"},
+		{ParseIPv4("10.0.0.1"), StyleDecimal, "167772161"},
+		{ParseIPv4("10.0.0.1"), StyleHex, "0x0a000001"},
+		{ParseIPv6("2001:db8::1"), StyleHex, "0x20010db8000000000000000000000001"},
+		{ParseIPv4("10.0.0.1"), StyleOctal, "001200000001"},
+		{ParseIPv4("10.0.0.1"), StyleBinary, "0b00001010000000000000000000000001"},
+		{ParseIPv4("10.0.0.1"), StyleDottedHex, "0xa.0x0.0x0.0x1"},
+		{ParseIPv4("10.0.0.1"), StyleDottedOctal, "012.0.0.01"},
+		{ParseIPv4("10.0.0.1"), StyleDottedBinary, "00001010.00000000.00000000.00000001"},
+		{ParseIPv6("2001:db8::1"), StyleDottedBinary, ""},
+		{ParseIPv4("10.0.0.1"), Style(-1), ""},
+	}
+
+	for _, c := range cases {
+		if s := Format(c.ip, c.style); s != c.s {
+			t.Errorf("unexpected result for %s in style %d: got %q, want %q", c.ip, c.style, s, c.s)
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	ips := []net.IP{
+		ParseIPv4("0.0.0.0"),
+		ParseIPv4("10.0.0.1"),
+		ParseIPv4("255.255.255.255"),
+		ParseIPv6("::"),
+		ParseIPv6("2001:db8::1"),
+		ParseIPv6("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"),
+	}
+	styles := []Style{StyleCanonical, StyleExpanded, StyleDecimal, StyleHex, StyleOctal, StyleBinary, StyleDottedHex, StyleDottedOctal, StyleDottedBinary}
+
+	for _, ip := range ips {
+		for _, style := range styles {
+			s := Format(ip, style)
+			if s == "" {
+				continue
+			}
+
+			// A decimal integer does not carry the address family.
+			if style == StyleDecimal && BitLen(ip) == IPv6BitLen {
+				continue
+			}
+
+			got := ParseAny(s)
+			if style == StyleExpanded {
+				got = got.To16()
+			}
+			if !got.Equal(ip) || (style != StyleCanonical && style != StyleExpanded && len(got) != len(ip)) {
+				t.Errorf("unexpected round trip for %s in style %d: %q parsed as %v", ip, style, s, got)
+			}
+		}
+	}
+}
+
+func TestParseAny(t *testing.T) {
+	cases := []struct {
+		s      string
+		ip     net.IP
+		strict net.IP
+	}{
+		{"", nil, nil},
+		{"10.0.0.1", ParseIPv4("10.0.0.1"), ParseIPv4("10.0.0.1")},
+		{"10.1", ParseIPv4("10.0.0.1"), nil},
+		{"10.1.1", ParseIPv4("10.1.0.1"), nil},
+		{"167772161", ParseIPv4("10.0.0.1"), nil},
+		{"0x0a000001", ParseIPv4("10.0.0.1"), ParseIPv4("10.0.0.1")},
+		{"0xa000001", ParseIPv4("10.0.0.1"), nil},
+		{"0012.0.0.1", ParseIPv4("10.0.0.1"), nil},
+		{"0xa.0.0.1", ParseIPv4("10.0.0.1"), nil},
+		{"0x.1.2.3", ParseIPv4("0.1.2.3"), nil},
+		{"0x", ParseIPv4("0.0.0.0"), nil},
+		{"10.0x", ParseIPv4("10.0.0.0"), nil},
+		{"00001010.00000000.00000000.00000001", ParseIPv4("10.0.0.1"), nil},
+		{"0b00001010000000000000000000000001", ParseIPv4("10.0.0.1"), ParseIPv4("10.0.0.1")},
+		{"2001:0db8:0000:0000:0000:0000:0000:0001", ParseIPv6("2001:db8::1"), ParseIPv6("2001:db8::1")},
+		{"0x20010db8000000000000000000000001", ParseIPv6("2001:db8::1"), ParseIPv6("2001:db8::1")},
+		{"42540766411282592856903984951653826561", ParseIPv6("2001:db8::1"), nil},
+		{"256.0.0.1", nil, nil},
+		{"10.0.0.0.1", nil, nil},
+		{"10..0.1", nil, nil},
+		{"10.16777216", nil, nil},
+		{"08.0.0.1", nil, nil},
+		{"0x1g", nil, nil},
+		{"0x120010db8000000000000000000000001", nil, nil},
+	}
+
+	for _, c := range cases {
+		if ip := ParseAny(c.s); !ip.Equal(c.ip) {
+			t.Errorf("unexpected result for %q: got %s, want %s", c.s, ip, c.ip)
+		}
+		if ip := ParseAnyStrict(c.s); !ip.Equal(c.strict) {
+			t.Errorf("unexpected strict result for %q: got %s, want %s", c.s, ip, c.strict)
+		}
+	}
+}
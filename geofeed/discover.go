@@ -0,0 +1,62 @@
+package geofeed
+
+import (
+	"bufio"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrNoReference is returned by FindReference if an object refers to no
+// geofeed.
+var ErrNoReference = errors.New("geofeed: no geofeed reference")
+
+// FindReference returns the URL of the geofeed referred to by an inetnum
+// or inet6num object in RPSL text, such as the output of a whois query.
+// As described in RFC 9092, the reference is a remarks attribute whose
+// value is "Geofeed" followed by the URL, or a geofeed attribute whose
+// value is the URL. The URL must use HTTPS, and an object must not refer
+// to more than one geofeed.
+func FindReference(object string) (*url.URL, error) {
+	var refs []string
+
+	s := bufio.NewScanner(strings.NewReader(object))
+	for s.Scan() {
+		name, value, ok := strings.Cut(s.Text(), ":")
+		if !ok || strings.ContainsAny(name, " \t") {
+			// Continuation lines start with white space or a "+".
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.ToLower(name) {
+		case "remarks":
+			fields := strings.Fields(value)
+			if len(fields) == 2 && strings.EqualFold(fields[0], "Geofeed") {
+				refs = append(refs, fields[1])
+			}
+		case "geofeed":
+			refs = append(refs, value)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(refs) == 0:
+		return nil, ErrNoReference
+	case len(refs) > 1:
+		return nil, errors.New("geofeed: more than one geofeed reference")
+	}
+
+	u, err := url.Parse(refs[0])
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return nil, errors.New("geofeed: reference is not an https url: " + refs[0])
+	}
+
+	return u, nil
+}
@@ -0,0 +1,60 @@
+package geofeed
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFindReference(t *testing.T) {
+	cases := []struct {
+		object string
+		url    string
+		err    error
+	}{
+		{
+			"inetnum:        192.0.2.0 - 192.0.2.255\n" +
+				"netname:        EXAMPLE-NET\n" +
+				"remarks:        Geofeed https://example.net/geofeed.csv\n" +
+				"country:        US\n",
+			"https://example.net/geofeed.csv",
+			nil,
+		},
+		{
+			"inet6num:       2001:db8::/32\n" +
+				"geofeed:        https://example.net/geofeed.csv\n" +
+				"remarks:        see https://example.net/about\n",
+			"https://example.net/geofeed.csv",
+			nil,
+		},
+		{
+			"inetnum:        192.0.2.0 - 192.0.2.255\n" +
+				"descr:          Example\n" +
+				"                remarks: Geofeed https://example.net/continued.csv\n",
+			"",
+			ErrNoReference,
+		},
+	}
+
+	for _, c := range cases {
+		u, err := FindReference(c.object)
+		if !errors.Is(err, c.err) {
+			t.Errorf("unexpected error: got %v, want %v", err, c.err)
+			continue
+		}
+		if err == nil && u.String() != c.url {
+			t.Errorf("unexpected url: got %s, want %s", u, c.url)
+		}
+	}
+
+	invalid := []string{
+		"remarks: Geofeed http://example.net/geofeed.csv\n",
+		"remarks: Geofeed https://example.net/a.csv\ngeofeed: https://example.net/b.csv\n",
+		"geofeed: https://%zz\n",
+		"geofeed: https:///geofeed.csv\n",
+	}
+	for _, object := range invalid {
+		if _, err := FindReference(object); err == nil || err == ErrNoReference {
+			t.Errorf("unexpected error for %q: %v", object, err)
+		}
+	}
+}
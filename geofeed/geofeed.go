@@ -0,0 +1,168 @@
+// Package geofeed reads and writes self-published IP geolocation feeds
+// in the CSV format of RFC 8805, and discovers them as described in
+// RFC 9092.
+package geofeed
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ericyan/iputil"
+)
+
+// An Entry is a line of a geofeed, giving the location of the addresses
+// of a prefix. All fields but Prefix are optional.
+type Entry struct {
+	Prefix iputil.Prefix
+
+	// Country is the ISO 3166-1 alpha-2 country code.
+	Country string
+
+	// Region is the ISO 3166-2 subdivision code, such as "US-CA".
+	Region string
+
+	City string
+
+	// PostalCode is deprecated by RFC 8805, and should be left empty.
+	PostalCode string
+
+	// Line is the line number of the entry when read by a Reader.
+	Line int
+}
+
+// A Reader reads entries from a geofeed.
+type Reader struct {
+	r *csv.Reader
+}
+
+// NewReader returns a Reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	return &Reader{cr}
+}
+
+// Read returns the next entry. Comments and blank lines are skipped,
+// and fields beyond the postal code are ignored. Prefixes are parsed as
+// by iputil.ParsePrefix, so host bits are cleared, and a single address
+// is accepted; Validate reports such prefixes. At the end of the input,
+// it returns io.EOF.
+func (r *Reader) Read() (*Entry, error) {
+	e, _, err := r.read()
+	return e, err
+}
+
+// read returns the next entry, and its prefix as written.
+func (r *Reader) read() (*Entry, string, error) {
+	fields, err := r.r.Read()
+	if err != nil {
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			return nil, "", fmt.Errorf("geofeed: line %d: %w", perr.Line, perr.Err)
+		}
+		return nil, "", err
+	}
+	line, _ := r.r.FieldPos(0)
+
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	for len(fields) < 5 {
+		fields = append(fields, "")
+	}
+
+	p, err := iputil.ParsePrefix(fields[0])
+	if err != nil {
+		return nil, "", fmt.Errorf("geofeed: line %d: invalid prefix %q", line, fields[0])
+	}
+
+	e := &Entry{
+		Prefix:     p,
+		Country:    fields[1],
+		Region:     fields[2],
+		City:       fields[3],
+		PostalCode: fields[4],
+		Line:       line,
+	}
+
+	return e, fields[0], nil
+}
+
+// ReadAll reads all the remaining entries.
+func (r *Reader) ReadAll() ([]*Entry, error) {
+	var entries []*Entry
+	for {
+		e, err := r.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+}
+
+// A Writer writes entries to a geofeed. Writes are buffered, so Flush
+// must be called to ensure that an entry has been written.
+type Writer struct {
+	w   *csv.Writer
+	out io.Writer
+}
+
+// NewWriter returns a Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{csv.NewWriter(w), w}
+}
+
+// Write writes e as a line with all five fields. The prefix is written
+// in canonical form.
+func (w *Writer) Write(e *Entry) error {
+	if !e.Prefix.IsValid() {
+		return errors.New("geofeed: invalid prefix")
+	}
+
+	return w.w.Write([]string{e.Prefix.String(), e.Country, e.Region, e.City, e.PostalCode})
+}
+
+// WriteAll writes entries, and then calls Flush.
+func (w *Writer) WriteAll(entries []*Entry) error {
+	for _, e := range entries {
+		if err := w.Write(e); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// WriteComment writes text as comment lines, one for each of its lines.
+func (w *Writer) WriteComment(text string) error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			b.WriteString("#\n")
+		} else {
+			b.WriteString("# " + line + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w.out, b.String())
+	return err
+}
+
+// Flush writes any buffered data.
+func (w *Writer) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
@@ -0,0 +1,108 @@
+package geofeed
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ericyan/iputil"
+)
+
+const testFeed = `# Geofeed of Example Networks
+192.0.2.0/25,US,US-CA,San Francisco,
+192.0.2.128/25, US , US-WA , Seattle
+2001:db8::/32,GB,GB-LND,"London, City of",
+
+198.51.100.0/24,NL,,,1012
+203.0.113.0/24
+`
+
+func TestReader(t *testing.T) {
+	entries, err := NewReader(strings.NewReader(testFeed)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Entry{
+		{Country: "US", Region: "US-CA", City: "San Francisco", Line: 2},
+		{Country: "US", Region: "US-WA", City: "Seattle", Line: 3},
+		{Country: "GB", Region: "GB-LND", City: "London, City of", Line: 4},
+		{Country: "NL", PostalCode: "1012", Line: 6},
+		{Line: 7},
+	}
+	prefixes := []string{"192.0.2.0/25", "192.0.2.128/25", "2001:db8::/32", "198.51.100.0/24", "203.0.113.0/24"}
+
+	if len(entries) != len(want) {
+		t.Fatalf("unexpected number of entries: got %d, want %d", len(entries), len(want))
+	}
+	for i, e := range entries {
+		w := want[i]
+		w.Prefix, _ = iputil.ParsePrefix(prefixes[i])
+		if *e != w {
+			t.Errorf("unexpected entry: got %+v, want %+v", *e, w)
+		}
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	cases := []string{
+		"192.0.2.0/33,US",
+		"example.com,US",
+		"192.0.2.0/24,\"US",
+	}
+
+	for _, c := range cases {
+		_, err := NewReader(strings.NewReader("# comment\n" + c)).Read()
+		if err == nil || err == io.EOF {
+			t.Errorf("error expected for %q", c)
+		} else if !strings.HasPrefix(err.Error(), "geofeed: line 2: ") {
+			t.Errorf("unexpected error for %q: %v", c, err)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	entries, err := NewReader(strings.NewReader(testFeed)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.WriteComment("Geofeed of Example Networks\n\nContact: noc@example.net"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteAll(entries); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Geofeed of Example Networks
+#
+# Contact: noc@example.net
+192.0.2.0/25,US,US-CA,San Francisco,
+192.0.2.128/25,US,US-WA,Seattle,
+2001:db8::/32,GB,GB-LND,"London, City of",
+198.51.100.0/24,NL,,,1012
+203.0.113.0/24,,,,
+`
+	if buf.String() != want {
+		t.Errorf("unexpected output: got %q, want %q", buf.String(), want)
+	}
+
+	// The output reads back as the same entries.
+	again, err := NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range again {
+		again[i].Line = entries[i].Line
+		if *again[i] != *entries[i] {
+			t.Errorf("unexpected entry: got %+v, want %+v", *again[i], *entries[i])
+		}
+	}
+
+	if err := w.Write(&Entry{Country: "US"}); err == nil {
+		t.Error("error expected for invalid prefix")
+	}
+}
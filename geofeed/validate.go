@@ -0,0 +1,144 @@
+package geofeed
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ericyan/iputil/internal/radix"
+)
+
+// Validation errors, wrapped in a ValidationError.
+var (
+	ErrNonCanonicalPrefix = errors.New("prefix not in canonical form")
+	ErrInvalidCountry     = errors.New("invalid country code")
+	ErrInvalidRegion      = errors.New("invalid region code")
+	ErrOverlap            = errors.New("overlapping prefix")
+)
+
+// A ValidationError describes a problem with an entry of a geofeed.
+type ValidationError struct {
+	Line int
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("geofeed: line %d: %v", e.Line, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate reads a geofeed from r and checks that:
+//
+//   - prefixes are in canonical form, with a prefix length, no host bits
+//     set and, for IPv6, the text representation of RFC 5952
+//   - country codes are ISO 3166-1 alpha-2 codes
+//   - region codes are ISO 3166-2 codes of the country of the entry
+//   - no prefix overlaps with another
+//
+// It returns the problems found, or an error if the geofeed cannot be
+// read at all.
+func Validate(r io.Reader) ([]*ValidationError, error) {
+	var problems []*ValidationError
+	report := func(line int, err error) {
+		problems = append(problems, &ValidationError{line, err})
+	}
+
+	// Entries are stored in the tree by their prefixes. Each value is the
+	// list of entries for the same prefix.
+	tree := radix.NewTree()
+
+	gr := NewReader(r)
+	for {
+		e, text, err := gr.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if text != e.Prefix.String() {
+			report(e.Line, fmt.Errorf("%w: %s", ErrNonCanonicalPrefix, text))
+		}
+		if e.Country != "" && !IsCountryCode(e.Country) {
+			report(e.Line, fmt.Errorf("%w: %s", ErrInvalidCountry, e.Country))
+		}
+		if e.Region != "" && !isRegionCode(e.Region, e.Country) {
+			report(e.Line, fmt.Errorf("%w: %s", ErrInvalidRegion, e.Region))
+		}
+
+		key, n := radix.PrefixKey(e.Prefix)
+		values, _ := tree.Overlapping(key, n)
+		var same []*Entry
+		for _, v := range values {
+			for _, other := range v.([]*Entry) {
+				report(e.Line, fmt.Errorf("%w: %s overlaps %s on line %d", ErrOverlap, e.Prefix, other.Prefix, other.Line))
+				if other.Prefix == e.Prefix {
+					same = append(same, other)
+				}
+			}
+		}
+		tree.SetPrefix(key, n, append(same, e))
+	}
+
+	return problems, nil
+}
+
+// IsCountryCode reports whether s is an ISO 3166-1 alpha-2 country code.
+// Codes are case-insensitive.
+func IsCountryCode(s string) bool {
+	return len(s) == 2 && strings.Contains(countryCodes, " "+strings.ToUpper(s)+" ")
+}
+
+// isRegionCode reports whether s has the form of an ISO 3166-2 code,
+// which is a country code followed by a hyphen and up to three letters
+// or digits. If country is not empty, the code must be for that country.
+func isRegionCode(s, country string) bool {
+	cc, sub, ok := strings.Cut(s, "-")
+	if !ok || !IsCountryCode(cc) || len(sub) == 0 || len(sub) > 3 {
+		return false
+	}
+	if country != "" && !strings.EqualFold(cc, country) {
+		return false
+	}
+
+	for _, c := range sub {
+		if !('0' <= c && c <= '9' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// countryCodes are the officially assigned ISO 3166-1 alpha-2 codes.
+const countryCodes = " " +
+	"AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ " +
+	"BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW BY BZ " +
+	"CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ " +
+	"DE DJ DK DM DO DZ " +
+	"EC EE EG EH ER ES ET " +
+	"FI FJ FK FM FO FR " +
+	"GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY " +
+	"HK HM HN HR HT HU " +
+	"ID IE IL IM IN IO IQ IR IS IT " +
+	"JE JM JO JP " +
+	"KE KG KH KI KM KN KP KR KW KY KZ " +
+	"LA LB LC LI LK LR LS LT LU LV LY " +
+	"MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT MU MV MW MX MY MZ " +
+	"NA NC NE NF NG NI NL NO NP NR NU NZ " +
+	"OM " +
+	"PA PE PF PG PH PK PL PM PN PR PS PT PW PY " +
+	"QA " +
+	"RE RO RS RU RW " +
+	"SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ " +
+	"TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ " +
+	"UA UG UM US UY UZ " +
+	"VA VC VE VG VI VN VU " +
+	"WF WS " +
+	"YE YT " +
+	"ZA ZM ZW "
@@ -0,0 +1,103 @@
+package geofeed
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	feed := `192.0.2.0/25,US,US-CA,San Francisco,
+192.0.2.128/25,us,us-wa,Seattle,
+192.0.2.1/24,US,,,
+198.51.100.7,NL,,,
+2001:DB8::/48,GB,GB-LND,London,
+2001:db8:1::/48,UK,,,
+2001:db8:2::/48,FR,DE-BE,,
+2001:db8:3::/48,FR,FR-,,
+2001:db8:4::/48,,FR-75C,Paris,
+2001:db8::/32,,XX-1,,
+::ffff:0.0.0.0/96,,,,
+`
+
+	problems, err := Validate(strings.NewReader(feed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		line int
+		err  error
+		text string
+	}{
+		{3, ErrNonCanonicalPrefix, "192.0.2.1/24"},
+		{3, ErrOverlap, "192.0.2.0/24 overlaps 192.0.2.0/25 on line 1"},
+		{3, ErrOverlap, "192.0.2.0/24 overlaps 192.0.2.128/25 on line 2"},
+		{4, ErrNonCanonicalPrefix, "198.51.100.7"},
+		{5, ErrNonCanonicalPrefix, "2001:DB8::/48"},
+		{6, ErrInvalidCountry, "UK"},
+		{7, ErrInvalidRegion, "DE-BE"},
+		{8, ErrInvalidRegion, "FR-"},
+		{10, ErrInvalidRegion, "XX-1"},
+		{10, ErrOverlap, "2001:db8::/32 overlaps 2001:db8::/48 on line 5"},
+		{10, ErrOverlap, "2001:db8::/32 overlaps 2001:db8:1::/48 on line 6"},
+		{10, ErrOverlap, "2001:db8::/32 overlaps 2001:db8:2::/48 on line 7"},
+		{10, ErrOverlap, "2001:db8::/32 overlaps 2001:db8:3::/48 on line 8"},
+		{10, ErrOverlap, "2001:db8::/32 overlaps 2001:db8:4::/48 on line 9"},
+	}
+
+	if len(problems) != len(want) {
+		for _, p := range problems {
+			t.Log(p)
+		}
+		t.Fatalf("unexpected number of problems: got %d, want %d", len(problems), len(want))
+	}
+	for i, p := range problems {
+		w := want[i]
+		if p.Line != w.line || !errors.Is(p, w.err) || !strings.HasSuffix(p.Error(), ": "+w.text) {
+			t.Errorf("unexpected problem: got %v, want line %d: %v: %s", p, w.line, w.err, w.text)
+		}
+	}
+}
+
+func TestValidateDuplicate(t *testing.T) {
+	feed := "192.0.2.0/24,US,,,\n192.0.2.0/24,US,,,\n192.0.2.0/24,CA,,,\n"
+
+	problems, err := Validate(strings.NewReader(feed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"geofeed: line 2: overlapping prefix: 192.0.2.0/24 overlaps 192.0.2.0/24 on line 1",
+		"geofeed: line 3: overlapping prefix: 192.0.2.0/24 overlaps 192.0.2.0/24 on line 1",
+		"geofeed: line 3: overlapping prefix: 192.0.2.0/24 overlaps 192.0.2.0/24 on line 2",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	for i, p := range problems {
+		if p.Error() != want[i] {
+			t.Errorf("unexpected problem: got %q, want %q", p, want[i])
+		}
+	}
+
+	if _, err := Validate(strings.NewReader("example.com,US")); err == nil {
+		t.Error("error expected for invalid prefix")
+	}
+}
+
+func TestIsCountryCode(t *testing.T) {
+	if n := len(strings.Fields(countryCodes)); n != 249 {
+		t.Errorf("unexpected number of country codes: got %d, want %d", n, 249)
+	}
+
+	cases := map[string]bool{
+		"US": true, "gb": true, "Nl": true, "UK": false, "EU": false, "U": false, "USA": false, "": false, "S ": false,
+	}
+	for s, want := range cases {
+		if got := IsCountryCode(s); got != want {
+			t.Errorf("unexpected result for %q: got %t, want %t", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package iputil
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+)
+
+// InterfaceIDLen is the length of an IPv6 interface identifier in bytes.
+const InterfaceIDLen = 8
+
+var (
+	errInvalidMAC         = errors.New("invalid hardware address")
+	errInvalidInterfaceID = errors.New("invalid interface identifier")
+	errInvalidPrefix      = errors.New("invalid ipv6 prefix")
+)
+
+// EUI64 returns the modified EUI-64 interface identifier derived from
+// the 48-bit MAC or 64-bit EUI-64 hardware address mac, as specified in
+// appendix A of RFC 4291.
+func EUI64(mac net.HardwareAddr) ([]byte, error) {
+	iid := make([]byte, InterfaceIDLen)
+
+	switch len(mac) {
+	case 6:
+		copy(iid[:3], mac[:3])
+		iid[3], iid[4] = 0xff, 0xfe
+		copy(iid[5:], mac[3:])
+	case 8:
+		copy(iid, mac)
+	default:
+		return nil, errInvalidMAC
+	}
+
+	// Invert the universal/local bit.
+	iid[0] ^= 0x02
+
+	return iid, nil
+}
+
+// MACFromEUI64 reverses EUI64 and returns the 48-bit MAC address from
+// which the modified EUI-64 interface identifier iid was derived. It
+// returns an error if iid does not contain the 0xfffe marker.
+func MACFromEUI64(iid []byte) (net.HardwareAddr, error) {
+	if len(iid) != InterfaceIDLen || iid[3] != 0xff || iid[4] != 0xfe {
+		return nil, errInvalidInterfaceID
+	}
+
+	mac := make(net.HardwareAddr, 6)
+	copy(mac[:3], iid[:3])
+	copy(mac[3:], iid[5:])
+	mac[0] ^= 0x02
+
+	return mac, nil
+}
+
+// InterfaceID returns the last 64 bits of the IPv6 address ip.
+func InterfaceID(ip net.IP) []byte {
+	if !IsIPv6(ip) {
+		return nil
+	}
+
+	iid := make([]byte, InterfaceIDLen)
+	copy(iid, ip[net.IPv6len-InterfaceIDLen:])
+
+	return iid
+}
+
+// JoinInterfaceID returns the IPv6 address formed by the first 64 bits
+// of prefix followed by the interface identifier iid. The prefix must be
+// an IPv6 prefix no longer than 64 bits.
+func JoinInterfaceID(prefix *net.IPNet, iid []byte) (net.IP, error) {
+	p, err := prefix64(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(iid) != InterfaceIDLen {
+		return nil, errInvalidInterfaceID
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, p)
+	copy(ip[8:], iid)
+
+	return ip, nil
+}
+
+// prefix64 returns the first 64 bits of prefix with the host bits
+// cleared.
+func prefix64(prefix *net.IPNet) ([]byte, error) {
+	if prefix == nil || len(prefix.IP) != net.IPv6len || IsIPv4(prefix.IP) {
+		return nil, errInvalidPrefix
+	}
+
+	ones, bits := prefix.Mask.Size()
+	if bits != IPv6BitLen || ones > 64 {
+		return nil, errInvalidPrefix
+	}
+
+	return prefix.IP.Mask(prefix.Mask)[:8], nil
+}
+
+// StableInterfaceID generates a stable, semantically opaque interface
+// identifier as specified in RFC 7217. The identifier is derived from
+// the secret key, the prefix, the name of the network interface, an
+// optional network identifier such as the SSID, and the DAD counter,
+// using HMAC-SHA256 as the pseudorandom function. Identifiers reserved
+// by RFC 5453 are skipped by incrementing the DAD counter.
+func StableInterfaceID(secret []byte, prefix *net.IPNet, ifaceName string, networkID []byte, dadCounter uint8) ([]byte, error) {
+	return opaqueInterfaceID(secret, prefix, ifaceName, networkID, nil, dadCounter)
+}
+
+// StableAddr returns the IPv6 address formed by prefix and the
+// interface identifier generated by StableInterfaceID.
+func StableAddr(secret []byte, prefix *net.IPNet, ifaceName string, networkID []byte, dadCounter uint8) (net.IP, error) {
+	iid, err := StableInterfaceID(secret, prefix, ifaceName, networkID, dadCounter)
+	if err != nil {
+		return nil, err
+	}
+
+	return JoinInterfaceID(prefix, iid)
+}
+
+// TemporaryInterfaceID generates a temporary interface identifier as
+// specified in section 3.3.2 of RFC 8981. It is computed like
+// StableInterfaceID, with the generation time t as an additional input,
+// so that a new identifier results from each regeneration.
+func TemporaryInterfaceID(secret []byte, prefix *net.IPNet, ifaceName string, networkID []byte, t time.Time, dadCounter uint8) ([]byte, error) {
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(t.UnixNano()))
+
+	return opaqueInterfaceID(secret, prefix, ifaceName, networkID, ts[:], dadCounter)
+}
+
+// TemporaryAddr returns the IPv6 address formed by prefix and the
+// interface identifier generated by TemporaryInterfaceID.
+func TemporaryAddr(secret []byte, prefix *net.IPNet, ifaceName string, networkID []byte, t time.Time, dadCounter uint8) (net.IP, error) {
+	iid, err := TemporaryInterfaceID(secret, prefix, ifaceName, networkID, t, dadCounter)
+	if err != nil {
+		return nil, err
+	}
+
+	return JoinInterfaceID(prefix, iid)
+}
+
+func opaqueInterfaceID(secret []byte, prefix *net.IPNet, ifaceName string, networkID []byte, ts []byte, dadCounter uint8) ([]byte, error) {
+	p, err := prefix64(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each variable-length input is preceded by its length, so that
+	// different inputs, such as "eth0" and "1ssid" or "eth01" and "ssid",
+	// cannot run together into the same bytes.
+	var input []byte
+	input = append(input, p...)
+	for _, b := range [][]byte{[]byte(ifaceName), networkID, ts} {
+		input = binary.BigEndian.AppendUint32(input, uint32(len(b)))
+		input = append(input, b...)
+	}
+
+	for {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(input)
+		mac.Write([]byte{dadCounter})
+
+		iid := mac.Sum(nil)[:InterfaceIDLen]
+		if !IsReservedInterfaceID(iid) {
+			return iid, nil
+		}
+		dadCounter++
+	}
+}
+
+// IsReservedInterfaceID reports whether iid is one of the reserved IPv6
+// interface identifiers listed in RFC 5453.
+func IsReservedInterfaceID(iid []byte) bool {
+	if len(iid) != InterfaceIDLen {
+		return false
+	}
+
+	x := binary.BigEndian.Uint64(iid)
+	switch {
+	case x == 0:
+		// Subnet-Router Anycast
+		return true
+	case x >= 0x02005efffe000000 && x <= 0x02005efffeffffff:
+		// Reserved IPv6 Interface Identifiers corresponding to the IANA
+		// Ethernet Block
+		return true
+	case x >= 0xfdffffffffffff80 && x <= 0xfdffffffffffffff:
+		// Reserved Subnet Anycast Addresses
+		return true
+	default:
+		return false
+	}
+}
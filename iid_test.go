@@ -0,0 +1,153 @@
+package iputil
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestEUI64(t *testing.T) {
+	cases := []struct {
+		mac string
+		iid []byte
+	}{
+		{"00:1a:2b:3c:4d:5e", []byte{0x02, 0x1a, 0x2b, 0xff, 0xfe, 0x3c, 0x4d, 0x5e}},
+		{"02:00:5e:10:00:00", []byte{0x00, 0x00, 0x5e, 0xff, 0xfe, 0x10, 0x00, 0x00}},
+		{"00:1a:2b:3c:4d:5e:6f:70", []byte{0x02, 0x1a, 0x2b, 0x3c, 0x4d, 0x5e, 0x6f, 0x70}},
+	}
+
+	for _, c := range cases {
+		mac, _ := net.ParseMAC(c.mac)
+		iid, err := EUI64(mac)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(iid, c.iid) {
+			t.Errorf("unexpected interface id for %s: got %x, want %x", c.mac, iid, c.iid)
+		}
+
+		if len(mac) != 6 {
+			continue
+		}
+		if rev, err := MACFromEUI64(iid); err != nil || rev.String() != c.mac {
+			t.Errorf("unexpected mac for %x: got %s, want %s", iid, rev, c.mac)
+		}
+	}
+
+	if _, err := EUI64(net.HardwareAddr{1, 2, 3}); err == nil {
+		t.Error("error expected for invalid mac")
+	}
+	if _, err := MACFromEUI64([]byte{1, 2, 3, 4, 5, 6, 7, 8}); err == nil {
+		t.Error("error expected for non-EUI-64 interface id")
+	}
+}
+
+func TestJoinInterfaceID(t *testing.T) {
+	_, prefix, _ := net.ParseCIDR("2001:db8:1:2::/64")
+	mac, _ := net.ParseMAC("00:1a:2b:3c:4d:5e")
+	iid, _ := EUI64(mac)
+
+	ip, err := JoinInterfaceID(prefix, iid)
+	if err != nil {
+		t.Error(err)
+	}
+	if want := ParseIPv6("2001:db8:1:2:21a:2bff:fe3c:4d5e"); !ip.Equal(want) {
+		t.Errorf("unexpected address: got %s, want %s", ip, want)
+	}
+	if !bytes.Equal(InterfaceID(ip), iid) {
+		t.Errorf("unexpected interface id: got %x, want %x", InterfaceID(ip), iid)
+	}
+
+	for _, cidr := range []string{"2001:db8::/96", "192.168.0.0/24"} {
+		_, prefix, _ := net.ParseCIDR(cidr)
+		if _, err := JoinInterfaceID(prefix, iid); err == nil {
+			t.Errorf("error expected for prefix %s", cidr)
+		}
+	}
+}
+
+func TestStableInterfaceID(t *testing.T) {
+	secret := []byte("secret")
+	_, prefix1, _ := net.ParseCIDR("2001:db8:1::/64")
+	_, prefix2, _ := net.ParseCIDR("2001:db8:2::/64")
+
+	a, _ := StableInterfaceID(secret, prefix1, "eth0", nil, 0)
+	b, _ := StableInterfaceID(secret, prefix1, "eth0", nil, 0)
+	if !bytes.Equal(a, b) {
+		t.Errorf("stable interface id is not stable: %x != %x", a, b)
+	}
+
+	// The interface name and network ID must not run together.
+	c, _ := StableInterfaceID(secret, prefix1, "eth0", []byte("1ssid"), 0)
+	d, _ := StableInterfaceID(secret, prefix1, "eth01", []byte("ssid"), 0)
+	if bytes.Equal(c, d) {
+		t.Errorf("interface id is the same for eth0/1ssid and eth01/ssid: %x", c)
+	}
+
+	variants := [][]byte{}
+	for _, f := range []func() ([]byte, error){
+		func() ([]byte, error) { return StableInterfaceID(secret, prefix2, "eth0", nil, 0) },
+		func() ([]byte, error) { return StableInterfaceID(secret, prefix1, "eth1", nil, 0) },
+		func() ([]byte, error) { return StableInterfaceID(secret, prefix1, "eth0", []byte("ssid"), 0) },
+		func() ([]byte, error) { return StableInterfaceID(secret, prefix1, "eth0", nil, 1) },
+		func() ([]byte, error) { return StableInterfaceID([]byte("other"), prefix1, "eth0", nil, 0) },
+	} {
+		iid, err := f()
+		if err != nil {
+			t.Error(err)
+		}
+		variants = append(variants, iid)
+	}
+	for _, v := range variants {
+		if bytes.Equal(a, v) {
+			t.Errorf("interface id did not change with its inputs: %x", v)
+		}
+	}
+
+	ip, _ := StableAddr(secret, prefix1, "eth0", nil, 0)
+	if !prefix1.Contains(ip) || !bytes.Equal(InterfaceID(ip), a) {
+		t.Errorf("unexpected stable address: %s", ip)
+	}
+}
+
+func TestTemporaryAddr(t *testing.T) {
+	secret := []byte("secret")
+	_, prefix, _ := net.ParseCIDR("2001:db8:1::/64")
+	t1 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(24 * time.Hour)
+
+	a, _ := TemporaryAddr(secret, prefix, "eth0", nil, t1, 0)
+	b, _ := TemporaryAddr(secret, prefix, "eth0", nil, t1, 0)
+	c, _ := TemporaryAddr(secret, prefix, "eth0", nil, t2, 0)
+	stable, _ := StableAddr(secret, prefix, "eth0", nil, 0)
+
+	if !prefix.Contains(a) || !a.Equal(b) {
+		t.Errorf("unexpected temporary address: %s, %s", a, b)
+	}
+	if a.Equal(c) || a.Equal(stable) {
+		t.Errorf("temporary address did not change: %s", a)
+	}
+}
+
+func TestIsReservedInterfaceID(t *testing.T) {
+	cases := []struct {
+		iid      []byte
+		reserved bool
+	}{
+		{[]byte{0, 0, 0, 0, 0, 0, 0, 0}, true},
+		{[]byte{0, 0, 0, 0, 0, 0, 0, 1}, false},
+		{[]byte{0x02, 0x00, 0x5e, 0xff, 0xfe, 0x00, 0x52, 0x13}, true},
+		{[]byte{0x02, 0x00, 0x5e, 0xff, 0xfe, 0xff, 0xff, 0xff}, true},
+		{[]byte{0x02, 0x00, 0x5e, 0xff, 0xff, 0x00, 0x00, 0x00}, false},
+		{[]byte{0xfd, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x80}, true},
+		{[]byte{0xfd, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, false},
+		{[]byte{0xfe, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, false},
+	}
+
+	for _, c := range cases {
+		if reserved := IsReservedInterfaceID(c.iid); reserved != c.reserved {
+			t.Errorf("unexpected result for %x: got %t, want %t", c.iid, reserved, c.reserved)
+		}
+	}
+}
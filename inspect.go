@@ -0,0 +1,292 @@
+package iputil
+
+import (
+	"net"
+	"strconv"
+)
+
+// An AddrType classifies an IPv6 address by its format prefix.
+type AddrType int
+
+// IPv6 address types, as defined in RFC 4291 and RFC 4193.
+const (
+	TypeUnknown AddrType = iota
+	TypeUnspecified
+	TypeLoopback
+	TypeIPv4Mapped
+	TypeLinkLocal
+	TypeSiteLocal
+	TypeUniqueLocal
+	TypeMulticast
+	TypeGlobalUnicast
+)
+
+var addrTypeNames = []string{
+	TypeUnknown:       "unknown",
+	TypeUnspecified:   "unspecified",
+	TypeLoopback:      "loopback",
+	TypeIPv4Mapped:    "ipv4-mapped",
+	TypeLinkLocal:     "link-local unicast",
+	TypeSiteLocal:     "site-local unicast",
+	TypeUniqueLocal:   "unique local unicast",
+	TypeMulticast:     "multicast",
+	TypeGlobalUnicast: "global unicast",
+}
+
+func (t AddrType) String() string {
+	if t < 0 || int(t) >= len(addrTypeNames) {
+		return "AddrType(" + strconv.Itoa(int(t)) + ")"
+	}
+
+	return addrTypeNames[t]
+}
+
+// A Scope is an IPv6 address scope, using the values of the 4-bit
+// multicast scope field defined in section 2.7 of RFC 4291 and RFC 7346.
+type Scope uint8
+
+// IPv6 address scopes.
+const (
+	ScopeInterfaceLocal    Scope = 0x1
+	ScopeLinkLocal         Scope = 0x2
+	ScopeRealmLocal        Scope = 0x3
+	ScopeAdminLocal        Scope = 0x4
+	ScopeSiteLocal         Scope = 0x5
+	ScopeOrganizationLocal Scope = 0x8
+	ScopeGlobal            Scope = 0xe
+)
+
+func (s Scope) String() string {
+	switch s {
+	case ScopeInterfaceLocal:
+		return "interface-local"
+	case ScopeLinkLocal:
+		return "link-local"
+	case ScopeRealmLocal:
+		return "realm-local"
+	case ScopeAdminLocal:
+		return "admin-local"
+	case ScopeSiteLocal:
+		return "site-local"
+	case ScopeOrganizationLocal:
+		return "organization-local"
+	case ScopeGlobal:
+		return "global"
+	default:
+		return "Scope(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// An Embedding identifies a mechanism that embeds an IPv4 address in
+// an IPv6 address.
+type Embedding int
+
+// IPv4 embedding mechanisms recognised by Inspect.
+const (
+	EmbeddingNone       Embedding = iota
+	EmbeddingIPv4Mapped           // ::ffff:0:0/96, RFC 4291
+	EmbeddingIPv4Compat           // ::/96, deprecated by RFC 4291
+	EmbeddingNAT64                // 64:ff9b::/96 and 64:ff9b:1::/48, RFC 6052 and RFC 8215
+	Embedding6to4                 // 2002::/16, RFC 3056
+	EmbeddingTeredo               // 2001::/32, RFC 4380
+)
+
+var embeddingNames = []string{
+	EmbeddingNone:       "none",
+	EmbeddingIPv4Mapped: "ipv4-mapped",
+	EmbeddingIPv4Compat: "ipv4-compatible",
+	EmbeddingNAT64:      "nat64",
+	Embedding6to4:       "6to4",
+	EmbeddingTeredo:     "teredo",
+}
+
+func (e Embedding) String() string {
+	if e < 0 || int(e) >= len(embeddingNames) {
+		return "Embedding(" + strconv.Itoa(int(e)) + ")"
+	}
+
+	return embeddingNames[e]
+}
+
+// MulticastInfo holds the flags and scope of an IPv6 multicast address.
+type MulticastInfo struct {
+	Flags uint8 // the 4-bit flags field, 0RPT
+	Scope Scope
+}
+
+// Transient reports whether the T flag is set, that is, the multicast
+// address is not permanently assigned by IANA.
+func (m *MulticastInfo) Transient() bool {
+	return m.Flags&0x1 != 0
+}
+
+// PrefixBased reports whether the P flag is set, that is, the multicast
+// address is based on a unicast prefix as specified in RFC 3306.
+func (m *MulticastInfo) PrefixBased() bool {
+	return m.Flags&0x2 != 0
+}
+
+// EmbeddedRP reports whether the R flag is set, that is, the multicast
+// address embeds the address of its rendezvous point as specified in
+// RFC 3956.
+func (m *MulticastInfo) EmbeddedRP() bool {
+	return m.Flags&0x4 != 0
+}
+
+// TeredoInfo holds the fields of a Teredo address, as specified in
+// section 4 of RFC 4380.
+type TeredoInfo struct {
+	Server net.IP // IPv4 address of the Teredo server
+	Client net.IP // external IPv4 address of the client
+	Port   uint16 // external UDP port of the client
+	Flags  uint16
+}
+
+// An Anatomy is a structured breakdown of an IPv6 address.
+type Anatomy struct {
+	IP    net.IP
+	Type  AddrType
+	Scope Scope
+
+	// Multicast holds the flags and scope of multicast addresses, and
+	// is nil for unicast addresses.
+	Multicast *MulticastInfo
+
+	// Embedding identifies the mechanism by which an IPv4 address is
+	// embedded in IP, and IPv4 holds that address.
+	Embedding Embedding
+	IPv4      net.IP
+
+	// Teredo holds the fields of Teredo addresses.
+	Teredo *TeredoInfo
+
+	// SolicitedNode is the solicited-node multicast address of unicast
+	// addresses.
+	SolicitedNode net.IP
+
+	// MAC is the hardware address from which the interface identifier
+	// was derived, if the interface identifier looks like a modified
+	// EUI-64 one.
+	MAC net.HardwareAddr
+}
+
+// Inspect returns a structured breakdown of the IPv6 address ip. It
+// returns nil if ip is not a 16-byte IPv6 address.
+func Inspect(ip net.IP) *Anatomy {
+	if !IsIPv6(ip) {
+		return nil
+	}
+
+	a := &Anatomy{
+		IP:    ip,
+		Type:  TypeOf(ip),
+		Scope: ScopeGlobal,
+	}
+
+	switch a.Type {
+	case TypeLoopback:
+		a.Scope = ScopeInterfaceLocal
+	case TypeLinkLocal:
+		a.Scope = ScopeLinkLocal
+	case TypeSiteLocal:
+		a.Scope = ScopeSiteLocal
+	case TypeMulticast:
+		a.Multicast = &MulticastInfo{
+			Flags: ip[1] >> 4,
+			Scope: Scope(ip[1] & 0x0f),
+		}
+		a.Scope = a.Multicast.Scope
+	}
+
+	a.Embedding, a.IPv4 = EmbeddedIPv4(ip)
+	if a.Embedding == EmbeddingTeredo {
+		a.Teredo = &TeredoInfo{
+			Server: net.IP{ip[4], ip[5], ip[6], ip[7]},
+			Client: a.IPv4,
+			Port:   (uint16(ip[10])<<8 | uint16(ip[11])) ^ 0xffff,
+			Flags:  uint16(ip[8])<<8 | uint16(ip[9]),
+		}
+	}
+
+	switch a.Type {
+	case TypeUnspecified, TypeIPv4Mapped, TypeMulticast:
+	default:
+		a.SolicitedNode = SolicitedNodeAddr(ip)
+		a.MAC, _ = MACFromEUI64(InterfaceID(ip))
+	}
+
+	return a
+}
+
+// TypeOf returns the type of the IPv6 address ip, or TypeUnknown if ip is
+// not a 16-byte IPv6 address.
+func TypeOf(ip net.IP) AddrType {
+	if !IsIPv6(ip) {
+		return TypeUnknown
+	}
+
+	switch {
+	case ip.Equal(net.IPv6unspecified):
+		return TypeUnspecified
+	case ip.Equal(net.IPv6loopback):
+		return TypeLoopback
+	case IsIPv4Mapped(ip):
+		return TypeIPv4Mapped
+	case ip[0] == 0xfe && ip[1]&0xc0 == 0x80:
+		return TypeLinkLocal
+	case ip[0] == 0xfe && ip[1]&0xc0 == 0xc0:
+		return TypeSiteLocal
+	case ip[0]&0xfe == 0xfc:
+		return TypeUniqueLocal
+	case ip[0] == 0xff:
+		return TypeMulticast
+	default:
+		return TypeGlobalUnicast
+	}
+}
+
+// EmbeddedIPv4 returns the IPv4 address embedded in the IPv6 address
+// ip, together with the embedding mechanism. Teredo client addresses are
+// returned with their obfuscation removed.
+func EmbeddedIPv4(ip net.IP) (Embedding, net.IP) {
+	if !IsIPv6(ip) {
+		return EmbeddingNone, nil
+	}
+
+	switch {
+	case IsIPv4Mapped(ip):
+		return EmbeddingIPv4Mapped, ip.To4()
+	case isZero(ip[:12]) && !isZero(ip[12:14]):
+		// Exclude :: and ::1, as well as other addresses in ::/112.
+		return EmbeddingIPv4Compat, copyIPv4(ip[12:])
+	case ip[0] == 0x00 && ip[1] == 0x64 && ip[2] == 0xff && ip[3] == 0x9b && isZero(ip[4:12]):
+		return EmbeddingNAT64, copyIPv4(ip[12:])
+	case ip[0] == 0x00 && ip[1] == 0x64 && ip[2] == 0xff && ip[3] == 0x9b && ip[4] == 0x00 && ip[5] == 0x01:
+		// The local-use prefix is a /48, so the IPv4 address follows
+		// it with bits 64 to 71 skipped, as specified in RFC 6052.
+		return EmbeddingNAT64, net.IP{ip[6], ip[7], ip[9], ip[10]}
+	case ip[0] == 0x20 && ip[1] == 0x02:
+		return Embedding6to4, copyIPv4(ip[2:6])
+	case ip[0] == 0x20 && ip[1] == 0x01 && ip[2] == 0x00 && ip[3] == 0x00:
+		return EmbeddingTeredo, net.IP{ip[12] ^ 0xff, ip[13] ^ 0xff, ip[14] ^ 0xff, ip[15] ^ 0xff}
+	default:
+		return EmbeddingNone, nil
+	}
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func copyIPv4(b []byte) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	copy(ip, b)
+
+	return ip
+}
@@ -0,0 +1,90 @@
+package iputil
+
+import (
+	"net"
+	"testing"
+)
+
+func TestTypeOf(t *testing.T) {
+	cases := []struct {
+		ip  net.IP
+		typ AddrType
+	}{
+		{nil, TypeUnknown},
+		{ParseIPv4("192.168.0.1"), TypeUnknown},
+		{ParseIPv6("::"), TypeUnspecified},
+		{ParseIPv6("::1"), TypeLoopback},
+		{ParseIPv6("::ffff:192.168.0.1"), TypeIPv4Mapped},
+		{ParseIPv6("fe80::1"), TypeLinkLocal},
+		{ParseIPv6("fec0::1"), TypeSiteLocal},
+		{ParseIPv6("fd00::1"), TypeUniqueLocal},
+		{ParseIPv6("ff02::1"), TypeMulticast},
+		{ParseIPv6("2001:db8::1"), TypeGlobalUnicast},
+	}
+
+	for _, c := range cases {
+		if typ := TypeOf(c.ip); typ != c.typ {
+			t.Errorf("unexpected type for %s: got %s, want %s", c.ip, typ, c.typ)
+		}
+	}
+}
+
+func TestEmbeddedIPv4(t *testing.T) {
+	cases := []struct {
+		ip        net.IP
+		embedding Embedding
+		ipv4      net.IP
+	}{
+		{ParseIPv6("2001:db8::1"), EmbeddingNone, nil},
+		{ParseIPv6("::1"), EmbeddingNone, nil},
+		{ParseIPv6("::ffff:192.0.2.33"), EmbeddingIPv4Mapped, ParseIPv4("192.0.2.33")},
+		{ParseIPv6("::192.0.2.33"), EmbeddingIPv4Compat, ParseIPv4("192.0.2.33")},
+		{ParseIPv6("64:ff9b::192.0.2.33"), EmbeddingNAT64, ParseIPv4("192.0.2.33")},
+		{ParseIPv6("64:ff9b:1:c000:2:2100::"), EmbeddingNAT64, ParseIPv4("192.0.2.33")},
+		{ParseIPv6("2002:c000:221::1"), Embedding6to4, ParseIPv4("192.0.2.33")},
+		{ParseIPv6("2001:0:4136:e378:8000:63bf:3fff:fdd2"), EmbeddingTeredo, ParseIPv4("192.0.2.45")},
+	}
+
+	for _, c := range cases {
+		embedding, ipv4 := EmbeddedIPv4(c.ip)
+		if embedding != c.embedding || !ipv4.Equal(c.ipv4) {
+			t.Errorf("unexpected embedding for %s: got %s %s, want %s %s", c.ip, embedding, ipv4, c.embedding, c.ipv4)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	if Inspect(ParseIPv4("192.168.0.1")) != nil {
+		t.Error("nil expected for IPv4 address")
+	}
+
+	a := Inspect(ParseIPv6("fe80::21a:2bff:fe3c:4d5e"))
+	if a.Type != TypeLinkLocal || a.Scope != ScopeLinkLocal || a.Multicast != nil {
+		t.Errorf("unexpected type or scope: %s %s", a.Type, a.Scope)
+	}
+	if want := ParseIPv6("ff02::1:ff3c:4d5e"); !a.SolicitedNode.Equal(want) {
+		t.Errorf("unexpected solicited-node address: got %s, want %s", a.SolicitedNode, want)
+	}
+	if want := "00:1a:2b:3c:4d:5e"; a.MAC.String() != want {
+		t.Errorf("unexpected mac: got %s, want %s", a.MAC, want)
+	}
+
+	a = Inspect(ParseIPv6("ff35::1234"))
+	if a.Type != TypeMulticast || a.Scope != ScopeSiteLocal || a.SolicitedNode != nil {
+		t.Errorf("unexpected type or scope: %s %s", a.Type, a.Scope)
+	}
+	if !a.Multicast.PrefixBased() || !a.Multicast.Transient() || a.Multicast.EmbeddedRP() {
+		t.Errorf("unexpected multicast flags: %04b", a.Multicast.Flags)
+	}
+
+	a = Inspect(ParseIPv6("2001:0:4136:e378:8000:63bf:3fff:fdd2"))
+	if a.Teredo == nil {
+		t.Fatal("teredo info expected")
+	}
+	if !a.Teredo.Server.Equal(ParseIPv4("65.54.227.120")) || !a.Teredo.Client.Equal(ParseIPv4("192.0.2.45")) || a.Teredo.Port != 40000 || a.Teredo.Flags != 0x8000 {
+		t.Errorf("unexpected teredo info: %+v", a.Teredo)
+	}
+	if a.MAC != nil {
+		t.Errorf("unexpected mac: %s", a.MAC)
+	}
+}
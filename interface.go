@@ -2,73 +2,237 @@ package iputil
 
 import (
 	"errors"
+	"math"
 	"net"
+	"path"
+	"time"
 )
 
+// AddrFlags are the IPv6 address state flags, as reported by the Linux
+// kernel. They are always zero on other systems.
+type AddrFlags uint32
+
+// IPv6 address state flags, using the values of the Linux IFA_F_*
+// constants.
+const (
+	AddrTemporary  AddrFlags = 0x01 // temporary address (RFC 8981)
+	AddrNoDAD      AddrFlags = 0x02 // duplicate address detection disabled
+	AddrOptimistic AddrFlags = 0x04 // optimistic DAD (RFC 4429)
+	AddrDADFailed  AddrFlags = 0x08 // duplicate address detection failed
+	AddrHomeAddr   AddrFlags = 0x10 // Mobile IPv6 home address
+	AddrDeprecated AddrFlags = 0x20 // preferred lifetime expired
+	AddrTentative  AddrFlags = 0x40 // duplicate address detection pending
+	AddrPermanent  AddrFlags = 0x80 // statically configured
+)
+
+// InfiniteLifetime is the lifetime of an address that does not expire.
+const InfiniteLifetime time.Duration = math.MaxInt64
+
 // InterfaceAddr represents an interface IP address and the name of its
 // associated network interface.
 type InterfaceAddr struct {
 	*net.IPNet
 	InterfaceName string
+
+	// Flags are the flags of the associated network interface.
+	Flags net.Flags
+
+	// AddrFlags are the state flags of an IPv6 address.
+	AddrFlags AddrFlags
+
+	// InterfaceIndex, MTU and HardwareAddr are the index, maximum
+	// transmission unit and hardware address of the associated network
+	// interface. HardwareAddr is empty for interfaces without one.
+	InterfaceIndex int
+	MTU            int
+	HardwareAddr   net.HardwareAddr
+
+	// PreferredLifetime and ValidLifetime are the remaining lifetimes of
+	// the address, as of when it was read, after which it becomes
+	// deprecated and invalid respectively. They are InfiniteLifetime for
+	// addresses that do not expire, and zero if unknown, as they are only
+	// available on Linux.
+	PreferredLifetime time.Duration
+	ValidLifetime     time.Duration
 }
 
-// Interface returns the associated network interface.
+// VirtualInterfaces are the name patterns of interfaces considered to
+// be virtual by IsVirtual, in the syntax of path.Match. They cover the
+// bridges and virtual Ethernet devices created by common container and
+// virtual machine managers.
+var VirtualInterfaces = []string{
+	"docker*", "br-*", "veth*", "virbr*", "vnet*", "vmnet*", "vboxnet*",
+	"cni*", "flannel*", "cali*", "weave*", "lxcbr*", "lxdbr*", "podman*",
+}
+
+// Interface returns the associated network interface. It is built from
+// the metadata of addr if its InterfaceIndex is known, and looked up by
+// name otherwise.
 func (addr *InterfaceAddr) Interface() (*net.Interface, error) {
-	return net.InterfaceByName(addr.InterfaceName)
+	if addr.InterfaceIndex == 0 {
+		return net.InterfaceByName(addr.InterfaceName)
+	}
+
+	return &net.Interface{
+		Index:        addr.InterfaceIndex,
+		MTU:          addr.MTU,
+		Name:         addr.InterfaceName,
+		HardwareAddr: addr.HardwareAddr,
+		Flags:        addr.Flags,
+	}, nil
 }
 
-// InterfaceAddrs returns a list of the system's unicast interface IP
-// addresses.
-func InterfaceAddrs() ([]*InterfaceAddr, error) {
-	var ifAddrs []*InterfaceAddr
+// Family returns the address family of the interface address: 1 for
+// IPv4, 2 for IPv6, and 0 for everthing else.
+func (addr *InterfaceAddr) Family() uint {
+	return AddrFromIP(addr.IP).Family()
+}
 
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		return nil, err
-	}
+// IsUp reports whether the associated interface is up.
+func (addr *InterfaceAddr) IsUp() bool {
+	return addr.Flags&net.FlagUp != 0
+}
 
-	for _, iface := range ifaces {
-		addrs, err := iface.Addrs()
-		if err != nil {
-			return nil, err
-		}
+// IsLoopback reports whether the address is a loopback address, or is
+// assigned to a loopback interface.
+func (addr *InterfaceAddr) IsLoopback() bool {
+	return addr.IP.IsLoopback() || addr.Flags&net.FlagLoopback != 0
+}
+
+// IsPointToPoint reports whether the associated interface is a
+// point-to-point link, such as a VPN tunnel.
+func (addr *InterfaceAddr) IsPointToPoint() bool {
+	return addr.Flags&net.FlagPointToPoint != 0
+}
+
+// IsVirtual reports whether the name of the associated interface
+// matches any of the patterns in VirtualInterfaces.
+func (addr *InterfaceAddr) IsVirtual() bool {
+	return matchName(addr.InterfaceName, VirtualInterfaces)
+}
+
+// IsLinkLocal reports whether the address is a link-local unicast
+// address, in 169.254.0.0/16 or fe80::/10.
+func (addr *InterfaceAddr) IsLinkLocal() bool {
+	return addr.IP.IsLinkLocalUnicast()
+}
+
+// IsUniqueLocal reports whether the address is an IPv6 unique local
+// address, in fc00::/7.
+func (addr *InterfaceAddr) IsUniqueLocal() bool {
+	return TypeOf(addr.IP) == TypeUniqueLocal
+}
 
-		for _, addr := range addrs {
-			ifAddrs = append(ifAddrs, &InterfaceAddr{addr.(*net.IPNet), iface.Name})
+// IsTemporary reports whether the address is a temporary IPv6 address,
+// as generated for privacy extensions.
+func (addr *InterfaceAddr) IsTemporary() bool {
+	return addr.AddrFlags&AddrTemporary != 0
+}
+
+// IsDeprecated reports whether the address is a deprecated IPv6
+// address, which should not be used for new connections.
+func (addr *InterfaceAddr) IsDeprecated() bool {
+	return addr.AddrFlags&AddrDeprecated != 0
+}
+
+// IsTentative reports whether the address is an IPv6 address that
+// cannot be used yet, or ever, as duplicate address detection is still
+// in progress or has failed.
+func (addr *InterfaceAddr) IsTentative() bool {
+	return addr.AddrFlags&(AddrTentative|AddrDADFailed) != 0
+}
+
+// matchName reports whether name matches any of the patterns.
+func matchName(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
 		}
 	}
 
-	return ifAddrs, nil
+	return false
+}
+
+// InterfaceAddrs returns a list of the system's unicast interface IP
+// addresses, as provided by DefaultSource.
+func InterfaceAddrs() ([]*InterfaceAddr, error) {
+	return DefaultSource.InterfaceAddrs()
+}
+
+// ifaceAddrKey identifies an address assigned to an interface.
+type ifaceAddrKey struct {
+	index int
+	addr  Addr
+}
+
+// addrInfo is the state of an address not provided by the net package.
+type addrInfo struct {
+	flags            AddrFlags
+	preferred, valid time.Duration
 }
 
-// DefaultIPv4 returns the first non-loopback interface IPv4 address.
+// DefaultIPv4 returns the interface IPv4 address preferred by
+// DefaultPolicy.
 func DefaultIPv4() (*InterfaceAddr, error) {
-	ifAddrs, err := InterfaceAddrs()
-	if err != nil {
-		return nil, err
+	addr, err := DefaultAddr(IPv4, DefaultPolicy)
+	if err == errNoAddr {
+		return nil, errors.New("default ipv4 unavailable")
 	}
 
-	for _, addr := range ifAddrs {
-		if IsIPv4(addr.IP) && !addr.IP.IsLoopback() {
-			return addr, nil
-		}
+	return addr, err
+}
+
+// DefaultIPv6 returns the interface IPv6 address preferred by
+// DefaultPolicy.
+func DefaultIPv6() (*InterfaceAddr, error) {
+	addr, err := DefaultAddr(IPv6, DefaultPolicy)
+	if err == errNoAddr {
+		return nil, errors.New("default ipv6 unavailable")
 	}
 
-	return nil, errors.New("default ipv4 unavailable")
+	return addr, err
 }
 
-// DefaultIPv6 returns the first non-loopback interface IPv6 address.
-func DefaultIPv6() (*InterfaceAddr, error) {
+// SourceAddrFor returns the interface address that the system would use
+// as the source address when sending packets to dst, as chosen by
+// DefaultSource if it is a SourceSelector. OSSource asks the kernel,
+// which consults the routing table.
+//
+// If there is no route to dst, or DefaultSource is not a SourceSelector,
+// it falls back to the address of the same family as dst preferred by
+// DefaultPolicy.
+func SourceAddrFor(dst net.IP) (*InterfaceAddr, error) {
+	af := AddrFromIP(dst).Family()
+	if af == 0 {
+		return nil, errors.New("invalid destination address")
+	}
+
+	var src net.IP
+	if s, ok := DefaultSource.(SourceSelector); ok {
+		addr, err := s.SourceAddr(dst)
+		if err != nil {
+			return nil, err
+		}
+		src = addr
+	}
+	if src == nil {
+		return DefaultAddr(af, DefaultPolicy)
+	}
+
 	ifAddrs, err := InterfaceAddrs()
 	if err != nil {
 		return nil, err
 	}
 
 	for _, addr := range ifAddrs {
-		if IsIPv6(addr.IP) && !IsIPv4(addr.IP) && !addr.IP.IsLoopback() {
+		if addr.IP.Equal(src) {
 			return addr, nil
 		}
 	}
 
-	return nil, errors.New("default ipv6 unavailable")
+	// The source address is not assigned to any interface, as may be the
+	// case for addresses that are routed to the host.
+	return &InterfaceAddr{
+		IPNet: &net.IPNet{IP: src, Mask: net.CIDRMask(BitLen(src), BitLen(src))},
+	}, nil
 }
@@ -0,0 +1,133 @@
+package iputil
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Netlink attribute types of address messages, from linux/if_addr.h.
+const (
+	ifaAddress   = 1
+	ifaLocal     = 2
+	ifaCacheInfo = 6
+)
+
+// addrInfos returns the state of the addresses of all interfaces. The
+// state flags of IPv6 addresses are read from /proc/net/if_inet6, and
+// the lifetimes of all addresses are requested through netlink. As
+// either may fail independently, the information that could be gathered
+// is returned along with the first error.
+func addrInfos() (map[ifaceAddrKey]addrInfo, error) {
+	infos := make(map[ifaceAddrKey]addrInfo)
+
+	entries, flagsErr := readIfInet6("/proc/net/if_inet6")
+	for _, e := range entries {
+		infos[ifaceAddrKey{e.index, e.addr}] = addrInfo{flags: e.flags}
+	}
+
+	lifetimes, err := netlinkAddrLifetimes()
+	for key, lt := range lifetimes {
+		info := infos[key]
+		info.preferred, info.valid = lt.preferred, lt.valid
+		infos[key] = info
+	}
+
+	if flagsErr != nil {
+		return infos, flagsErr
+	}
+	return infos, err
+}
+
+// readIfInet6 reads and parses the if_inet6 file at name.
+func readIfInet6(name string) ([]ifInet6Entry, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseIfInet6(f)
+}
+
+// netlinkAddrLifetimes requests the addresses of all interfaces from the
+// kernel and returns their lifetimes.
+func netlinkAddrLifetimes() (map[ifaceAddrKey]addrInfo, error) {
+	rib, err := syscall.NetlinkRIB(syscall.RTM_GETADDR, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, os.NewSyscallError("netlinkrib", err)
+	}
+
+	msgs, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		return nil, os.NewSyscallError("parsenetlinkmessage", err)
+	}
+
+	return parseAddrLifetimes(msgs)
+}
+
+// parseAddrLifetimes returns the lifetimes of the addresses in the
+// RTM_NEWADDR messages among msgs. Each message starts with an
+// ifaddrmsg structure, which holds the interface index, followed by
+// attributes including the address and its cache info. For IPv4, the
+// local address is in IFA_LOCAL, as IFA_ADDRESS is the peer address on
+// point-to-point interfaces.
+func parseAddrLifetimes(msgs []syscall.NetlinkMessage) (map[ifaceAddrKey]addrInfo, error) {
+	lifetimes := make(map[ifaceAddrKey]addrInfo)
+
+	for i := range msgs {
+		m := &msgs[i]
+		if m.Header.Type != syscall.RTM_NEWADDR {
+			continue
+		}
+		if len(m.Data) < syscall.SizeofIfAddrmsg {
+			return nil, errors.New("invalid netlink address message")
+		}
+		index := int(binary.NativeEndian.Uint32(m.Data[4:8]))
+
+		attrs, err := syscall.ParseNetlinkRouteAttr(m)
+		if err != nil {
+			return nil, os.NewSyscallError("parsenetlinkrouteattr", err)
+		}
+
+		var addr, local Addr
+		var info addrInfo
+		var hasInfo bool
+		for _, a := range attrs {
+			switch a.Attr.Type {
+			case ifaAddress:
+				addr = AddrFromIP(a.Value)
+			case ifaLocal:
+				local = AddrFromIP(a.Value)
+			case ifaCacheInfo:
+				if len(a.Value) < 8 {
+					return nil, errors.New("invalid netlink address cache info")
+				}
+				info.preferred = lifetime(binary.NativeEndian.Uint32(a.Value[0:4]))
+				info.valid = lifetime(binary.NativeEndian.Uint32(a.Value[4:8]))
+				hasInfo = true
+			}
+		}
+		if local.IsValid() {
+			addr = local
+		}
+
+		if addr.IsValid() && hasInfo {
+			lifetimes[ifaceAddrKey{index, addr}] = info
+		}
+	}
+
+	return lifetimes, nil
+}
+
+// lifetime converts an address lifetime in seconds, as reported by the
+// kernel, to a time.Duration.
+func lifetime(v uint32) time.Duration {
+	if v == 0xffffffff {
+		return InfiniteLifetime
+	}
+
+	return time.Duration(v) * time.Second
+}
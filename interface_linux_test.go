@@ -0,0 +1,123 @@
+package iputil
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// newAddrMessage returns an RTM_NEWADDR message for an address on the
+// interface with the given index, with the given attributes.
+func newAddrMessage(family byte, index uint32, attrs map[uint16][]byte) syscall.NetlinkMessage {
+	data := make([]byte, syscall.SizeofIfAddrmsg)
+	data[0] = family
+	binary.NativeEndian.PutUint32(data[4:8], index)
+
+	for _, typ := range []uint16{ifaAddress, ifaLocal, ifaCacheInfo} {
+		v, ok := attrs[typ]
+		if !ok {
+			continue
+		}
+
+		attr := make([]byte, syscall.SizeofRtAttr, syscall.SizeofRtAttr+len(v)+3)
+		binary.NativeEndian.PutUint16(attr[0:2], uint16(syscall.SizeofRtAttr+len(v)))
+		binary.NativeEndian.PutUint16(attr[2:4], typ)
+		attr = append(attr, v...)
+		for len(attr)%4 != 0 {
+			attr = append(attr, 0)
+		}
+		data = append(data, attr...)
+	}
+
+	return syscall.NetlinkMessage{
+		Header: syscall.NlMsghdr{Type: syscall.RTM_NEWADDR},
+		Data:   data,
+	}
+}
+
+func cacheInfo(preferred, valid uint32) []byte {
+	b := make([]byte, 16)
+	binary.NativeEndian.PutUint32(b[0:4], preferred)
+	binary.NativeEndian.PutUint32(b[4:8], valid)
+
+	return b
+}
+
+func TestParseAddrLifetimes(t *testing.T) {
+	msgs := []syscall.NetlinkMessage{
+		newAddrMessage(syscall.AF_INET, 2, map[uint16][]byte{
+			ifaAddress:   {192, 168, 1, 10},
+			ifaLocal:     {192, 168, 1, 10},
+			ifaCacheInfo: cacheInfo(3600, 7200),
+		}),
+		newAddrMessage(syscall.AF_INET, 4, map[uint16][]byte{
+			ifaAddress:   {10, 8, 0, 1},
+			ifaLocal:     {10, 8, 0, 2},
+			ifaCacheInfo: cacheInfo(0xffffffff, 0xffffffff),
+		}),
+		newAddrMessage(syscall.AF_INET6, 2, map[uint16][]byte{
+			ifaAddress:   net.ParseIP("2001:db8::10"),
+			ifaCacheInfo: cacheInfo(0, 600),
+		}),
+		newAddrMessage(syscall.AF_INET6, 2, map[uint16][]byte{
+			ifaAddress: net.ParseIP("2001:db8::20"),
+		}),
+		{Header: syscall.NlMsghdr{Type: syscall.NLMSG_DONE}},
+	}
+
+	lifetimes, err := parseAddrLifetimes(msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		index            int
+		addr             string
+		preferred, valid time.Duration
+	}{
+		{2, "192.168.1.10", time.Hour, 2 * time.Hour},
+		{4, "10.8.0.2", InfiniteLifetime, InfiniteLifetime},
+		{2, "2001:db8::10", 0, 10 * time.Minute},
+	}
+
+	if len(lifetimes) != len(cases) {
+		t.Errorf("unexpected number of entries: got %d, want %d", len(lifetimes), len(cases))
+	}
+	for _, c := range cases {
+		a, _ := ParseAddr(c.addr)
+		lt, ok := lifetimes[ifaceAddrKey{c.index, a}]
+		if !ok || lt.preferred != c.preferred || lt.valid != c.valid {
+			t.Errorf("unexpected lifetimes for %s: got %v, %v", c.addr, lt.preferred, lt.valid)
+		}
+	}
+
+	short := []syscall.NetlinkMessage{{Header: syscall.NlMsghdr{Type: syscall.RTM_NEWADDR}, Data: []byte{2}}}
+	if _, err := parseAddrLifetimes(short); err == nil {
+		t.Error("error expected for short message")
+	}
+}
+
+func TestOSSourceLifetimes(t *testing.T) {
+	addrs, err := OSSource{}.InterfaceAddrs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, addr := range addrs {
+		if !addr.IP.Equal(net.IPv4(127, 0, 0, 1)) {
+			continue
+		}
+
+		if addr.InterfaceIndex == 0 || addr.MTU == 0 {
+			t.Errorf("unexpected interface metadata: #%d, mtu %d", addr.InterfaceIndex, addr.MTU)
+		}
+		if addr.ValidLifetime != InfiniteLifetime {
+			t.Errorf("unexpected lifetime of loopback address: %v", addr.ValidLifetime)
+		}
+		return
+	}
+
+	t.Skip("no loopback address")
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package iputil
+
+import "errors"
+
+// addrInfos is not supported on this system.
+func addrInfos() (map[ifaceAddrKey]addrInfo, error) {
+	return nil, errors.New("address state unavailable")
+}
@@ -37,3 +37,83 @@ func TestInterfaceAddrs(t *testing.T) {
 		t.Errorf("unexpected interface: want %s (#%d), get %s (#%d)", iface.Name, iface.Index, lo.Name, lo.Index)
 	}
 }
+
+func TestSourceAddrFor(t *testing.T) {
+	addr, err := SourceAddrFor(net.IPv4(127, 0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !addr.IP.IsLoopback() || !addr.IsLoopback() {
+		t.Errorf("unexpected source address: got %s on %s", addr.IP, addr.InterfaceName)
+	}
+
+	if _, err := SourceAddrFor(nil); err == nil {
+		t.Error("error expected for nil destination")
+	}
+}
+
+// routedSource is a SourceSelector whose source address is routed to the
+// host rather than assigned to an interface.
+type routedSource struct {
+	StaticSource
+}
+
+func (routedSource) SourceAddr(dst net.IP) (net.IP, error) {
+	return ParseIPv4("198.51.100.1"), nil
+}
+
+func TestSourceAddrForSource(t *testing.T) {
+	defer func(src InterfaceSource) { DefaultSource = src }(DefaultSource)
+	DefaultSource = StaticSource(testAddrs)
+
+	cases := []struct {
+		dst  net.IP
+		addr string
+	}{
+		{ParseIPv4("127.0.0.2"), "lo 127.0.0.1"},
+		{ParseIPv4("172.17.5.5"), "docker0 172.17.0.1"},
+		{ParseIPv4("192.168.1.99"), "eth0 192.168.1.10"},
+		{ParseIPv6("2001:db8::99"), "eth0 2001:db8::1122:3344"},
+
+		// eth1 is down, so there is no route to 10.0.0.0/8, and the
+		// default address is used instead.
+		{ParseIPv4("10.1.2.3"), "eth0 192.168.1.10"},
+		{ParseIPv6("2001:db9::1"), "eth0 2001:db8::10"},
+	}
+	for _, c := range cases {
+		addr, err := SourceAddrFor(c.dst)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", c.dst, err)
+			continue
+		}
+		if got := addr.InterfaceName + " " + addr.IP.String(); got != c.addr {
+			t.Errorf("unexpected source address for %s: got %s, want %s", c.dst, got, c.addr)
+		}
+	}
+
+	DefaultSource = routedSource{StaticSource(testAddrs)}
+	addr, err := SourceAddrFor(ParseIPv4("192.0.2.1"))
+	if err != nil || addr.String() != "198.51.100.1/32" || addr.InterfaceName != "" {
+		t.Errorf("unexpected source address for unassigned address: got %v, %v", addr, err)
+	}
+}
+
+func TestInterfaceAddrInterface(t *testing.T) {
+	addr := &InterfaceAddr{
+		IPNet:          &net.IPNet{IP: ParseIPv4("192.168.1.10"), Mask: net.CIDRMask(24, 32)},
+		InterfaceName:  "eth0",
+		Flags:          net.FlagUp,
+		InterfaceIndex: 42,
+		MTU:            9000,
+		HardwareAddr:   net.HardwareAddr{0x00, 0x50, 0x56, 0x8a, 0x12, 0x34},
+	}
+
+	iface, err := addr.Interface()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iface.Index != 42 || iface.Name != "eth0" || iface.MTU != 9000 || iface.Flags != net.FlagUp ||
+		iface.HardwareAddr.String() != "00:50:56:8a:12:34" {
+		t.Errorf("unexpected interface: %+v", iface)
+	}
+}
@@ -0,0 +1,13 @@
+package radix
+
+import "github.com/ericyan/iputil"
+
+// PrefixKey returns the key of p for SetPrefix, GetPrefix and
+// Overlapping, and its length in bits. The key is a byte for the address
+// family followed by the address, so that prefixes of different families
+// never overlap, and its length covers the family byte and the network
+// bits of p.
+func PrefixKey(p iputil.Prefix) ([]byte, int) {
+	addr := p.Addr()
+	return append([]byte{byte(addr.Family())}, addr.IP()...), 8 + p.Bits()
+}
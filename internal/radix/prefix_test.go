@@ -0,0 +1,35 @@
+package radix
+
+import (
+	"testing"
+
+	"github.com/ericyan/iputil"
+)
+
+func TestPrefixKey(t *testing.T) {
+	tree := NewTree()
+	for _, s := range []string{"0.0.0.0/0", "192.0.2.0/24", "::/0", "2001:db8::/32"} {
+		p, _ := iputil.ParsePrefix(s)
+		key, n := PrefixKey(p)
+		tree.SetPrefix(key, n, s)
+	}
+
+	cases := []struct {
+		prefix string
+		values int
+	}{
+		{"192.0.2.128/25", 2},
+		{"198.51.100.0/24", 1},
+		{"2001:db8:1::/48", 2},
+		{"::ffff:192.0.2.0/120", 1},
+	}
+
+	for _, c := range cases {
+		p, _ := iputil.ParsePrefix(c.prefix)
+		key, n := PrefixKey(p)
+		values, err := tree.Overlapping(key, n)
+		if err != nil || len(values) != c.values {
+			t.Errorf("unexpected overlapping values for %s: got %v, %v, want %d", c.prefix, values, err, c.values)
+		}
+	}
+}
@@ -59,10 +59,20 @@ func (t *Tree) Get(key []byte) (interface{}, error) {
 	if len(key) == 0 {
 		return nil, ErrInvalidKey
 	}
+
+	return t.GetPrefix(key, bitset(key).BitLen())
+}
+
+// GetPrefix retrieves the value for the key consisting of the first n
+// bits of key.
+func (t *Tree) GetPrefix(key []byte, n int) (interface{}, error) {
 	bits := bitset(key)
+	if n < 0 || n > bits.BitLen() {
+		return nil, ErrInvalidKey
+	}
 
 	cur := t.root
-	for i := 0; i < bits.BitLen(); i++ {
+	for i := 0; i < n; i++ {
 		child := cur.findChild(bits.Get(uint(i)))
 		if child == nil {
 			return nil, ErrNotFound
@@ -84,10 +94,21 @@ func (t *Tree) Set(key []byte, value interface{}) error {
 	if len(key) == 0 {
 		return ErrInvalidKey
 	}
+
+	return t.SetPrefix(key, bitset(key).BitLen(), value)
+}
+
+// SetPrefix sets the value for the key consisting of the first n bits
+// of key, such as the network bits of an IP prefix. If the key already
+// exists, its previous value will be overwritten.
+func (t *Tree) SetPrefix(key []byte, n int, value interface{}) error {
 	bits := bitset(key)
+	if n < 0 || n > bits.BitLen() {
+		return ErrInvalidKey
+	}
 
 	cur := t.root
-	for i := 0; i < bits.BitLen(); i++ {
+	for i := 0; i < n; i++ {
 		child := cur.findChild(bits.Get(uint(i)))
 		if child == nil {
 			child = cur.newChild(bits.Get(uint(i)))
@@ -99,3 +120,44 @@ func (t *Tree) Set(key []byte, value interface{}) error {
 	cur.value = value
 	return nil
 }
+
+// Overlapping returns the values of the keys that are prefixes of the
+// first n bits of key, or of which those bits are a prefix, including
+// the key itself. Values are ordered by key, shorter keys first.
+func (t *Tree) Overlapping(key []byte, n int) ([]interface{}, error) {
+	bits := bitset(key)
+	if n < 0 || n > bits.BitLen() {
+		return nil, ErrInvalidKey
+	}
+
+	var values []interface{}
+	cur := t.root
+	for i := 0; i < n; i++ {
+		if cur.isLeaf() {
+			values = append(values, cur.value)
+		}
+
+		cur = cur.findChild(bits.Get(uint(i)))
+		if cur == nil {
+			return values, nil
+		}
+	}
+
+	return cur.appendValues(values), nil
+}
+
+// appendValues appends the values of n and its descendants to values,
+// in depth-first order with the 0 edge first.
+func (n *node) appendValues(values []interface{}) []interface{} {
+	if n.isLeaf() {
+		values = append(values, n.value)
+	}
+
+	for _, label := range []byte{0, 1} {
+		if child := n.findChild(label); child != nil {
+			values = child.appendValues(values)
+		}
+	}
+
+	return values
+}
@@ -1,6 +1,7 @@
 package radix
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -37,3 +38,65 @@ func TestTree(t *testing.T) {
 	testSet(tree, nil, nil, ErrInvalidKey, t)
 	testGet(tree, nil, nil, ErrInvalidKey, t)
 }
+
+func TestTreePrefix(t *testing.T) {
+	tree := NewTree()
+
+	prefixes := []struct {
+		key []byte
+		n   int
+	}{
+		{[]byte{0x0a, 0x00}, 8},
+		{[]byte{0x0a, 0x80}, 9},
+		{[]byte{0x0a, 0x01}, 16},
+		{[]byte{0xc0, 0xa8}, 16},
+		{[]byte{0x00, 0x00}, 0},
+	}
+	for _, p := range prefixes {
+		if err := tree.SetPrefix(p.key, p.n, p.n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := tree.Get([]byte{0x0a, 0x01}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if v, err := tree.GetPrefix([]byte{0x0a, 0xff}, 9); err != nil || v != 9 {
+		t.Errorf("unexpected value: got '%v', '%v'", v, err)
+	}
+	if _, err := tree.GetPrefix([]byte{0x0a, 0xff}, 10); err != ErrNotFound {
+		t.Errorf("unexpected error: got '%v', want '%v'", err, ErrNotFound)
+	}
+	if _, err := tree.GetPrefix([]byte{0x0a}, 9); err != ErrInvalidKey {
+		t.Errorf("unexpected error: got '%v', want '%v'", err, ErrInvalidKey)
+	}
+
+	cases := []struct {
+		key    []byte
+		n      int
+		values []interface{}
+	}{
+		{[]byte{0x0a, 0x00}, 8, []interface{}{0, 8, 16, 9}},
+		{[]byte{0x0a, 0x80}, 12, []interface{}{0, 8, 9}},
+		{[]byte{0x0a, 0x02}, 16, []interface{}{0, 8}},
+		{[]byte{0xc0, 0x00}, 2, []interface{}{0, 16}},
+		{[]byte{0x00, 0x00}, 0, []interface{}{0, 8, 16, 9, 16}},
+	}
+
+	for _, c := range cases {
+		values, err := tree.Overlapping(c.key, c.n)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(values, c.values) {
+			t.Errorf("unexpected values for %x/%d: got %v, want %v", c.key, c.n, values, c.values)
+		}
+	}
+
+	if err := tree.SetPrefix([]byte{0x0a}, 9, nil); err != ErrInvalidKey {
+		t.Errorf("unexpected error: got '%v', want '%v'", err, ErrInvalidKey)
+	}
+	if _, err := tree.Overlapping([]byte{0x0a}, -1); err != ErrInvalidKey {
+		t.Errorf("unexpected error: got '%v', want '%v'", err, ErrInvalidKey)
+	}
+}
@@ -76,3 +76,10 @@ func TestDecimalString(t *testing.T) {
 		}
 	}
 }
+
+func BenchmarkDecimalString(b *testing.B) {
+	ip := ParseIPv6("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")
+	for i := 0; i < b.N; i++ {
+		DecimalString(ip)
+	}
+}
@@ -0,0 +1,505 @@
+package mmdb
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ericyan/iputil/uint128"
+)
+
+// A dataType is the type of a value in the data section.
+type dataType int
+
+// Data section types.
+const (
+	typeExtended dataType = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+var typeNames = [...]string{
+	"extended", "pointer", "utf8_string", "double", "bytes", "uint16",
+	"uint32", "map", "int32", "uint64", "uint128", "array", "container",
+	"end_marker", "boolean", "float",
+}
+
+// String returns the name of the type as used in the specification.
+func (t dataType) String() string {
+	if t < 0 || int(t) >= len(typeNames) {
+		return fmt.Sprintf("type(%d)", int(t))
+	}
+
+	return typeNames[t]
+}
+
+// maxDepth is the maximum nesting of maps, arrays and pointers, which
+// guards against reference cycles in corrupt databases.
+const maxDepth = 512
+
+var uint128Type = reflect.TypeOf(uint128.Int{})
+
+// A decoder decodes values of the data section.
+type decoder struct {
+	buf []byte
+}
+
+// errData returns an error for an invalid data section.
+func errData(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidDatabase}, args...)...)
+}
+
+// ctrl decodes the control byte, and any extended type and size bytes
+// following it, at offset. For pointers, the returned size is the size
+// bits of the control byte, which are part of the pointer value.
+func (d *decoder) ctrl(offset uint) (dataType, uint, uint, error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, 0, 0, errData("offset %d out of range", offset)
+	}
+
+	b := d.buf[offset]
+	offset++
+
+	typ := dataType(b >> 5)
+	if typ == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return 0, 0, 0, errData("unexpected end of data")
+		}
+		typ = dataType(d.buf[offset]) + 7
+		offset++
+		if typ < typeInt32 || typ > typeFloat {
+			return 0, 0, 0, errData("invalid extended type %d", int(typ))
+		}
+	}
+
+	size := uint(b & 0x1f)
+	if typ == typePointer || size < 29 {
+		return typ, size, offset, nil
+	}
+
+	n := size - 28
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, 0, errData("unexpected end of data")
+	}
+	v := d.uint(offset, n)
+	offset += n
+
+	switch size {
+	case 29:
+		size = 29 + uint(v)
+	case 30:
+		size = 285 + uint(v)
+	default:
+		size = 65821 + uint(v)
+	}
+
+	return typ, size, offset, nil
+}
+
+// uint returns the big-endian unsigned integer in the n bytes at offset.
+func (d *decoder) uint(offset, n uint) uint64 {
+	var v uint64
+	for _, b := range d.buf[offset : offset+n] {
+		v = v<<8 | uint64(b)
+	}
+
+	return v
+}
+
+// pointer decodes the value of a pointer whose control byte has the
+// given size bits, returning the offset it points to and the offset of
+// the next value.
+func (d *decoder) pointer(size, offset uint) (uint, uint, error) {
+	n := (size>>3)&3 + 1
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, errData("unexpected end of data")
+	}
+
+	v := uint(d.uint(offset, n))
+	switch n {
+	case 1:
+		v |= (size & 7) << 8
+	case 2:
+		v = ((size&7)<<16 | v) + 2048
+	case 3:
+		v = ((size&7)<<24 | v) + 526336
+	}
+
+	return v, offset + n, nil
+}
+
+// decode decodes the value at offset into rv, and returns the offset of
+// the next value. If rv is the zero Value, the value is skipped.
+func (d *decoder) decode(offset uint, rv reflect.Value, depth int) (uint, error) {
+	if depth > maxDepth {
+		return 0, errData("maximum depth exceeded")
+	}
+
+	typ, size, offset, err := d.ctrl(offset)
+	if err != nil {
+		return 0, err
+	}
+
+	if typ == typePointer {
+		ptr, next, err := d.pointer(size, offset)
+		if err != nil {
+			return 0, err
+		}
+
+		// A pointer must not point to another pointer.
+		typ, size, offset, err := d.ctrl(ptr)
+		if err != nil {
+			return 0, err
+		}
+		if typ == typePointer {
+			return 0, errData("pointer to pointer at offset %d", ptr)
+		}
+		if _, err := d.decodeValue(typ, size, offset, rv, depth+1); err != nil {
+			return 0, err
+		}
+
+		return next, nil
+	}
+
+	return d.decodeValue(typ, size, offset, rv, depth)
+}
+
+// naturalType returns the Go type that a value of type typ is decoded
+// to when the destination is an empty interface.
+func naturalType(typ dataType) reflect.Type {
+	switch typ {
+	case typeString:
+		return reflect.TypeOf("")
+	case typeDouble:
+		return reflect.TypeOf(float64(0))
+	case typeFloat:
+		return reflect.TypeOf(float32(0))
+	case typeBytes:
+		return reflect.TypeOf([]byte(nil))
+	case typeUint16, typeUint32, typeUint64:
+		return reflect.TypeOf(uint64(0))
+	case typeUint128:
+		return uint128Type
+	case typeInt32:
+		return reflect.TypeOf(0)
+	case typeBool:
+		return reflect.TypeOf(false)
+	case typeMap:
+		return reflect.TypeOf(map[string]interface{}(nil))
+	case typeArray:
+		return reflect.TypeOf([]interface{}(nil))
+	default:
+		return nil
+	}
+}
+
+// decodeValue decodes the value of type typ and size at offset into rv,
+// and returns the offset of the next value.
+func (d *decoder) decodeValue(typ dataType, size, offset uint, rv reflect.Value, depth int) (uint, error) {
+	// Allocate pointers and resolve empty interfaces, so that rv is a
+	// concrete settable value.
+	for rv.IsValid() {
+		if rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+			continue
+		}
+
+		if rv.Kind() == reflect.Interface && rv.NumMethod() == 0 {
+			t := naturalType(typ)
+			if t == nil {
+				return 0, errData("unexpected %s", typ)
+			}
+
+			v := reflect.New(t).Elem()
+			next, err := d.decodeValue(typ, size, offset, v, depth)
+			if err != nil {
+				return 0, err
+			}
+			rv.Set(v)
+
+			return next, nil
+		}
+
+		break
+	}
+
+	switch typ {
+	case typeMap:
+		return d.decodeMap(size, offset, rv, depth)
+	case typeArray:
+		return d.decodeArray(size, offset, rv, depth)
+	case typeBool:
+		// The value of a boolean is its size, so it has no payload.
+		if size > 1 {
+			return 0, errData("invalid size %d for %s", size, typ)
+		}
+		if !rv.IsValid() {
+			return offset, nil
+		}
+		if rv.Kind() == reflect.Bool {
+			rv.SetBool(size == 1)
+			return offset, nil
+		}
+		return 0, fmt.Errorf("mmdb: cannot decode %s into %s", typ, rv.Type())
+	}
+
+	if offset+size > uint(len(d.buf)) {
+		return 0, errData("unexpected end of data")
+	}
+	next := offset + size
+	if !rv.IsValid() {
+		return next, nil
+	}
+
+	switch typ {
+	case typeString:
+		if rv.Kind() == reflect.String {
+			rv.SetString(string(d.buf[offset:next]))
+			return next, nil
+		}
+	case typeBytes:
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			rv.SetBytes(append([]byte(nil), d.buf[offset:next]...))
+			return next, nil
+		}
+	case typeDouble, typeFloat:
+		var f float64
+		switch {
+		case typ == typeDouble && size == 8:
+			f = math.Float64frombits(d.uint(offset, 8))
+		case typ == typeFloat && size == 4:
+			f = float64(math.Float32frombits(uint32(d.uint(offset, 4))))
+		default:
+			return 0, errData("invalid size %d for %s", size, typ)
+		}
+		if rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64 {
+			rv.SetFloat(f)
+			return next, nil
+		}
+	case typeUint16, typeUint32, typeUint64:
+		max := map[dataType]uint{typeUint16: 2, typeUint32: 4, typeUint64: 8}[typ]
+		if size > max {
+			return 0, errData("invalid size %d for %s", size, typ)
+		}
+		if setUint(rv, d.uint(offset, size)) {
+			return next, nil
+		}
+	case typeUint128:
+		if size > 16 {
+			return 0, errData("invalid size %d for %s", size, typ)
+		}
+		b := make([]byte, 16)
+		copy(b[16-size:], d.buf[offset:next])
+		x, _ := uint128.NewFromBytes(b)
+		if rv.Type() == uint128Type {
+			rv.Set(reflect.ValueOf(x))
+			return next, nil
+		}
+		if x.IsUint64() && setUint(rv, x.Uint64()) {
+			return next, nil
+		}
+	case typeInt32:
+		if size > 4 {
+			return 0, errData("invalid size %d for %s", size, typ)
+		}
+		// Leading zero bytes are omitted, so negative values always have
+		// all 4 bytes.
+		if setInt(rv, int64(int32(uint32(d.uint(offset, size))))) {
+			return next, nil
+		}
+	default:
+		return 0, errData("unexpected %s", typ)
+	}
+
+	return 0, fmt.Errorf("mmdb: cannot decode %s into %s", typ, rv.Type())
+}
+
+// setUint sets rv to the unsigned integer v, and reports whether rv is
+// of an integer kind that can hold v.
+func setUint(rv reflect.Value, v uint64) bool {
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.OverflowUint(v) {
+			return false
+		}
+		rv.SetUint(v)
+		return true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v > math.MaxInt64 || rv.OverflowInt(int64(v)) {
+			return false
+		}
+		rv.SetInt(int64(v))
+		return true
+	default:
+		return false
+	}
+}
+
+// setInt sets rv to the signed integer v, and reports whether rv is of
+// an integer kind that can hold v.
+func setInt(rv reflect.Value, v int64) bool {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.OverflowInt(v) {
+			return false
+		}
+		rv.SetInt(v)
+		return true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v < 0 || rv.OverflowUint(uint64(v)) {
+			return false
+		}
+		rv.SetUint(uint64(v))
+		return true
+	default:
+		return false
+	}
+}
+
+// checkSize returns an error if size values at offset cannot fit in the
+// rest of the data, as each value takes at least one byte. This bounds
+// the allocations for maps and arrays, whose sizes come from the data.
+func (d *decoder) checkSize(size, offset uint) error {
+	if offset > uint(len(d.buf)) || size > uint(len(d.buf))-offset {
+		return errData("%d values at offset %d out of range", size, offset)
+	}
+
+	return nil
+}
+
+// decodeMap decodes a map of size pairs at offset into rv, which must be
+// a map with string keys or a struct.
+func (d *decoder) decodeMap(size, offset uint, rv reflect.Value, depth int) (uint, error) {
+	if err := d.checkSize(2*size, offset); err != nil {
+		return 0, err
+	}
+
+	var fields map[string]int
+	switch {
+	case !rv.IsValid():
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMapWithSize(rv.Type(), int(size)))
+		}
+	case rv.Kind() == reflect.Struct:
+		fields = structFields(rv.Type())
+	default:
+		return 0, fmt.Errorf("mmdb: cannot decode %s into %s", typeMap, rv.Type())
+	}
+
+	for i := uint(0); i < size; i++ {
+		var key string
+		next, err := d.decode(offset, reflect.ValueOf(&key).Elem(), depth+1)
+		if err != nil {
+			return 0, err
+		}
+		offset = next
+
+		var elem reflect.Value
+		switch {
+		case !rv.IsValid():
+		case rv.Kind() == reflect.Map:
+			elem = reflect.New(rv.Type().Elem()).Elem()
+		default:
+			if j, ok := fields[key]; ok {
+				elem = rv.Field(j)
+			}
+		}
+
+		if offset, err = d.decode(offset, elem, depth+1); err != nil {
+			return 0, err
+		}
+
+		if rv.IsValid() && rv.Kind() == reflect.Map {
+			rv.SetMapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()), elem)
+		}
+	}
+
+	return offset, nil
+}
+
+// decodeArray decodes an array of size elements at offset into rv,
+// which must be a slice or an array.
+func (d *decoder) decodeArray(size, offset uint, rv reflect.Value, depth int) (uint, error) {
+	if err := d.checkSize(size, offset); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case !rv.IsValid():
+	case rv.Kind() == reflect.Slice:
+		rv.Set(reflect.MakeSlice(rv.Type(), int(size), int(size)))
+	case rv.Kind() == reflect.Array:
+		if uint(rv.Len()) < size {
+			return 0, fmt.Errorf("mmdb: cannot decode %s of %d elements into %s", typeArray, size, rv.Type())
+		}
+	default:
+		return 0, fmt.Errorf("mmdb: cannot decode %s into %s", typeArray, rv.Type())
+	}
+
+	for i := uint(0); i < size; i++ {
+		var elem reflect.Value
+		if rv.IsValid() {
+			elem = rv.Index(int(i))
+		}
+
+		var err error
+		if offset, err = d.decode(offset, elem, depth+1); err != nil {
+			return 0, err
+		}
+	}
+
+	return offset, nil
+}
+
+var fieldCache sync.Map // map[reflect.Type]map[string]int
+
+// structFields returns the indexes of the exported fields of struct
+// type t by their keys in the data section. The key of a field is given
+// by its "mmdb" tag, or is the field name if it has none. Fields tagged
+// with "-" are ignored.
+func structFields(t reflect.Type) map[string]int {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.(map[string]int)
+	}
+
+	fields := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		key := f.Name
+		if tag, ok := f.Tag.Lookup("mmdb"); ok {
+			if tag = strings.Split(tag, ",")[0]; tag == "-" {
+				continue
+			} else if tag != "" {
+				key = tag
+			}
+		}
+
+		fields[key] = i
+	}
+
+	fieldCache.Store(t, fields)
+	return fields
+}
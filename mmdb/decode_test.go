@@ -0,0 +1,121 @@
+package mmdb
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/ericyan/iputil/uint128"
+)
+
+func TestDecodeTypes(t *testing.T) {
+	cases := []interface{}{
+		"",
+		"hello, 世界",
+		string(bytes.Repeat([]byte("x"), 300)),
+		[]byte{0, 1, 2},
+		42.5,
+		float32(1.5),
+		uint64(0),
+		uint64(math.MaxUint64),
+		uint128.Max,
+		0,
+		-1,
+		math.MinInt32,
+		math.MaxInt32,
+		true,
+		false,
+		[]interface{}{},
+		map[string]interface{}{"a": []interface{}{"b", map[string]interface{}{"c": false}}},
+	}
+
+	for _, c := range cases {
+		d := decoder{testEncode(nil, c)}
+
+		var v interface{}
+		next, err := d.decode(0, reflect.ValueOf(&v).Elem(), 0)
+		if err != nil {
+			t.Errorf("unexpected error for %v: %v", c, err)
+			continue
+		}
+		if !reflect.DeepEqual(v, c) {
+			t.Errorf("unexpected value: got %#v, want %#v", v, c)
+		}
+		if next != uint(len(d.buf)) {
+			t.Errorf("unexpected next offset for %v: got %d, want %d", c, next, len(d.buf))
+		}
+
+		// Skipping a value must consume the same number of bytes.
+		if next, err := d.decode(0, reflect.Value{}, 0); err != nil || next != uint(len(d.buf)) {
+			t.Errorf("unexpected skip for %v: got %d, %v", c, next, err)
+		}
+	}
+}
+
+func TestDecodePointer(t *testing.T) {
+	// A map whose value is a pointer to the string at offset 0, followed
+	// by pointers of each size.
+	buf := testEncode(nil, "shared")
+	start := uint(len(buf))
+	buf = append(buf, byte(typeMap)<<5|1)
+	buf = testEncode(buf, "key")
+	buf = append(buf, byte(typePointer)<<5, 0)
+
+	d := decoder{buf}
+	var v interface{}
+	if _, err := d.decode(start, reflect.ValueOf(&v).Elem(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, map[string]interface{}{"key": "shared"}) {
+		t.Errorf("unexpected value: %#v", v)
+	}
+
+	cases := []struct {
+		ptr []byte
+		out uint
+	}{
+		{[]byte{0x20 | 0x05, 0x01}, 0x501},
+		{[]byte{0x28 | 0x01, 0x00, 0x00}, 0x10000 + 2048},
+		{[]byte{0x30 | 0x01, 0x00, 0x00, 0x00}, 0x1000000 + 526336},
+		{[]byte{0x38, 0x01, 0x02, 0x03, 0x04}, 0x01020304},
+	}
+
+	for _, c := range cases {
+		d := decoder{c.ptr}
+		_, size, offset, err := d.ctrl(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ptr, next, err := d.pointer(size, offset)
+		if err != nil || ptr != c.out || next != uint(len(c.ptr)) {
+			t.Errorf("unexpected pointer for %x: got %#x, %d, %v", c.ptr, ptr, next, err)
+		}
+	}
+
+	// A pointer to a pointer is invalid.
+	d = decoder{[]byte{byte(typePointer) << 5, 0}}
+	if _, err := d.decode(0, reflect.ValueOf(&v).Elem(), 0); !errors.Is(err, ErrInvalidDatabase) {
+		t.Errorf("unexpected error for pointer to pointer: %v", err)
+	}
+}
+
+func TestDecodeSizeOutOfRange(t *testing.T) {
+	cases := [][]byte{
+		// An array and a map of about 16M entries, without any.
+		{0x1f, byte(typeArray - 7), 0xff, 0xff, 0xff},
+		{byte(typeMap)<<5 | 0x1f, 0xff, 0xff, 0xff},
+
+		// A map of two pairs, truncated after the first.
+		append([]byte{byte(typeMap)<<5 | 2}, testEncode(testEncode(nil, "a"), "b")...),
+	}
+
+	for _, c := range cases {
+		d := decoder{c}
+		var v interface{}
+		if _, err := d.decode(0, reflect.ValueOf(&v).Elem(), 0); !errors.Is(err, ErrInvalidDatabase) {
+			t.Errorf("unexpected error for %x: %v", c, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package mmdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/ericyan/iputil/uint128"
+)
+
+// appendCtrl appends the control byte for a value of type typ and size,
+// followed by any extended type and size bytes.
+func appendCtrl(b []byte, typ dataType, size int) []byte {
+	var ext []byte
+	if typ > typeMap {
+		ext = []byte{byte(typ - 7)}
+		typ = typeExtended
+	}
+
+	switch {
+	case size < 29:
+		b = append(b, byte(typ)<<5|byte(size))
+		return append(b, ext...)
+	case size < 285:
+		b = append(append(b, byte(typ)<<5|29), ext...)
+		return append(b, byte(size-29))
+	case size < 65821:
+		b = append(append(b, byte(typ)<<5|30), ext...)
+		return append(b, byte((size-285)>>8), byte(size-285))
+	default:
+		b = append(append(b, byte(typ)<<5|31), ext...)
+		return append(b, byte((size-65821)>>16), byte((size-65821)>>8), byte(size-65821))
+	}
+}
+
+// appendUint appends the unsigned integer v as a value of type typ,
+// omitting leading zero bytes.
+func appendUint(b []byte, typ dataType, v uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+
+	i := 0
+	for i < len(buf) && buf[i] == 0 {
+		i++
+	}
+
+	return append(appendCtrl(b, typ, len(buf)-i), buf[i:]...)
+}
+
+// appendValue appends the encoding of rv to b. Go types are encoded as
+// follows:
+//
+//   - strings as utf8_string, and byte slices as bytes
+//   - float64 as double, and float32 as float
+//   - uint8 and uint16 as uint16, uint32 as uint32, and uint and uint64
+//     as uint64
+//   - uint128.Int as uint128
+//   - signed integers as int32, if they are in its range
+//   - bools as boolean
+//   - maps with string keys and structs as map
+//   - other slices and arrays as array
+//
+// Struct fields are keyed as for decoding. Nil pointers and interfaces
+// in maps and structs are omitted, as the format has no null value.
+func appendValue(b []byte, rv reflect.Value, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, errors.New("mmdb: maximum depth exceeded")
+	}
+
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			break
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		return nil, errors.New("mmdb: cannot encode nil value")
+	}
+
+	if rv.Type() == uint128Type {
+		x := rv.Interface().(uint128.Int)
+		buf := x.Bytes()
+		i := 0
+		for i < len(buf) && buf[i] == 0 {
+			i++
+		}
+		return append(appendCtrl(b, typeUint128, len(buf)-i), buf[i:]...), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return append(appendCtrl(b, typeString, rv.Len()), rv.String()...), nil
+	case reflect.Float64:
+		b = appendCtrl(b, typeDouble, 8)
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(rv.Float())), nil
+	case reflect.Float32:
+		b = appendCtrl(b, typeFloat, 4)
+		return binary.BigEndian.AppendUint32(b, math.Float32bits(float32(rv.Float()))), nil
+	case reflect.Uint8, reflect.Uint16:
+		return appendUint(b, typeUint16, rv.Uint()), nil
+	case reflect.Uint32:
+		return appendUint(b, typeUint32, rv.Uint()), nil
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return appendUint(b, typeUint64, rv.Uint()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v := rv.Int()
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, fmt.Errorf("mmdb: integer %d out of range for %s", v, typeInt32)
+		}
+		return appendUint(b, typeInt32, uint64(uint32(v))), nil
+	case reflect.Bool:
+		if rv.Bool() {
+			return appendCtrl(b, typeBool, 1), nil
+		}
+		return appendCtrl(b, typeBool, 0), nil
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b = appendCtrl(b, typeBytes, rv.Len())
+			for i := 0; i < rv.Len(); i++ {
+				b = append(b, byte(rv.Index(i).Uint()))
+			}
+			return b, nil
+		}
+
+		b = appendCtrl(b, typeArray, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			var err error
+			if b, err = appendValue(b, rv.Index(i), depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+
+		values := make(map[string]reflect.Value, rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			values[iter.Key().String()] = iter.Value()
+		}
+		return appendMap(b, values, depth)
+	case reflect.Struct:
+		fields := structFields(rv.Type())
+		values := make(map[string]reflect.Value, len(fields))
+		for key, i := range fields {
+			values[key] = rv.Field(i)
+		}
+		return appendMap(b, values, depth)
+	}
+
+	return nil, fmt.Errorf("mmdb: cannot encode %s", rv.Type())
+}
+
+// appendMap appends a map of values, in the order of their keys so that
+// the encoding is deterministic.
+func appendMap(b []byte, values map[string]reflect.Value, depth int) ([]byte, error) {
+	keys := make([]string, 0, len(values))
+	for key, v := range values {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	b = appendCtrl(b, typeMap, len(keys))
+	for _, key := range keys {
+		b = append(appendCtrl(b, typeString, len(key)), key...)
+
+		var err error
+		if b, err = appendValue(b, values[key], depth+1); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
@@ -0,0 +1,108 @@
+package mmdb
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ericyan/iputil/uint128"
+)
+
+func TestAppendValue(t *testing.T) {
+	cases := []struct {
+		in  interface{}
+		out string
+	}{
+		{"", "40"},
+		{"a", "4161"},
+		{strings.Repeat("a", 29), "5d00" + strings.Repeat("61", 29)},
+		{uint16(0), "a0"},
+		{uint8(255), "a1ff"},
+		{uint32(500), "c201f4"},
+		{uint64(1) << 32, "05020100000000"},
+		{uint(1), "010201"},
+		{uint128.Int{Hi: 1}, "0903010000000000000000"},
+		{-1, "0401ffffffff"},
+		{int8(1), "010101"},
+		{true, "0107"},
+		{false, "0007"},
+		{1.0, "683ff0000000000000"},
+		{float32(1.0), "04083f800000"},
+		{[]byte{1, 2}, "820102"},
+		{[2]uint8{1, 2}, "820102"},
+		{[]string{"a"}, "01044161"},
+		{map[string]bool{"b": true, "a": false}, "e24161000741620107"},
+		{struct {
+			B string `mmdb:"b"`
+			A *int   `mmdb:"a"`
+			C int    `mmdb:"-"`
+		}{B: "x"}, "e141624178"},
+	}
+
+	for _, c := range cases {
+		b, err := appendValue(nil, reflect.ValueOf(c.in), 0)
+		if err != nil {
+			t.Errorf("unexpected error for %#v: %v", c.in, err)
+			continue
+		}
+		if out := hex.EncodeToString(b); out != c.out {
+			t.Errorf("unexpected encoding of %#v: got %s, want %s", c.in, out, c.out)
+		}
+	}
+}
+
+func TestAppendCtrl(t *testing.T) {
+	cases := []struct {
+		typ  dataType
+		size int
+		out  string
+	}{
+		{typeString, 28, "5c"},
+		{typeString, 29, "5d00"},
+		{typeString, 284, "5dff"},
+		{typeString, 285, "5e0000"},
+		{typeString, 65820, "5effff"},
+		{typeString, 65821, "5f000000"},
+		{typeArray, 300, "1e04000f"},
+		{typeInt32, 4, "0401"},
+	}
+
+	for _, c := range cases {
+		b := appendCtrl(nil, c.typ, c.size)
+		if out := hex.EncodeToString(b); out != c.out {
+			t.Errorf("unexpected control bytes for %s of size %d: got %s, want %s", c.typ, c.size, out, c.out)
+		}
+
+		d := decoder{append(b, make([]byte, c.size)...)}
+		typ, size, _, err := d.ctrl(0)
+		if err != nil || typ != c.typ || size != uint(c.size) {
+			t.Errorf("unexpected round trip for %s of size %d: got %s, %d, %v", c.typ, c.size, typ, size, err)
+		}
+	}
+}
+
+func TestAppendValueErrors(t *testing.T) {
+	cases := []interface{}{
+		nil,
+		(*int)(nil),
+		make(chan int),
+		map[int]string{},
+		int64(math.MaxInt32) + 1,
+		[]interface{}{nil},
+	}
+
+	for _, c := range cases {
+		if _, err := appendValue(nil, reflect.ValueOf(c), 0); err == nil {
+			t.Errorf("error expected for %#v", c)
+		}
+	}
+
+	// Nil values in maps are omitted.
+	b, err := appendValue(nil, reflect.ValueOf(map[string]interface{}{"a": nil}), 0)
+	if err != nil || !bytes.Equal(b, []byte{0xe0}) {
+		t.Errorf("unexpected encoding: got %x, %v", b, err)
+	}
+}
@@ -0,0 +1,195 @@
+// Package mmdb implements the MaxMind DB file format, a binary search
+// tree of IP address prefixes mapped to structured records, as used by
+// GeoIP2 and GeoLite2 databases.
+//
+// The format is described at https://maxmind.github.io/MaxMind-DB/.
+package mmdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+
+	"github.com/ericyan/iputil"
+)
+
+// ErrInvalidDatabase is returned, possibly wrapped, when a database is
+// corrupt or not in the MaxMind DB format.
+var ErrInvalidDatabase = errors.New("mmdb: invalid database")
+
+// metadataStart is the marker preceding the metadata section.
+var metadataStart = []byte("\xab\xcd\xefMaxMind.com")
+
+// dataSectionSeparator is the length of the zero bytes between the
+// search tree and the data section.
+const dataSectionSeparator = 16
+
+// Metadata describes a database.
+type Metadata struct {
+	BinaryFormatMajorVersion uint              `mmdb:"binary_format_major_version"`
+	BinaryFormatMinorVersion uint              `mmdb:"binary_format_minor_version"`
+	BuildEpoch               uint64            `mmdb:"build_epoch"`
+	DatabaseType             string            `mmdb:"database_type"`
+	Description              map[string]string `mmdb:"description"`
+	IPVersion                uint              `mmdb:"ip_version"`
+	Languages                []string          `mmdb:"languages"`
+	NodeCount                uint              `mmdb:"node_count"`
+	RecordSize               uint              `mmdb:"record_size"`
+}
+
+// A Reader looks up records in a database. It is safe for concurrent
+// use.
+type Reader struct {
+	Metadata Metadata
+
+	tree []byte
+	data decoder
+
+	// ipv4Start is the node reached by the 96 zero bits of ::/96, where
+	// IPv4 lookups start in an IPv6 database, and ipv4Depth is the
+	// number of bits actually followed to reach it.
+	ipv4Start uint
+	ipv4Depth int
+}
+
+// Open reads the database in the named file.
+func Open(name string) (*Reader, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromBytes(b)
+}
+
+// FromBytes returns a Reader for the database in b. The Reader refers
+// to b, which must not be modified afterwards.
+func FromBytes(b []byte) (*Reader, error) {
+	i := bytes.LastIndex(b, metadataStart)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: metadata not found", ErrInvalidDatabase)
+	}
+
+	r := new(Reader)
+	meta := decoder{b[i+len(metadataStart):]}
+	if _, err := meta.decode(0, reflect.ValueOf(&r.Metadata).Elem(), 0); err != nil {
+		return nil, err
+	}
+
+	m := &r.Metadata
+	if m.BinaryFormatMajorVersion != 2 {
+		return nil, fmt.Errorf("%w: unsupported format version %d", ErrInvalidDatabase, m.BinaryFormatMajorVersion)
+	}
+	if m.RecordSize != 24 && m.RecordSize != 28 && m.RecordSize != 32 {
+		return nil, fmt.Errorf("%w: unsupported record size %d", ErrInvalidDatabase, m.RecordSize)
+	}
+	if m.IPVersion != 4 && m.IPVersion != 6 {
+		return nil, fmt.Errorf("%w: unsupported ip version %d", ErrInvalidDatabase, m.IPVersion)
+	}
+
+	treeSize := m.NodeCount * m.RecordSize / 4
+	if treeSize+dataSectionSeparator > uint(i) {
+		return nil, fmt.Errorf("%w: search tree out of range", ErrInvalidDatabase)
+	}
+	r.tree = b[:treeSize]
+	r.data = decoder{b[treeSize+dataSectionSeparator : i]}
+
+	if m.IPVersion == 6 {
+		for r.ipv4Depth < 96 && r.ipv4Start < m.NodeCount {
+			r.ipv4Start = r.record(r.ipv4Start, 0)
+			r.ipv4Depth++
+		}
+	}
+
+	return r, nil
+}
+
+// record returns the left (bit 0) or right (bit 1) record of node.
+func (r *Reader) record(node, bit uint) uint {
+	b := r.tree[node*r.Metadata.RecordSize/4:]
+
+	switch r.Metadata.RecordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		// The middle byte holds the high nibbles of both records.
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		b = b[bit*4:]
+		return uint(b[0])<<24 | uint(b[1])<<16 | uint(b[2])<<8 | uint(b[3])
+	}
+}
+
+// Lookup looks up the record for ip, and decodes it into v, which must
+// be a non-nil pointer. A record is decoded as by the encoding/json
+// package: maps into structs, using the "mmdb" tag of fields as keys, or
+// into maps with string keys, and arrays into slices. If v points to an
+// empty interface, maps are decoded into map[string]interface{}, arrays
+// into []interface{}, unsigned integers into uint64 or uint128.Int, and
+// signed integers into int.
+//
+// It returns the prefix of the tree entry that matched ip, and whether
+// a record was found. If no record was found, the prefix is that of the
+// empty tree entry, and v is left unchanged. In IPv6 databases, IPv4
+// addresses are looked up in the ::/96 subtree, and the prefix is
+// returned as an IPv4 prefix. If v is nil, the record is not decoded.
+func (r *Reader) Lookup(ip net.IP, v interface{}) (iputil.Prefix, bool, error) {
+	var rv reflect.Value
+	if v != nil {
+		rv = reflect.ValueOf(v)
+		if rv.Kind() != reflect.Ptr || rv.IsNil() {
+			return iputil.Prefix{}, false, errors.New("mmdb: lookup result must be a non-nil pointer")
+		}
+	}
+
+	addr := iputil.AddrFromIP(ip)
+	node, depth := uint(0), 0
+	switch {
+	case !addr.IsValid():
+		return iputil.Prefix{}, false, errors.New("mmdb: invalid address")
+	case addr.Family() == iputil.IPv6 && r.Metadata.IPVersion == 4:
+		return iputil.Prefix{}, false, errors.New("mmdb: cannot look up ipv6 address in ipv4 database")
+	case addr.Family() == iputil.IPv4 && r.Metadata.IPVersion == 6:
+		node, depth = r.ipv4Start, r.ipv4Depth
+	}
+
+	// The number of bits of the lookup address that were followed.
+	bits := 0
+	x, n := addr.Int(), addr.BitLen()
+	for ; bits < n && node < r.Metadata.NodeCount; bits++ {
+		node = r.record(node, uint(x.Rsh(uint(n-1-bits)).Lo&1))
+	}
+	if node < r.Metadata.NodeCount {
+		return iputil.Prefix{}, false, fmt.Errorf("%w: search tree too deep", ErrInvalidDatabase)
+	}
+
+	// An IPv4 address that ended in a record above ::/96 matched a prefix
+	// covering all IPv4 addresses.
+	if depth < 96 && addr.Family() == iputil.IPv4 && r.Metadata.IPVersion == 6 {
+		bits = 0
+	}
+
+	prefix, err := iputil.NewPrefix(addr, bits)
+	if err != nil {
+		return iputil.Prefix{}, false, err
+	}
+	if node == r.Metadata.NodeCount {
+		return prefix, false, nil
+	}
+
+	offset := node - r.Metadata.NodeCount - dataSectionSeparator
+	if rv.IsValid() {
+		if _, err := r.data.decode(offset, rv.Elem(), 0); err != nil {
+			return iputil.Prefix{}, false, err
+		}
+	}
+
+	return prefix, true, nil
+}
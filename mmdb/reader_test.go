@@ -0,0 +1,405 @@
+package mmdb
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ericyan/iputil"
+)
+
+// testEncode appends the encoding of v to b.
+func testEncode(b []byte, v interface{}) []byte {
+	b, err := appendValue(b, reflect.ValueOf(v), 0)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// testBuild returns a database of the given ip version and record size
+// containing records, keyed by prefix.
+func testBuild(ipVersion, recordSize uint, records map[string]interface{}) []byte {
+	w := &Writer{
+		IPVersion:    ipVersion,
+		RecordSize:   recordSize,
+		DatabaseType: "Test",
+		Description:  map[string]string{"en": "Test database"},
+		Languages:    []string{"en"},
+		BuildEpoch:   1700000000,
+	}
+
+	prefixes := make([]string, 0, len(records))
+	for s := range records {
+		prefixes = append(prefixes, s)
+	}
+	sort.Strings(prefixes)
+
+	for _, s := range prefixes {
+		p, err := iputil.ParsePrefix(s)
+		if err != nil {
+			panic(err)
+		}
+		if err := w.Insert(p, records[s]); err != nil {
+			panic(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		panic(err)
+	}
+
+	return buf.Bytes()
+}
+
+var testRecords = map[string]interface{}{
+	"1.0.0.0/24": map[string]interface{}{
+		"country": map[string]interface{}{"iso_code": "AU", "geoname_id": uint32(2077456)},
+		"asn":     uint32(13335),
+	},
+	"10.0.0.0/8": map[string]interface{}{
+		"site":    "hq",
+		"private": true,
+		"vlans":   []interface{}{uint16(10), uint16(20)},
+	},
+	"2001:db8::/32": map[string]interface{}{
+		"site":     "lab",
+		"location": map[string]interface{}{"latitude": 51.5, "longitude": -0.12},
+	},
+}
+
+func TestReaderMetadata(t *testing.T) {
+	r, err := FromBytes(testBuild(6, 24, testRecords))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := r.Metadata
+	if m.BinaryFormatMajorVersion != 2 || m.IPVersion != 6 || m.RecordSize != 24 || m.NodeCount == 0 {
+		t.Errorf("unexpected metadata: %+v", m)
+	}
+	if m.DatabaseType != "Test" || m.Description["en"] != "Test database" || m.BuildEpoch != 1700000000 {
+		t.Errorf("unexpected metadata: %+v", m)
+	}
+	if !reflect.DeepEqual(m.Languages, []string{"en"}) {
+		t.Errorf("unexpected languages: %v", m.Languages)
+	}
+}
+
+func TestReaderLookup(t *testing.T) {
+	cases := []struct {
+		ip     string
+		prefix string
+		found  bool
+		site   string
+	}{
+		{"10.1.2.3", "10.0.0.0/8", true, "hq"},
+		{"1.0.0.1", "1.0.0.0/24", true, ""},
+		{"2001:db8::1", "2001:db8::/32", true, "lab"},
+		{"2001:db9::1", "2001:db9::/32", false, ""},
+		{"192.168.1.1", "128.0.0.0/1", false, ""},
+		{"::ffff:10.0.0.1", "10.0.0.0/8", true, "hq"},
+	}
+
+	for _, size := range []uint{24, 28, 32} {
+		r, err := FromBytes(testBuild(6, size, testRecords))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, c := range cases {
+			var rec struct {
+				Site string `mmdb:"site"`
+			}
+
+			prefix, found, err := r.Lookup(net.ParseIP(c.ip), &rec)
+			if err != nil {
+				t.Errorf("unexpected error for %s: %v", c.ip, err)
+				continue
+			}
+			if prefix.String() != c.prefix || found != c.found || rec.Site != c.site {
+				t.Errorf("unexpected result for %s with %d-bit records: got %s, %t, %q", c.ip, size, prefix, found, rec.Site)
+			}
+		}
+	}
+}
+
+// TestReaderTestData looks up addresses in the databases written by
+// testdata/write-test-data.py without this package. They hold the
+// networks of the MaxMind-DB test data, each mapped to its address, so
+// the expected results follow from the networks alone.
+func TestReaderTestData(t *testing.T) {
+	ipv4 := []struct {
+		ip, want string
+	}{
+		{"1.1.1.1", "1.1.1.1"},
+		{"1.1.1.2", "1.1.1.2"},
+		{"1.1.1.3", "1.1.1.2"},
+		{"1.1.1.4", "1.1.1.4"},
+		{"1.1.1.5", "1.1.1.4"},
+		{"1.1.1.7", "1.1.1.4"},
+		{"1.1.1.8", "1.1.1.8"},
+		{"1.1.1.9", "1.1.1.8"},
+		{"1.1.1.15", "1.1.1.8"},
+		{"1.1.1.16", "1.1.1.16"},
+		{"1.1.1.17", "1.1.1.16"},
+		{"1.1.1.31", "1.1.1.16"},
+		{"1.1.1.32", "1.1.1.32"},
+		{"1.1.1.33", ""},
+		{"255.254.253.123", ""},
+	}
+	ipv6 := []struct {
+		ip, want string
+	}{
+		{"::1:ffff:ffff", "::1:ffff:ffff"},
+		{"::2:0:0", "::2:0:0"},
+		{"::2:0:1", "::2:0:0"},
+		{"::2:0:33", "::2:0:0"},
+		{"::2:0:39", "::2:0:0"},
+		{"::2:0:40", "::2:0:40"},
+		{"::2:0:41", "::2:0:40"},
+		{"::2:0:49", "::2:0:40"},
+		{"::2:0:50", "::2:0:50"},
+		{"::2:0:52", "::2:0:50"},
+		{"::2:0:57", "::2:0:50"},
+		{"::2:0:58", "::2:0:58"},
+		{"::2:0:59", "::2:0:58"},
+		{"89fa::", ""},
+	}
+
+	type result struct {
+		ip, want string
+	}
+	var mixed []result
+	for _, c := range ipv4 {
+		want := c.want
+		if want != "" {
+			want = "::" + want
+		}
+		mixed = append(mixed, result{c.ip, want})
+	}
+	for _, c := range ipv6 {
+		mixed = append(mixed, result{c.ip, c.want})
+	}
+
+	// IPv4 addresses are found at their aliases too.
+	mixed = append(mixed,
+		result{"::ffff:1.1.1.3", "::1.1.1.2"},
+		result{"2002:101:103::", "::1.1.1.2"},
+		result{"2001:0:101:103::", "::1.1.1.2"},
+		result{"2002:101:121::", ""},
+	)
+
+	files := []struct {
+		name  string
+		cases []result
+	}{
+		{"testdata/iputil-test-ipv4-28.mmdb", nil},
+		{"testdata/iputil-test-mixed-24.mmdb", mixed},
+	}
+	for _, c := range ipv4 {
+		files[0].cases = append(files[0].cases, result{c.ip, c.want})
+	}
+
+	for _, f := range files {
+		r, err := Open(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, c := range f.cases {
+			var rec map[string]string
+			_, found, err := r.Lookup(net.ParseIP(c.ip), &rec)
+			if err != nil {
+				t.Errorf("unexpected error for %s in %s: %v", c.ip, f.name, err)
+				continue
+			}
+			if found != (c.want != "") || rec["ip"] != c.want {
+				t.Errorf("unexpected result for %s in %s: got %t, %q, want %q", c.ip, f.name, found, rec["ip"], c.want)
+			}
+		}
+	}
+
+	r, err := Open("testdata/iputil-test-mixed-24.mmdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefix, _, _ := r.Lookup(net.ParseIP("2002:101:103::"), nil); prefix.String() != "2002:101:102::/47" {
+		t.Errorf("unexpected prefix for 6to4 address: got %s, want 2002:101:102::/47", prefix)
+	}
+	if prefix, _, _ := r.Lookup(net.ParseIP("1.1.1.3"), nil); prefix.String() != "1.1.1.2/31" {
+		t.Errorf("unexpected prefix for IPv4 address: got %s, want 1.1.1.2/31", prefix)
+	}
+}
+
+// TestReaderRecord28 checks the layout of 28-bit records, whose middle
+// byte holds the high nibbles of both records, with values too large to
+// appear in the test databases.
+func TestReaderRecord28(t *testing.T) {
+	r := &Reader{
+		Metadata: Metadata{RecordSize: 28},
+		tree:     []byte{0x12, 0x34, 0x56, 0xab, 0x78, 0x9a, 0xbc},
+	}
+
+	if left := r.record(0, 0); left != 0xa123456 {
+		t.Errorf("unexpected left record: got %#x, want 0xa123456", left)
+	}
+	if right := r.record(0, 1); right != 0xb789abc {
+		t.Errorf("unexpected right record: got %#x, want 0xb789abc", right)
+	}
+}
+
+func TestReaderLookupIPv4(t *testing.T) {
+	records := map[string]interface{}{
+		"10.0.0.0/8": "hq",
+		"1.0.0.0/24": "au",
+	}
+
+	r, err := FromBytes(testBuild(4, 28, records))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s string
+	if prefix, found, err := r.Lookup(net.ParseIP("10.20.30.40"), &s); err != nil || !found || s != "hq" || prefix.String() != "10.0.0.0/8" {
+		t.Errorf("unexpected result: got %s, %t, %q, %v", prefix, found, s, err)
+	}
+	if _, _, err := r.Lookup(net.ParseIP("2001:db8::1"), &s); err == nil {
+		t.Error("error expected for ipv6 lookup in ipv4 database")
+	}
+}
+
+func TestReaderDecodeInterface(t *testing.T) {
+	r, err := FromBytes(testBuild(6, 24, testRecords))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v interface{}
+	if _, _, err := r.Lookup(net.ParseIP("10.0.0.1"), &v); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"site":    "hq",
+		"private": true,
+		"vlans":   []interface{}{uint64(10), uint64(20)},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("unexpected record: got %#v, want %#v", v, want)
+	}
+
+	if _, _, err := r.Lookup(net.ParseIP("1.0.0.1"), nil); err != nil {
+		t.Errorf("unexpected error for nil result: %v", err)
+	}
+	if _, _, err := r.Lookup(net.ParseIP("1.0.0.1"), v); err == nil {
+		t.Error("error expected for non-pointer result")
+	}
+	if _, _, err := r.Lookup(nil, &v); err == nil {
+		t.Error("error expected for nil address")
+	}
+}
+
+func TestReaderDecodeStruct(t *testing.T) {
+	type country struct {
+		ISOCode   string `mmdb:"iso_code"`
+		GeonameID uint   `mmdb:"geoname_id"`
+	}
+	type record struct {
+		Country  *country `mmdb:"country"`
+		ASN      int      `mmdb:"asn"`
+		Ignored  string   `mmdb:"-"`
+		Vlans    []uint16 `mmdb:"vlans"`
+		Private  bool     `mmdb:"private"`
+		Location struct {
+			Latitude  float64 `mmdb:"latitude"`
+			Longitude float32 `mmdb:"longitude"`
+		} `mmdb:"location"`
+		Site string
+	}
+
+	r, err := FromBytes(testBuild(6, 24, testRecords))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rec record
+	if _, _, err := r.Lookup(net.ParseIP("1.0.0.1"), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Country == nil || rec.Country.ISOCode != "AU" || rec.Country.GeonameID != 2077456 || rec.ASN != 13335 {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+
+	rec = record{}
+	if _, _, err := r.Lookup(net.ParseIP("10.0.0.1"), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Private || !reflect.DeepEqual(rec.Vlans, []uint16{10, 20}) || rec.Site != "" {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+
+	rec = record{}
+	if _, _, err := r.Lookup(net.ParseIP("2001:db8::1"), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Location.Latitude != 51.5 || rec.Location.Longitude != -0.12 {
+		t.Errorf("unexpected location: %+v", rec.Location)
+	}
+
+	var mismatch struct {
+		ASN string `mmdb:"asn"`
+	}
+	if _, _, err := r.Lookup(net.ParseIP("1.0.0.1"), &mismatch); err == nil {
+		t.Error("error expected for type mismatch")
+	}
+}
+
+func TestFromBytesErrors(t *testing.T) {
+	valid := testBuild(6, 24, testRecords)
+
+	cases := [][]byte{
+		nil,
+		valid[:100],
+		append(append([]byte{}, metadataStart...), testEncode(nil, map[string]interface{}{
+			"binary_format_major_version": uint16(1),
+		})...),
+		append(append([]byte{}, metadataStart...), testEncode(nil, map[string]interface{}{
+			"binary_format_major_version": uint16(2),
+			"ip_version":                  uint16(6),
+			"record_size":                 uint16(24),
+			"node_count":                  uint32(1000),
+		})...),
+	}
+
+	for i, b := range cases {
+		if _, err := FromBytes(b); !errors.Is(err, ErrInvalidDatabase) {
+			t.Errorf("unexpected error for case %d: %v", i, err)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(name, testBuild(6, 32, testRecords), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found, err := r.Lookup(net.ParseIP("10.0.0.1"), nil); err != nil || !found {
+		t.Errorf("unexpected result: %t, %v", found, err)
+	}
+
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.mmdb")); err == nil {
+		t.Error("error expected for missing file")
+	}
+}
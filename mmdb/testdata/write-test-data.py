@@ -0,0 +1,213 @@
+#!/usr/bin/env python3
+"""Writes the MaxMind DB test databases in this directory.
+
+The databases hold the networks of the test data of the MaxMind-DB
+project (https://github.com/maxmind/MaxMind-DB): each network maps to
+{"ip": the network address}. They are not the files of that project, but
+are written here from the format specification alone, without the
+encoder of this package, so that they check the reader independently of
+the writer.
+
+    iputil-test-ipv4-28.mmdb   IPv4 database with 28-bit records
+    iputil-test-mixed-24.mmdb  IPv6 database with 24-bit records, with
+                               IPv4 networks in ::/96, aliased at
+                               ::ffff:0:0/96, 2001::/32 and 2002::/16
+
+Map keys and values are written inline or as pointers of size 0, 1 and 3,
+placed before and after a padding string of more than 2048 bytes.
+"""
+
+import ipaddress
+import struct
+
+IPV4_NETWORKS = [
+    "1.1.1.1/32",
+    "1.1.1.2/31",
+    "1.1.1.4/30",
+    "1.1.1.8/29",
+    "1.1.1.16/28",
+    "1.1.1.32/32",
+]
+
+IPV6_NETWORKS = [
+    "::1:ffff:ffff/128",
+    "::2:0:0/122",
+    "::2:0:40/124",
+    "::2:0:50/125",
+    "::2:0:58/127",
+]
+
+ALIASES = ["::ffff:0:0/96", "2001::/32", "2002::/16"]
+
+# Data types.
+POINTER, UTF8, MAP, UINT16, UINT32, UINT64, ARRAY = 1, 2, 7, 5, 6, 9, 11
+
+
+def ctrl(typ, size):
+    """Returns the control bytes of a value of type typ and size."""
+    if size < 29:
+        b, extra = size, b""
+    elif size < 29 + 256:
+        b, extra = 29, bytes([size - 29])
+    elif size < 285 + 65536:
+        b, extra = 30, struct.pack(">H", size - 285)
+    else:
+        b, extra = 31, struct.pack(">I", size - 65821)[1:]
+
+    if typ <= 7:
+        return bytes([typ << 5 | b]) + extra
+    return bytes([b, typ - 7]) + extra
+
+
+def utf8(s):
+    return ctrl(UTF8, len(s)) + s.encode()
+
+
+def uint(typ, v):
+    b = v.to_bytes(8, "big").lstrip(b"\0")
+    return ctrl(typ, len(b)) + b
+
+
+def pointer(size, offset):
+    """Returns a pointer of the given size class to offset."""
+    if size == 0:
+        assert offset < 2048
+        return bytes([0x20 | offset >> 8, offset & 0xFF])
+    if size == 1:
+        v = offset - 2048
+        assert 0 <= v < 1 << 19
+        return bytes([0x28 | v >> 16]) + struct.pack(">H", v & 0xFFFF)
+    assert size == 3
+    return bytes([0x38]) + struct.pack(">I", offset)
+
+
+class Node:
+    def __init__(self):
+        self.children = [None, None]
+
+
+def insert(root, network, value):
+    """Inserts a network with a data section offset into the tree."""
+    bits = network.network_address.packed
+    node = root
+    for i in range(network.prefixlen):
+        bit = bits[i // 8] >> (7 - i % 8) & 1
+        if i == network.prefixlen - 1:
+            node.children[bit] = value
+        else:
+            if not isinstance(node.children[bit], Node):
+                node.children[bit] = Node()
+            node = node.children[bit]
+
+
+def alias(root, network, target):
+    """Makes network lead to the node target."""
+    bits = network.network_address.packed
+    node = root
+    for i in range(network.prefixlen):
+        bit = bits[i // 8] >> (7 - i % 8) & 1
+        if i == network.prefixlen - 1:
+            node.children[bit] = target
+        else:
+            if node.children[bit] is None:
+                node.children[bit] = Node()
+            node = node.children[bit]
+
+
+def write(name, ip_version, record_size, networks):
+    # The data section: the "ip" key, a padding string which is never
+    # referred to, the network addresses, and then the records, each a
+    # map of one entry.
+    data = bytearray()
+    key_offset = len(data)
+    data += utf8("ip")
+    data += utf8("padding" * 300)
+    assert len(data) > 2048
+
+    addrs = {}
+    for net in networks:
+        addrs[net] = len(data)
+        data += utf8(net.split("/")[0])
+
+    records = {}
+    for i, net in enumerate(networks):
+        records[net] = len(data)
+        data += ctrl(MAP, 1)
+        data += [utf8("ip"), pointer(0, key_offset), pointer(3, key_offset)][i % 3]
+        if i % 2 == 0:
+            data += utf8(net.split("/")[0])
+        else:
+            data += pointer(1, addrs[net])
+
+    root = Node()
+    for net in networks:
+        insert(root, ipaddress.ip_network(net), records[net])
+
+    if ip_version == 6:
+        ipv4 = root
+        for _ in range(96):
+            ipv4 = ipv4.children[0]
+        for a in ALIASES:
+            alias(root, ipaddress.ip_network(a), ipv4)
+
+    # Number the nodes breadth-first.
+    nodes, index = [root], {id(root): 0}
+    for node in nodes:
+        for child in node.children:
+            if isinstance(child, Node) and id(child) not in index:
+                index[id(child)] = len(nodes)
+                nodes.append(child)
+    node_count = len(nodes)
+
+    def record(child):
+        if child is None:
+            return node_count
+        if isinstance(child, Node):
+            return index[id(child)]
+        return node_count + 16 + child
+
+    tree = bytearray()
+    for node in nodes:
+        left, right = (record(c) for c in node.children)
+        if record_size == 24:
+            tree += left.to_bytes(3, "big") + right.to_bytes(3, "big")
+        elif record_size == 28:
+            tree += left.to_bytes(4, "big")[1:]
+            tree.append((left >> 24) << 4 | right >> 24)
+            tree += right.to_bytes(4, "big")[1:]
+        else:
+            tree += left.to_bytes(4, "big") + right.to_bytes(4, "big")
+
+    meta = {
+        "binary_format_major_version": uint(UINT16, 2),
+        "binary_format_minor_version": uint(UINT16, 0),
+        "build_epoch": uint(UINT64, 1700000000),
+        "database_type": utf8("Test"),
+        "description": ctrl(MAP, 1) + utf8("en") + utf8("Test Database"),
+        "ip_version": uint(UINT16, ip_version),
+        "languages": ctrl(ARRAY, 1) + utf8("en"),
+        "node_count": uint(UINT32, node_count),
+        "record_size": uint(UINT16, record_size),
+    }
+    metadata = ctrl(MAP, len(meta))
+    for k in sorted(meta):
+        metadata += utf8(k) + meta[k]
+
+    with open(name, "wb") as f:
+        f.write(tree)
+        f.write(b"\0" * 16)
+        f.write(data)
+        f.write(b"\xab\xcd\xefMaxMind.com")
+        f.write(metadata)
+
+
+write("iputil-test-ipv4-28.mmdb", 4, 28, IPV4_NETWORKS)
+# In the mixed database, IPv4 networks are in ::/96, with their addresses
+# written as such.
+write(
+    "iputil-test-mixed-24.mmdb",
+    6,
+    24,
+    ["::%s/%d" % (n.split("/")[0], int(n.split("/")[1]) + 96) for n in IPV4_NETWORKS]
+    + IPV6_NETWORKS,
+)
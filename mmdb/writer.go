@@ -0,0 +1,382 @@
+package mmdb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/ericyan/iputil"
+	"github.com/ericyan/iputil/uint128"
+)
+
+// A MergeFunc returns the record for the addresses covered by both an
+// existing record and an inserted one. If it returns nil, the addresses
+// are left without a record.
+type MergeFunc func(existing, inserted interface{}) interface{}
+
+// Merge strategies.
+var (
+	// Replace replaces existing records with the inserted one. It is
+	// used by a Writer whose Merge is nil.
+	Replace MergeFunc = func(existing, inserted interface{}) interface{} { return inserted }
+
+	// Keep keeps existing records, so that an inserted record only
+	// applies to the addresses without one.
+	Keep MergeFunc = func(existing, inserted interface{}) interface{} { return existing }
+
+	// MergeMaps merges records of type map[string]interface{} key by
+	// key, recursively, with the values of the inserted record taking
+	// precedence. Other records are replaced.
+	MergeMaps MergeFunc = mergeMaps
+)
+
+func mergeMaps(existing, inserted interface{}) interface{} {
+	a, ok := existing.(map[string]interface{})
+	if !ok {
+		return inserted
+	}
+	b, ok := inserted.(map[string]interface{})
+	if !ok {
+		return inserted
+	}
+
+	// Records may be shared, so neither map is modified.
+	m := make(map[string]interface{}, len(a)+len(b))
+	for k, v := range a {
+		m[k] = v
+	}
+	for k, v := range b {
+		m[k] = mergeMaps(m[k], v)
+	}
+
+	return m
+}
+
+// aliases are the IPv6 prefixes that refer to IPv4 addresses, which
+// point to the IPv4 subtree in IPv6 databases: IPv4-mapped addresses,
+// Teredo addresses and 6to4 addresses.
+var aliases = []iputil.Prefix{
+	mustParsePrefix("::ffff:0:0/96"),
+	mustParsePrefix("2001::/32"),
+	mustParsePrefix("2002::/16"),
+}
+
+func mustParsePrefix(s string) iputil.Prefix {
+	p, err := iputil.ParsePrefix(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// A node is a node of the search tree being built. It either has two
+// children, or none and possibly a record.
+type node struct {
+	children [2]*node
+	record   interface{}
+}
+
+// isLeaf reports whether n has no children.
+func (n *node) isLeaf() bool {
+	return n.children[0] == nil
+}
+
+// split turns the leaf n into a node whose children have its record.
+func (n *node) split() {
+	n.children = [2]*node{{record: n.record}, {record: n.record}}
+	n.record = nil
+}
+
+// bit returns bit i, counting from the most significant, of an address
+// of width bits.
+func bit(x uint128.Int, width, i int) uint {
+	return uint(x.Rsh(uint(width-1-i)).Lo & 1)
+}
+
+// insert merges record into the subtree of n for the prefix of the
+// given bits of x, n being at depth. Children that end up with equal
+// records are joined.
+func (n *node) insert(x uint128.Int, width, bits, depth int, record interface{}, merge MergeFunc) {
+	if depth == bits {
+		n.merge(record, merge)
+		return
+	}
+
+	if n.isLeaf() {
+		n.split()
+	}
+	n.children[bit(x, width, depth)].insert(x, width, bits, depth+1, record, merge)
+	n.join()
+}
+
+// join turns n back into a leaf if its children are leaves with equal
+// records.
+func (n *node) join() {
+	l, r := n.children[0], n.children[1]
+	if l.isLeaf() && r.isLeaf() && reflect.DeepEqual(l.record, r.record) {
+		n.children, n.record = [2]*node{}, l.record
+	}
+}
+
+// merge merges record into all records in the subtree of n.
+func (n *node) merge(record interface{}, merge MergeFunc) {
+	if !n.isLeaf() {
+		n.children[0].merge(record, merge)
+		n.children[1].merge(record, merge)
+		n.join()
+		return
+	}
+
+	if n.record == nil {
+		n.record = record
+	} else {
+		n.record = merge(n.record, record)
+	}
+}
+
+// graft returns a copy of the subtree of n in which the prefix of the
+// given bits of x, n being at depth, is replaced with target. Only the
+// nodes along the path are copied.
+func (n *node) graft(x uint128.Int, width, bits, depth int, target *node) *node {
+	if depth == bits {
+		return target
+	}
+
+	c := &node{children: n.children, record: n.record}
+	if c.isLeaf() {
+		c.split()
+	}
+
+	b := bit(x, width, depth)
+	c.children[b] = c.children[b].graft(x, width, bits, depth+1, target)
+	return c
+}
+
+// A Writer builds a database.
+//
+// Records are inserted for prefixes, and may be of any type that the
+// Reader can decode from, mapping Go types to the types of the format
+// as follows: strings to utf8_string, byte slices to bytes, float64 to
+// double, float32 to float, uint8 and uint16 to uint16, uint32 to
+// uint32, uint and uint64 to uint64, uint128.Int to uint128, other
+// integers to int32, bools to boolean, maps with string keys and structs
+// to map, and other slices and arrays to array. Struct fields are keyed
+// by their "mmdb" tag, as for decoding, and nil pointers and interfaces
+// in maps and structs are omitted.
+//
+// The output only depends on the inserted records, in the order they
+// were inserted, and the fields below.
+type Writer struct {
+	// IPVersion is 4 for a database of IPv4 addresses only, or 6, the
+	// default, for IPv6 and IPv4 addresses. In IPv6 databases, IPv4
+	// addresses are stored in the ::/96 subtree, which is also aliased
+	// by ::ffff:0:0/96, 2001::/32 and 2002::/16.
+	IPVersion uint
+
+	// RecordSize is the size of search tree records in bits, which is
+	// 24, 28 or 32. The default is 28.
+	RecordSize uint
+
+	// Merge determines the record of addresses covered by more than one
+	// insert. If it is nil, Replace is used.
+	Merge MergeFunc
+
+	DatabaseType string
+	Description  map[string]string
+	Languages    []string
+
+	// BuildEpoch is the build time in seconds since the Unix epoch. It
+	// is not set automatically, as the output would not be deterministic.
+	BuildEpoch uint64
+
+	root *node
+}
+
+func (w *Writer) ipVersion() uint {
+	if w.IPVersion == 0 {
+		return 6
+	}
+
+	return w.IPVersion
+}
+
+// Insert inserts record for the addresses in p, merging it with any
+// existing records for those addresses. Prefixes must not be in the
+// ranges aliased to the IPv4 subtree in IPv6 databases.
+func (w *Writer) Insert(p iputil.Prefix, record interface{}) error {
+	if !p.IsValid() {
+		return errors.New("mmdb: invalid prefix")
+	}
+	if _, err := appendValue(nil, reflect.ValueOf(record), 0); err != nil {
+		return err
+	}
+
+	width := 128
+	switch {
+	case w.ipVersion() == 4 && p.Addr().Family() == iputil.IPv6:
+		return errors.New("mmdb: cannot insert ipv6 prefix into ipv4 database")
+	case w.ipVersion() == 4:
+		width = 32
+	case p.Addr().Family() == iputil.IPv4:
+		// IPv4 addresses are stored under ::/96, where an address has the
+		// same integer value.
+		return w.insert(p.Addr().Int(), width, p.Bits()+96, record)
+	default:
+		for _, alias := range aliases {
+			if alias.Contains(p.Addr()) && p.Bits() >= alias.Bits() {
+				return fmt.Errorf("mmdb: cannot insert %s into aliased network %s", p, alias)
+			}
+		}
+	}
+
+	return w.insert(p.Addr().Int(), width, p.Bits(), record)
+}
+
+func (w *Writer) insert(x uint128.Int, width, bits int, record interface{}) error {
+	merge := w.Merge
+	if merge == nil {
+		merge = Replace
+	}
+
+	if w.root == nil {
+		w.root = new(node)
+	}
+	w.root.insert(x, width, bits, 0, record, merge)
+
+	return nil
+}
+
+// WriteTo writes the database to out.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	recordSize := w.RecordSize
+	if recordSize == 0 {
+		recordSize = 28
+	}
+	if recordSize != 24 && recordSize != 28 && recordSize != 32 {
+		return 0, fmt.Errorf("mmdb: unsupported record size %d", recordSize)
+	}
+	if v := w.ipVersion(); v != 4 && v != 6 {
+		return 0, fmt.Errorf("mmdb: unsupported ip version %d", v)
+	}
+
+	root := w.root
+	if root == nil {
+		root = new(node)
+	}
+	if w.ipVersion() == 6 {
+		// The IPv4 subtree is the node, or leaf, reached by following 96
+		// zero bits.
+		ipv4 := root
+		for i := 0; i < 96 && !ipv4.isLeaf(); i++ {
+			ipv4 = ipv4.children[0]
+		}
+
+		for _, alias := range aliases {
+			root = root.graft(alias.Addr().Int(), 128, alias.Bits(), 0, ipv4)
+		}
+	}
+	if root.isLeaf() {
+		root = &node{children: [2]*node{root, root}}
+	}
+
+	// Number the nodes breadth first. Aliased subtrees are reachable by
+	// more than one path, but are numbered once.
+	var nodes []*node
+	index := make(map[*node]uint)
+	for queue := []*node{root}; len(queue) > 0; queue = queue[1:] {
+		n := queue[0]
+		if _, ok := index[n]; ok {
+			continue
+		}
+		index[n] = uint(len(nodes))
+		nodes = append(nodes, n)
+
+		for _, c := range n.children {
+			if !c.isLeaf() {
+				queue = append(queue, c)
+			}
+		}
+	}
+	count := uint(len(nodes))
+
+	// Encode the records in the order they are referred to, storing
+	// equal records once.
+	var data []byte
+	offsets := make(map[string]uint)
+	records := make([]uint, 0, 2*len(nodes))
+	for _, n := range nodes {
+		for _, c := range n.children {
+			switch {
+			case !c.isLeaf():
+				records = append(records, index[c])
+			case c.record == nil:
+				records = append(records, count)
+			default:
+				b, err := appendValue(nil, reflect.ValueOf(c.record), 0)
+				if err != nil {
+					return 0, err
+				}
+
+				offset, ok := offsets[string(b)]
+				if !ok {
+					offset = uint(len(data))
+					offsets[string(b)] = offset
+					data = append(data, b...)
+				}
+				records = append(records, count+dataSectionSeparator+offset)
+			}
+		}
+	}
+
+	if count+dataSectionSeparator+uint(len(data)) > 1<<recordSize {
+		return 0, fmt.Errorf("mmdb: database too large for record size %d", recordSize)
+	}
+
+	buf := make([]byte, 0, count*recordSize/4+dataSectionSeparator+uint(len(data)))
+	for i := 0; i < len(records); i += 2 {
+		l, r := records[i], records[i+1]
+		switch recordSize {
+		case 24:
+			buf = append(buf, byte(l>>16), byte(l>>8), byte(l), byte(r>>16), byte(r>>8), byte(r))
+		case 28:
+			buf = append(buf, byte(l>>16), byte(l>>8), byte(l), byte(l>>20)&0xf0|byte(r>>24)&0x0f,
+				byte(r>>16), byte(r>>8), byte(r))
+		default:
+			buf = append(buf, byte(l>>24), byte(l>>16), byte(l>>8), byte(l),
+				byte(r>>24), byte(r>>16), byte(r>>8), byte(r))
+		}
+	}
+	buf = append(buf, make([]byte, dataSectionSeparator)...)
+	buf = append(buf, data...)
+	buf = append(buf, metadataStart...)
+
+	description := w.Description
+	if description == nil {
+		description = map[string]string{}
+	}
+	languages := w.Languages
+	if languages == nil {
+		languages = []string{}
+	}
+
+	// The metadata is encoded with the types given by the specification.
+	buf, err := appendValue(buf, reflect.ValueOf(map[string]interface{}{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 w.BuildEpoch,
+		"database_type":               w.DatabaseType,
+		"description":                 description,
+		"ip_version":                  uint16(w.ipVersion()),
+		"languages":                   languages,
+		"node_count":                  uint32(count),
+		"record_size":                 uint16(recordSize),
+	}), 0)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := out.Write(buf)
+	return int64(n), err
+}
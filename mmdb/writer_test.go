@@ -0,0 +1,279 @@
+package mmdb
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/ericyan/iputil"
+)
+
+func mustPrefix(t *testing.T, s string) iputil.Prefix {
+	t.Helper()
+
+	p, err := iputil.ParsePrefix(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func mustReader(t *testing.T, w *Writer) *Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := FromBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestWriterMerge(t *testing.T) {
+	cases := []struct {
+		merge  MergeFunc
+		inner  interface{}
+		outer  interface{}
+		prefix string
+	}{
+		{
+			nil,
+			map[string]interface{}{"site": "branch"},
+			map[string]interface{}{"site": "hq", "owner": "neteng"},
+			"10.1.0.0/16",
+		},
+		{
+			Keep,
+			map[string]interface{}{"site": "hq", "owner": "neteng"},
+			map[string]interface{}{"site": "hq", "owner": "neteng"},
+			"10.0.0.0/8",
+		},
+		{
+			MergeMaps,
+			map[string]interface{}{"site": "branch", "owner": "neteng"},
+			map[string]interface{}{"site": "hq", "owner": "neteng"},
+			"10.1.0.0/16",
+		},
+	}
+
+	for _, c := range cases {
+		w := &Writer{Merge: c.merge}
+		if err := w.Insert(mustPrefix(t, "10.0.0.0/8"), map[string]interface{}{"site": "hq", "owner": "neteng"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Insert(mustPrefix(t, "10.1.0.0/16"), map[string]interface{}{"site": "branch"}); err != nil {
+			t.Fatal(err)
+		}
+		r := mustReader(t, w)
+
+		var inner, outer interface{}
+		prefix, _, err := r.Lookup(net.ParseIP("10.1.2.3"), &inner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := r.Lookup(net.ParseIP("10.2.0.0"), &outer); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(inner, c.inner) || !reflect.DeepEqual(outer, c.outer) {
+			t.Errorf("unexpected records: got %v and %v, want %v and %v", inner, outer, c.inner, c.outer)
+		}
+		if prefix.String() != c.prefix {
+			t.Errorf("unexpected prefix: got %s, want %s", prefix, c.prefix)
+		}
+	}
+}
+
+func TestWriterMergeCovering(t *testing.T) {
+	w := &Writer{IPVersion: 4, Merge: MergeMaps}
+	inserts := []struct {
+		prefix string
+		record map[string]interface{}
+	}{
+		{"192.0.2.0/25", map[string]interface{}{"vlan": uint16(10)}},
+		{"192.0.2.128/25", map[string]interface{}{"vlan": uint16(20)}},
+		{"192.0.2.0/24", map[string]interface{}{"site": "lab"}},
+	}
+	for _, i := range inserts {
+		if err := w.Insert(mustPrefix(t, i.prefix), i.record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := mustReader(t, w)
+
+	cases := []struct {
+		ip     string
+		prefix string
+		vlan   uint16
+	}{
+		{"192.0.2.1", "192.0.2.0/25", 10},
+		{"192.0.2.129", "192.0.2.128/25", 20},
+	}
+
+	for _, c := range cases {
+		var rec struct {
+			Site string `mmdb:"site"`
+			VLAN uint16 `mmdb:"vlan"`
+		}
+
+		prefix, found, err := r.Lookup(net.ParseIP(c.ip), &rec)
+		if err != nil || !found {
+			t.Fatalf("unexpected result for %s: %t, %v", c.ip, found, err)
+		}
+		if prefix.String() != c.prefix || rec.Site != "lab" || rec.VLAN != c.vlan {
+			t.Errorf("unexpected result for %s: got %s, %+v", c.ip, prefix, rec)
+		}
+	}
+
+	// Replacing the records of both halves with equal records joins them.
+	w.Merge = Replace
+	if err := w.Insert(mustPrefix(t, "192.0.2.0/24"), "lab"); err != nil {
+		t.Fatal(err)
+	}
+	if prefix, _, _ := mustReader(t, w).Lookup(net.ParseIP("192.0.2.1"), nil); prefix.String() != "192.0.2.0/24" {
+		t.Errorf("unexpected prefix: got %s, want %s", prefix, "192.0.2.0/24")
+	}
+
+	// Records can be removed by a merge function returning nil.
+	w.Merge = func(existing, inserted interface{}) interface{} { return nil }
+	if err := w.Insert(mustPrefix(t, "192.0.2.0/26"), "none"); err != nil {
+		t.Fatal(err)
+	}
+	r = mustReader(t, w)
+	if _, found, _ := r.Lookup(net.ParseIP("192.0.2.1"), nil); found {
+		t.Error("unexpected record for removed prefix")
+	}
+	if _, found, _ := r.Lookup(net.ParseIP("192.0.2.65"), nil); !found {
+		t.Error("record expected for remaining prefix")
+	}
+}
+
+func TestWriterAliases(t *testing.T) {
+	w := new(Writer)
+	if err := w.Insert(mustPrefix(t, "::/0"), "default"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Insert(mustPrefix(t, "10.0.0.0/8"), "ipv4"); err != nil {
+		t.Fatal(err)
+	}
+	r := mustReader(t, w)
+
+	cases := []struct {
+		ip     string
+		prefix string
+		record string
+	}{
+		{"10.0.0.1", "10.0.0.0/8", "ipv4"},
+		{"2002:a00:1::1", "2002:a00::/24", "ipv4"},
+		{"2001:0:a00:1::1", "2001:0:a00::/40", "ipv4"},
+		{"2001:0:b00:1::1", "2001:0:b00::/40", "default"},
+		{"2001:db8::1", "2001:800::/21", "default"},
+		{"2002:b00::1", "2002:b00::/24", "default"},
+		{"11.0.0.1", "11.0.0.0/8", "default"},
+	}
+
+	for _, c := range cases {
+		var s string
+		prefix, _, err := r.Lookup(net.ParseIP(c.ip), &s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prefix.String() != c.prefix || s != c.record {
+			t.Errorf("unexpected result for %s: got %s, %q", c.ip, prefix, s)
+		}
+	}
+
+	for _, s := range []string{"2002::/16", "2002:a00::/24", "2001::/32", "::ffff:0:0/96"} {
+		if err := w.Insert(mustPrefix(t, s), "aliased"); err == nil {
+			t.Errorf("error expected for %s", s)
+		}
+	}
+}
+
+func TestWriterDeterministic(t *testing.T) {
+	build := func(recordSize uint) []byte {
+		w := &Writer{RecordSize: recordSize}
+		for _, s := range []string{"10.0.0.0/8", "2001:db8::/32", "192.0.2.0/24", "172.16.0.0/12"} {
+			if err := w.Insert(mustPrefix(t, s), map[string]interface{}{
+				"a": "b", "c": uint32(1), "d": []interface{}{"e", 1.5}, "f": true,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var buf bytes.Buffer
+		if _, err := w.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	for _, size := range []uint{24, 28, 32} {
+		b := build(size)
+		for i := 0; i < 10; i++ {
+			if !bytes.Equal(build(size), b) {
+				t.Fatalf("output for %d-bit records is not deterministic", size)
+			}
+		}
+
+		r, err := FromBytes(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Metadata.RecordSize != size {
+			t.Errorf("unexpected record size: got %d, want %d", r.Metadata.RecordSize, size)
+		}
+
+		// Equal records are stored once.
+		if n := bytes.Count(b, []byte("\x41a\x41b")); n != 1 {
+			t.Errorf("unexpected number of records: got %d, want 1", n)
+		}
+	}
+}
+
+func TestWriterEmpty(t *testing.T) {
+	for _, v := range []uint{4, 6} {
+		r := mustReader(t, &Writer{IPVersion: v})
+		if _, found, err := r.Lookup(net.ParseIP("192.0.2.1"), nil); err != nil || found {
+			t.Errorf("unexpected result for empty ipv%d database: %t, %v", v, found, err)
+		}
+	}
+}
+
+func TestWriterErrors(t *testing.T) {
+	w := &Writer{IPVersion: 4}
+	if err := w.Insert(mustPrefix(t, "2001:db8::/32"), "x"); err == nil {
+		t.Error("error expected for ipv6 prefix in ipv4 database")
+	}
+	if err := w.Insert(iputil.Prefix{}, "x"); err == nil {
+		t.Error("error expected for invalid prefix")
+	}
+
+	records := []interface{}{
+		nil,
+		make(chan int),
+		map[int]string{1: "x"},
+		int64(1) << 40,
+		(*string)(nil),
+	}
+	for _, v := range records {
+		if err := w.Insert(mustPrefix(t, "192.0.2.0/24"), v); err == nil {
+			t.Errorf("error expected for record %#v", v)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := (&Writer{RecordSize: 16}).WriteTo(&buf); err == nil {
+		t.Error("error expected for unsupported record size")
+	}
+	if _, err := (&Writer{IPVersion: 5}).WriteTo(&buf); err == nil {
+		t.Error("error expected for unsupported ip version")
+	}
+}
@@ -0,0 +1,199 @@
+package iputil
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+)
+
+var (
+	errNotMulticast      = errors.New("not a multicast address")
+	errInvalidScope      = errors.New("invalid multicast scope")
+	errInvalidRP         = errors.New("invalid rendezvous point address")
+	errInvalidMcastFlags = errors.New("unexpected multicast flags")
+)
+
+var (
+	ipv4SSM = &net.IPNet{IP: net.IP{232, 0, 0, 0}, Mask: net.CIDRMask(8, IPv4BitLen)}
+
+	ipv4LinkLocalMulticast = &net.IPNet{IP: net.IP{224, 0, 0, 0}, Mask: net.CIDRMask(24, IPv4BitLen)}
+	ipv4AdminScoped        = &net.IPNet{IP: net.IP{239, 0, 0, 0}, Mask: net.CIDRMask(8, IPv4BitLen)}
+	ipv4LocalScope         = &net.IPNet{IP: net.IP{239, 255, 0, 0}, Mask: net.CIDRMask(16, IPv4BitLen)}
+	ipv4OrganizationScope  = &net.IPNet{IP: net.IP{239, 192, 0, 0}, Mask: net.CIDRMask(14, IPv4BitLen)}
+)
+
+// SolicitedNodeAddr returns the solicited-node multicast address of the
+// IPv6 unicast address ip, as specified in section 2.7.1 of RFC 4291.
+func SolicitedNodeAddr(ip net.IP) net.IP {
+	if !IsIPv6(ip) || IsIPv4(ip) {
+		return nil
+	}
+
+	addr := net.ParseIP("ff02::1:ff00:0")
+	copy(addr[13:], ip[13:])
+
+	return addr
+}
+
+// MulticastMAC returns the Ethernet multicast address to which the IPv4
+// or IPv6 multicast group is mapped, as specified in section 6.4 of
+// RFC 1112 and section 7 of RFC 2464.
+func MulticastMAC(group net.IP) net.HardwareAddr {
+	if !group.IsMulticast() {
+		return nil
+	}
+
+	if ip := group.To4(); ip != nil {
+		return net.HardwareAddr{0x01, 0x00, 0x5e, ip[1] & 0x7f, ip[2], ip[3]}
+	}
+
+	return net.HardwareAddr{0x33, 0x33, group[12], group[13], group[14], group[15]}
+}
+
+// IsSourceSpecificMulticast reports whether ip is in the source-specific
+// multicast range: 232.0.0.0/8 for IPv4 as specified in RFC 4607, or
+// ff3x::/96 for IPv6 as specified in RFC 3306.
+func IsSourceSpecificMulticast(ip net.IP) bool {
+	if IsIPv4(ip) {
+		return ipv4SSM.Contains(ip)
+	}
+
+	return IsIPv6(ip) && ip[0] == 0xff && ip[1]&0xf0 == 0x30 && isZero(ip[2:12])
+}
+
+// IsAdminScopedMulticast reports whether ip is an IPv4 administratively
+// scoped multicast address in 239.0.0.0/8, as specified in RFC 2365.
+func IsAdminScopedMulticast(ip net.IP) bool {
+	return IsIPv4(ip) && ipv4AdminScoped.Contains(ip)
+}
+
+// MulticastScope returns the scope of the multicast address ip. For
+// IPv6 addresses, it is the value of the scope field. IPv4 addresses are
+// mapped to the closest IPv6 scope: the local network control block
+// 224.0.0.0/24 is link-local, the IPv4 local scope 239.255.0.0/16 is
+// site-local, the organization local scope 239.192.0.0/14 is
+// organization-local, the rest of 239.0.0.0/8 is admin-local, and
+// everything else is global. It returns 0 if ip is not a multicast
+// address.
+func MulticastScope(ip net.IP) Scope {
+	if !ip.IsMulticast() {
+		return 0
+	}
+
+	if !IsIPv4(ip) {
+		return Scope(ip[1] & 0x0f)
+	}
+
+	switch {
+	case ipv4LinkLocalMulticast.Contains(ip):
+		return ScopeLinkLocal
+	case ipv4LocalScope.Contains(ip):
+		return ScopeSiteLocal
+	case ipv4OrganizationScope.Contains(ip):
+		return ScopeOrganizationLocal
+	case ipv4AdminScoped.Contains(ip):
+		return ScopeAdminLocal
+	default:
+		return ScopeGlobal
+	}
+}
+
+// PrefixMulticastAddr returns the unicast-prefix-based IPv6 multicast
+// address for the given prefix, scope and group ID, as specified in
+// RFC 3306. The prefix must be an IPv6 prefix no longer than 64 bits.
+func PrefixMulticastAddr(prefix *net.IPNet, scope Scope, groupID uint32) (net.IP, error) {
+	p, err := prefix64(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if scope > 0xf {
+		return nil, errInvalidScope
+	}
+	plen, _ := prefix.Mask.Size()
+
+	addr := make(net.IP, net.IPv6len)
+	addr[0] = 0xff
+	addr[1] = 0x30 | byte(scope)
+	addr[3] = byte(plen)
+	copy(addr[4:12], p)
+	binary.BigEndian.PutUint32(addr[12:], groupID)
+
+	return addr, nil
+}
+
+// ParsePrefixMulticastAddr returns the unicast prefix and group ID of
+// the RFC 3306 unicast-prefix-based IPv6 multicast address ip. As
+// required by section 4 of RFC 3306, both the P and T flags must be set.
+func ParsePrefixMulticastAddr(ip net.IP) (*net.IPNet, uint32, error) {
+	if !IsIPv6(ip) || ip[0] != 0xff {
+		return nil, 0, errNotMulticast
+	}
+	if ip[1]&0x30 != 0x30 || ip[3] > 64 {
+		return nil, 0, errInvalidMcastFlags
+	}
+
+	prefix := &net.IPNet{
+		IP:   make(net.IP, net.IPv6len),
+		Mask: net.CIDRMask(int(ip[3]), IPv6BitLen),
+	}
+	copy(prefix.IP, ip[4:12])
+	prefix.IP = prefix.IP.Mask(prefix.Mask)
+
+	return prefix, binary.BigEndian.Uint32(ip[12:]), nil
+}
+
+// EmbeddedRPAddr returns the IPv6 multicast address that embeds the
+// address of the rendezvous point rp, as specified in RFC 3956. The
+// rendezvous point address consists of a prefix of plen bits, at most
+// 64, followed by zeros and a 4-bit interface ID in the last nibble.
+func EmbeddedRPAddr(rp net.IP, plen int, scope Scope, groupID uint32) (net.IP, error) {
+	if !IsIPv6(rp) || IsIPv4(rp) || plen < 1 || plen > 64 {
+		return nil, errInvalidRP
+	}
+	if scope > 0xf {
+		return nil, errInvalidScope
+	}
+
+	prefix := &net.IPNet{IP: rp.Mask(net.CIDRMask(plen, IPv6BitLen)), Mask: net.CIDRMask(plen, IPv6BitLen)}
+	riid := rp[15] & 0x0f
+
+	// Everything between the prefix and the interface ID must be zero.
+	check := append(net.IP(nil), prefix.IP...)
+	check[15] |= riid
+	if !check.Equal(rp) {
+		return nil, errInvalidRP
+	}
+
+	addr, err := PrefixMulticastAddr(prefix, scope, groupID)
+	if err != nil {
+		return nil, err
+	}
+	addr[1] |= 0x40
+	addr[2] = riid
+
+	return addr, nil
+}
+
+// RendezvousPoint returns the address of the rendezvous point embedded
+// in the IPv6 multicast address group, as specified in RFC 3956.
+func RendezvousPoint(group net.IP) (net.IP, error) {
+	if !IsIPv6(group) || group[0] != 0xff {
+		return nil, errNotMulticast
+	}
+	if group[1]&0x70 != 0x70 {
+		return nil, errInvalidMcastFlags
+	}
+
+	prefix, _, err := ParsePrefixMulticastAddr(group)
+	if err != nil {
+		return nil, err
+	}
+	if ones, _ := prefix.Mask.Size(); ones == 0 {
+		return nil, errInvalidRP
+	}
+
+	rp := prefix.IP
+	rp[15] = group[2] & 0x0f
+
+	return rp, nil
+}
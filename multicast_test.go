@@ -0,0 +1,118 @@
+package iputil
+
+import (
+	"net"
+	"testing"
+)
+
+func TestMulticastMAC(t *testing.T) {
+	cases := []struct {
+		group net.IP
+		mac   string
+	}{
+		{ParseIPv4("224.0.0.251"), "01:00:5e:00:00:fb"},
+		{ParseIPv4("239.129.1.2"), "01:00:5e:01:01:02"},
+		{ParseIPv6("ff02::1:ff3c:4d5e"), "33:33:ff:3c:4d:5e"},
+		{ParseIPv6("ff02::fb"), "33:33:00:00:00:fb"},
+		{ParseIPv4("192.168.0.1"), ""},
+	}
+
+	for _, c := range cases {
+		if mac := MulticastMAC(c.group); mac.String() != c.mac {
+			t.Errorf("unexpected mac for %s: got %s, want %s", c.group, mac, c.mac)
+		}
+	}
+}
+
+func TestSolicitedNodeAddr(t *testing.T) {
+	if addr := SolicitedNodeAddr(ParseIPv6("2001:db8::1:2:3:4")); !addr.Equal(ParseIPv6("ff02::1:ff03:4")) {
+		t.Errorf("unexpected solicited-node address: %s", addr)
+	}
+	if addr := SolicitedNodeAddr(ParseIPv4("192.168.0.1")); addr != nil {
+		t.Errorf("unexpected solicited-node address: %s", addr)
+	}
+}
+
+func TestMulticastScope(t *testing.T) {
+	cases := []struct {
+		ip    net.IP
+		ssm   bool
+		admin bool
+		scope Scope
+	}{
+		{ParseIPv4("192.168.0.1"), false, false, 0},
+		{ParseIPv4("224.0.0.1"), false, false, ScopeLinkLocal},
+		{ParseIPv4("232.1.2.3"), true, false, ScopeGlobal},
+		{ParseIPv4("239.1.2.3"), false, true, ScopeAdminLocal},
+		{ParseIPv4("239.193.0.1"), false, true, ScopeOrganizationLocal},
+		{ParseIPv4("239.255.255.250"), false, true, ScopeSiteLocal},
+		{ParseIPv6("ff02::1"), false, false, ScopeLinkLocal},
+		{ParseIPv6("ff3e::8000:1"), true, false, ScopeGlobal},
+		{ParseIPv6("ff3e:30:2001:db8::1"), false, false, ScopeGlobal},
+		{ParseIPv6("2001:db8::1"), false, false, 0},
+	}
+
+	for _, c := range cases {
+		if ssm := IsSourceSpecificMulticast(c.ip); ssm != c.ssm {
+			t.Errorf("unexpected ssm for %s: got %t, want %t", c.ip, ssm, c.ssm)
+		}
+		if admin := IsAdminScopedMulticast(c.ip); admin != c.admin {
+			t.Errorf("unexpected admin scope for %s: got %t, want %t", c.ip, admin, c.admin)
+		}
+		if scope := MulticastScope(c.ip); scope != c.scope {
+			t.Errorf("unexpected scope for %s: got %s, want %s", c.ip, scope, c.scope)
+		}
+	}
+}
+
+func TestPrefixMulticastAddr(t *testing.T) {
+	_, prefix, _ := net.ParseCIDR("3ffe:ffff:1::/48")
+	addr, err := PrefixMulticastAddr(prefix, ScopeGlobal, 0x12345678)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ParseIPv6("ff3e:30:3ffe:ffff:1::1234:5678"); !addr.Equal(want) {
+		t.Errorf("unexpected address: got %s, want %s", addr, want)
+	}
+
+	p, groupID, err := ParsePrefixMulticastAddr(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != prefix.String() || groupID != 0x12345678 {
+		t.Errorf("unexpected prefix and group id: got %s %x", p, groupID)
+	}
+
+	if _, _, err := ParsePrefixMulticastAddr(ParseIPv6("ff02::1")); err == nil {
+		t.Error("error expected for non-prefix-based multicast address")
+	}
+	if _, _, err := ParsePrefixMulticastAddr(ParseIPv6("ff2e:40:2001:db8:1:2:1234:5678")); err == nil {
+		t.Error("error expected for P flag without T flag")
+	}
+}
+
+func TestEmbeddedRP(t *testing.T) {
+	rp := ParseIPv6("2001:db8:beef:feed::1")
+	addr, err := EmbeddedRPAddr(rp, 64, ScopeGlobal, 0x1234)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ParseIPv6("ff7e:140:2001:db8:beef:feed:0:1234"); !addr.Equal(want) {
+		t.Errorf("unexpected address: got %s, want %s", addr, want)
+	}
+
+	got, err := RendezvousPoint(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(rp) {
+		t.Errorf("unexpected rendezvous point: got %s, want %s", got, rp)
+	}
+
+	if _, err := EmbeddedRPAddr(ParseIPv6("2001:db8:beef:feed::11"), 64, ScopeGlobal, 0); err == nil {
+		t.Error("error expected for rendezvous point with non-zero bits after prefix")
+	}
+	if _, err := RendezvousPoint(ParseIPv6("ff3e:30:3ffe:ffff:1::1")); err == nil {
+		t.Error("error expected for multicast address without embedded rp")
+	}
+}
@@ -0,0 +1,310 @@
+package iputil
+
+import (
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/ericyan/iputil/uint128"
+)
+
+// Errors wrapped by ParseError to describe why an address is invalid.
+var (
+	ErrEmpty           = errors.New("empty string")
+	ErrUnexpectedToken = errors.New("unexpected character")
+	ErrUnexpectedEnd   = errors.New("unexpected end of string")
+	ErrLeadingZero     = errors.New("leading zero")
+	ErrOverflow        = errors.New("value out of range")
+	ErrZoneNotAllowed  = errors.New("zone not allowed")
+	ErrFamilyMismatch  = errors.New("address family mismatch")
+)
+
+// A ParseError describes why s could not be parsed as an IP address.
+// Its Err field is one of the errors defined above and can be tested
+// for with errors.Is.
+type ParseError struct {
+	Input    string // the string being parsed
+	Pos      int    // byte offset in Input at which the error occured
+	Expected string // description of the expected input, if any
+	Err      error  // the reason parsing failed
+}
+
+func (e *ParseError) Error() string {
+	msg := "iputil: parsing " + strconv.Quote(e.Input) + " at offset " + strconv.Itoa(e.Pos) + ": " + e.Err.Error()
+	if e.Expected != "" {
+		msg += ", expected " + e.Expected
+	}
+
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Parse parses s as an IP address in dotted-decimal IPv4 or RFC 4291
+// IPv6 notation. Unlike ParseIPv4 and ParseIPv6, it rejects IPv4 parts
+// with leading zeros and IPv6 zones, and reports failures as a
+// *ParseError. If af is IPv4 or IPv6, s must be an address of that
+// family; if af is 0, either is accepted. IPv4 addresses are returned as
+// 4-byte slices, IPv6 addresses (including IPv4-mapped ones) as 16-byte
+// slices.
+func Parse(s string, af uint) (net.IP, error) {
+	addr, family, err := ParseArray(s, af)
+	if err != nil {
+		return nil, err
+	}
+
+	if family == IPv4 {
+		ip := make(net.IP, net.IPv4len)
+		copy(ip, addr[12:])
+		return ip, nil
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, addr[:])
+	return ip, nil
+}
+
+// ParseArray is like Parse, but returns the address as a 16-byte array
+// together with its address family, and does not allocate unless s is
+// invalid. IPv4 addresses are stored in the last four bytes of the
+// array, and the first 12 bytes are zero.
+func ParseArray(s string, af uint) (addr [16]byte, family uint, err error) {
+	if len(s) == 0 {
+		return addr, 0, &ParseError{Input: s, Err: ErrEmpty}
+	}
+
+scan:
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '.':
+			family = IPv4
+			break scan
+		case ':':
+			family = IPv6
+			break scan
+		}
+	}
+
+	switch {
+	case family == 0:
+		err = &ParseError{Input: s, Pos: len(s), Expected: "'.' or ':'", Err: ErrUnexpectedEnd}
+	case af != 0 && af != family:
+		err = &ParseError{Input: s, Err: ErrFamilyMismatch}
+	case family == IPv4:
+		err = parseIPv4(s, 0, addr[12:])
+	default:
+		err = parseIPv6(s, addr[:])
+	}
+	if err != nil {
+		return [16]byte{}, 0, err
+	}
+
+	return addr, family, nil
+}
+
+// parseIPv4 parses s[off:] as a dotted-decimal IPv4 address into dst,
+// which must be 4 bytes long. Positions in errors are relative to s.
+func parseIPv4(s string, off int, dst []byte) error {
+	i := off
+	for n := 0; n < net.IPv4len; n++ {
+		if n > 0 {
+			if i == len(s) {
+				return &ParseError{Input: s, Pos: i, Expected: "'.'", Err: ErrUnexpectedEnd}
+			}
+			if s[i] != '.' {
+				return &ParseError{Input: s, Pos: i, Expected: "'.'", Err: ErrUnexpectedToken}
+			}
+			i++
+		}
+
+		start, v := i, 0
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			if i > start && s[start] == '0' {
+				return &ParseError{Input: s, Pos: start, Err: ErrLeadingZero}
+			}
+			v = v*10 + int(s[i]-'0')
+			if v > 0xff {
+				return &ParseError{Input: s, Pos: start, Err: ErrOverflow}
+			}
+			i++
+		}
+		if i == start {
+			if i == len(s) {
+				return &ParseError{Input: s, Pos: i, Expected: "decimal digit", Err: ErrUnexpectedEnd}
+			}
+			return &ParseError{Input: s, Pos: i, Expected: "decimal digit", Err: ErrUnexpectedToken}
+		}
+		dst[n] = byte(v)
+	}
+
+	if i < len(s) {
+		if s[i] == '%' {
+			return &ParseError{Input: s, Pos: i, Err: ErrZoneNotAllowed}
+		}
+		return &ParseError{Input: s, Pos: i, Expected: "end of string", Err: ErrUnexpectedToken}
+	}
+
+	return nil
+}
+
+// parseIPv6 parses s as an IPv6 address into dst, which must be 16
+// bytes long.
+func parseIPv6(s string, dst []byte) error {
+	ellipsis := -1 // byte index in dst at which "::" occurs
+	i, n := 0, 0   // position in s and number of bytes written to dst
+
+	if len(s) >= 2 && s[0] == ':' && s[1] == ':' {
+		ellipsis = 0
+		i = 2
+		if i == len(s) {
+			return nil
+		}
+	}
+
+	for n < net.IPv6len {
+		start, v := i, 0
+		for i < len(s) && i-start < 4 {
+			d, ok := hexDigit(s[i])
+			if !ok {
+				break
+			}
+			v = v<<4 | d
+			i++
+		}
+		if i < len(s) && i-start == 4 {
+			if _, ok := hexDigit(s[i]); ok {
+				return &ParseError{Input: s, Pos: start, Err: ErrOverflow}
+			}
+		}
+		if i == start {
+			if i == len(s) {
+				return &ParseError{Input: s, Pos: i, Expected: "hexadecimal digit", Err: ErrUnexpectedEnd}
+			}
+			if s[i] == '%' && ellipsis == n {
+				return &ParseError{Input: s, Pos: i, Err: ErrZoneNotAllowed}
+			}
+			return &ParseError{Input: s, Pos: i, Expected: "hexadecimal digit", Err: ErrUnexpectedToken}
+		}
+
+		// An embedded IPv4 address must occupy the last 32 bits.
+		if i < len(s) && s[i] == '.' {
+			if ellipsis < 0 && n != net.IPv6len-net.IPv4len || n > net.IPv6len-net.IPv4len {
+				return &ParseError{Input: s, Pos: i, Expected: "':'", Err: ErrUnexpectedToken}
+			}
+			if err := parseIPv4(s, start, dst[n:n+net.IPv4len]); err != nil {
+				return err
+			}
+			i, n = len(s), n+net.IPv4len
+			break
+		}
+
+		dst[n], dst[n+1] = byte(v>>8), byte(v)
+		n += 2
+
+		if i == len(s) {
+			break
+		}
+		if s[i] == '%' {
+			return &ParseError{Input: s, Pos: i, Err: ErrZoneNotAllowed}
+		}
+		if s[i] != ':' {
+			return &ParseError{Input: s, Pos: i, Expected: "':'", Err: ErrUnexpectedToken}
+		}
+		i++
+		if i == len(s) {
+			return &ParseError{Input: s, Pos: i, Expected: "hexadecimal digit", Err: ErrUnexpectedEnd}
+		}
+
+		if s[i] == ':' {
+			if ellipsis >= 0 {
+				return &ParseError{Input: s, Pos: i - 1, Err: ErrUnexpectedToken}
+			}
+			ellipsis = n
+			i++
+			if i == len(s) {
+				break
+			}
+		}
+	}
+
+	if i < len(s) {
+		if s[i] == '%' {
+			return &ParseError{Input: s, Pos: i, Err: ErrZoneNotAllowed}
+		}
+		return &ParseError{Input: s, Pos: i, Expected: "end of string", Err: ErrUnexpectedToken}
+	}
+
+	if ellipsis < 0 {
+		if n < net.IPv6len {
+			return &ParseError{Input: s, Pos: i, Expected: "':'", Err: ErrUnexpectedEnd}
+		}
+		return nil
+	}
+
+	// The ellipsis must stand for at least one group of zeros.
+	if n == net.IPv6len {
+		return &ParseError{Input: s, Pos: len(s), Err: ErrOverflow}
+	}
+
+	shift := net.IPv6len - n
+	copy(dst[ellipsis+shift:], dst[ellipsis:n])
+	for j := ellipsis; j < ellipsis+shift; j++ {
+		dst[j] = 0
+	}
+
+	return nil
+}
+
+func hexDigit(c byte) (int, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0'), true
+	case 'a' <= c && c <= 'f':
+		return int(c - 'a' + 10), true
+	case 'A' <= c && c <= 'F':
+		return int(c - 'A' + 10), true
+	default:
+		return 0, false
+	}
+}
+
+// ParseInt is like ParseDecimal, but reports failures as a *ParseError.
+func ParseInt(s string, af uint) (net.IP, error) {
+	var byteLen int
+	switch af {
+	case IPv4:
+		byteLen = net.IPv4len
+	case IPv6:
+		byteLen = net.IPv6len
+	default:
+		return nil, &ParseError{Input: s, Err: ErrFamilyMismatch}
+	}
+
+	x, err := uint128.Atoi(s)
+	switch err {
+	case nil:
+	case uint128.ErrOverflow:
+		return nil, &ParseError{Input: s, Err: ErrOverflow}
+	default:
+		if len(s) == 0 {
+			return nil, &ParseError{Input: s, Err: ErrEmpty}
+		}
+		for i := 0; i < len(s); i++ {
+			if s[i] < '0' || s[i] > '9' {
+				return nil, &ParseError{Input: s, Pos: i, Expected: "decimal digit", Err: ErrUnexpectedToken}
+			}
+		}
+		return nil, &ParseError{Input: s, Err: err}
+	}
+
+	if x.BitLen() > byteLen*8 {
+		return nil, &ParseError{Input: s, Err: ErrOverflow}
+	}
+
+	ip := make(net.IP, byteLen)
+	copy(ip, x.Bytes()[16-byteLen:])
+
+	return ip, nil
+}
@@ -0,0 +1,142 @@
+package iputil
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		s   string
+		af  uint
+		ip  net.IP
+		pos int
+		err error
+	}{
+		{"", 0, nil, 0, ErrEmpty},
+		{"192.168.0.1", 0, ParseIPv4("192.168.0.1"), 0, nil},
+		{"192.168.0.1", IPv4, ParseIPv4("192.168.0.1"), 0, nil},
+		{"192.168.0.1", IPv6, nil, 0, ErrFamilyMismatch},
+		{"192.168.00.1", 0, nil, 8, ErrLeadingZero},
+		{"192.168.0.256", 0, nil, 10, ErrOverflow},
+		{"192.168.0", 0, nil, 9, ErrUnexpectedEnd},
+		{"192.168.0.", 0, nil, 10, ErrUnexpectedEnd},
+		{"192.168.0.1.", 0, nil, 11, ErrUnexpectedToken},
+		{"192.168.0.x", 0, nil, 10, ErrUnexpectedToken},
+		{"192.168.0.1%eth0", 0, nil, 11, ErrZoneNotAllowed},
+		{"1921680001", 0, nil, 10, ErrUnexpectedEnd},
+		{"2001:db8::1", 0, ParseIPv6("2001:db8::1"), 0, nil},
+		{"2001:db8::1", IPv4, nil, 0, ErrFamilyMismatch},
+		{"2001:0db8:0000:0000:0000:0000:0000:0001", IPv6, ParseIPv6("2001:db8::1"), 0, nil},
+		{"::", 0, ParseIPv6("::"), 0, nil},
+		{"::1", 0, ParseIPv6("::1"), 0, nil},
+		{"fe80::", 0, ParseIPv6("fe80::"), 0, nil},
+		{"1:2:3:4:5:6:7::", 0, ParseIPv6("1:2:3:4:5:6:7:0"), 0, nil},
+		{"::ffff:192.168.0.1", 0, ParseIPv6("::ffff:192.168.0.1"), 0, nil},
+		{"64:ff9b::192.0.2.33", 0, ParseIPv6("64:ff9b::c000:221"), 0, nil},
+		{"1:2:3:4:5:6:192.168.0.1", 0, ParseIPv6("1:2:3:4:5:6:c0a8:1"), 0, nil},
+		{"1:2:3:4:5:192.168.0.1", 0, nil, 13, ErrUnexpectedToken},
+		{"::ffff:192.168.01.1", 0, nil, 15, ErrLeadingZero},
+		{"2001:db8::1%eth0", 0, nil, 11, ErrZoneNotAllowed},
+		{"2001:db8:::1", 0, nil, 10, ErrUnexpectedToken},
+		{"2001:db8::1::1", 0, nil, 11, ErrUnexpectedToken},
+		{"2001:db8:12345::1", 0, nil, 9, ErrOverflow},
+		{"2001:db8:1:2:3:4:5", 0, nil, 18, ErrUnexpectedEnd},
+		{"2001:db8:1:2:3:4:5:6::", 0, nil, 22, ErrOverflow},
+		{"2001:db8:", 0, nil, 9, ErrUnexpectedEnd},
+		{"2001:db8:g::1", 0, nil, 9, ErrUnexpectedToken},
+		{":1::", 0, nil, 0, ErrUnexpectedToken},
+	}
+
+	for _, c := range cases {
+		ip, err := Parse(c.s, c.af)
+		if !errors.Is(err, c.err) {
+			t.Errorf("unexpected error for %q: got %v, want %v", c.s, err, c.err)
+		}
+		if c.err != nil {
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Errorf("unexpected error type for %q: %T", c.s, err)
+			} else if perr.Pos != c.pos {
+				t.Errorf("unexpected error position for %q: got %d, want %d", c.s, perr.Pos, c.pos)
+			}
+		}
+		if !ip.Equal(c.ip) || ip != nil && len(ip) != len(c.ip) {
+			t.Errorf("unexpected result for %q: got %v, want %v", c.s, ip, c.ip)
+		}
+	}
+}
+
+func TestParseInt(t *testing.T) {
+	cases := []struct {
+		s   string
+		af  uint
+		ip  net.IP
+		err error
+	}{
+		{"", IPv4, nil, ErrEmpty},
+		{"0", 0, nil, ErrFamilyMismatch},
+		{"3232235521", IPv4, ParseIPv4("192.168.0.1"), nil},
+		{"4294967296", IPv4, nil, ErrOverflow},
+		{"42540766411282592856903984951653826561", IPv6, ParseIPv6("2001:db8::1"), nil},
+		{"340282366920938463463374607431768211456", IPv6, nil, ErrOverflow},
+		{"12a", IPv4, nil, ErrUnexpectedToken},
+	}
+
+	for _, c := range cases {
+		ip, err := ParseInt(c.s, c.af)
+		if !errors.Is(err, c.err) {
+			t.Errorf("unexpected error for %q: got %v, want %v", c.s, err, c.err)
+		}
+		if !ip.Equal(c.ip) {
+			t.Errorf("unexpected result for %q: got %v, want %v", c.s, ip, c.ip)
+		}
+	}
+}
+
+func TestParseArrayAllocs(t *testing.T) {
+	for _, s := range []string{"192.168.0.1", "2001:db8::1", "::ffff:192.168.0.1"} {
+		allocs := testing.AllocsPerRun(100, func() {
+			if _, _, err := ParseArray(s, 0); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("unexpected allocations for %q: %v", s, allocs)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{"192.168.0.1", "2001:db8::1", "::ffff:1.2.3.4", "1:2:3:4:5:6:7::", "fe80::1%eth0", "01.2.3.4", "1::2::3"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		ip, err := Parse(s, 0)
+
+		std := net.ParseIP(s)
+		if (err == nil) != (std != nil) {
+			t.Fatalf("net.ParseIP disagrees on %q: got %v, %v; want %v", s, ip, err, std)
+		}
+		if err == nil && !ip.Equal(std) {
+			t.Fatalf("net.ParseIP disagrees on %q: got %v, want %v", s, ip, std)
+		}
+
+		addr, nerr := netip.ParseAddr(s)
+		if nerr == nil && addr.Zone() != "" {
+			if !errors.Is(err, ErrZoneNotAllowed) {
+				t.Fatalf("unexpected error for zoned %q: %v", s, err)
+			}
+			return
+		}
+		if (err == nil) != (nerr == nil) {
+			t.Fatalf("netip.ParseAddr disagrees on %q: got %v, %v; want %v, %v", s, ip, err, addr, nerr)
+		}
+		if err == nil && !net.IP(addr.AsSlice()).Equal(ip) {
+			t.Fatalf("netip.ParseAddr disagrees on %q: got %v, want %v", s, ip, addr)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+// Package prefixlist renders prefix filters, such as those generated from
+// IRR data, in the syntax of routers and routing daemons.
+package prefixlist
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/ericyan/iputil"
+	"github.com/ericyan/iputil/uint128"
+)
+
+// An Entry matches the routes within Prefix whose prefix lengths are
+// between Min and Max, inclusive. An entry for Prefix alone has both
+// Min and Max equal to the length of Prefix.
+type Entry struct {
+	Prefix iputil.Prefix
+	Min    int
+	Max    int
+}
+
+// validate returns an error if e is not a valid entry.
+func (e Entry) validate() error {
+	if !e.Prefix.IsValid() {
+		return errors.New("prefixlist: invalid prefix")
+	}
+	if e.Min < e.Prefix.Bits() || e.Max < e.Min || e.Max > e.Prefix.Addr().BitLen() {
+		return errors.New("prefixlist: invalid prefix lengths for " + e.Prefix.String())
+	}
+
+	return nil
+}
+
+// String returns the prefix of e followed by its prefix lengths, written
+// with ge and le as Cisco does: "le N" for lengths from that of the
+// prefix, "ge N" for lengths up to the address length, and both
+// otherwise.
+func (e Entry) String() string {
+	s := e.Prefix.String()
+
+	bits, maxLen := e.Prefix.Bits(), e.Prefix.Addr().BitLen()
+	switch {
+	case e.Min == bits && e.Max == bits:
+	case e.Min == bits:
+		s += " le " + strconv.Itoa(e.Max)
+	case e.Max == maxLen:
+		s += " ge " + strconv.Itoa(e.Min)
+	default:
+		s += " ge " + strconv.Itoa(e.Min) + " le " + strconv.Itoa(e.Max)
+	}
+
+	return s
+}
+
+// compare orders entries by their prefixes as defined by
+// iputil.Prefix.Compare, then by their lengths.
+func (e Entry) compare(f Entry) int {
+	if c := e.Prefix.Compare(f.Prefix); c != 0 {
+		return c
+	}
+
+	switch {
+	case e.Min != f.Min:
+		return e.Min - f.Min
+	default:
+		return e.Max - f.Max
+	}
+}
+
+// sortEntries returns the entries of set in order.
+func sortEntries(set map[Entry]bool) []Entry {
+	entries := make([]Entry, 0, len(set))
+	for e := range set {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].compare(entries[j]) < 0 })
+
+	return entries
+}
+
+// Exact returns an entry for each valid prefix, matching the prefix
+// alone, in order and without duplicates.
+func Exact(prefixes []iputil.Prefix) []Entry {
+	set := make(map[Entry]bool)
+	for _, p := range prefixes {
+		if p.IsValid() {
+			set[Entry{p, p.Bits(), p.Bits()}] = true
+		}
+	}
+
+	return sortEntries(set)
+}
+
+// Aggregate returns entries matching exactly the valid prefixes, using
+// as few entries as it can by collapsing prefixes into ranges of prefix
+// lengths. Sibling prefixes of the same length are collapsed into their
+// parent, and a prefix and its subprefixes of the next lengths are
+// collapsed into a single entry, such as "192.0.2.0/24 le 25" for
+// 192.0.2.0/24, 192.0.2.0/25 and 192.0.2.128/25.
+func Aggregate(prefixes []iputil.Prefix) []Entry {
+	set := make(map[Entry]bool)
+	for _, e := range Exact(prefixes) {
+		set[e] = true
+	}
+
+	// The entries match disjoint sets of routes, so merging them never
+	// matches anything more.
+	for changed := true; changed; {
+		changed = false
+
+		for _, e := range sortEntries(set) {
+			if !set[e] {
+				// Merged into another entry already.
+				continue
+			}
+
+			// Merge with the entry for the same prefix and the next
+			// lengths.
+			for hi := e.Prefix.Addr().BitLen(); hi > e.Max; hi-- {
+				next := Entry{e.Prefix, e.Max + 1, hi}
+				if set[next] {
+					delete(set, e)
+					delete(set, next)
+					e = Entry{e.Prefix, e.Min, hi}
+					set[e] = true
+					changed = true
+					break
+				}
+			}
+
+			// Merge with the sibling entry into the parent.
+			if bits := e.Prefix.Bits(); bits > 0 {
+				sibling := Entry{siblingOf(e.Prefix), e.Min, e.Max}
+				if set[sibling] {
+					parent, _ := iputil.NewPrefix(e.Prefix.Addr(), bits-1)
+					delete(set, e)
+					delete(set, sibling)
+					set[Entry{parent, e.Min, e.Max}] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	return sortEntries(set)
+}
+
+// siblingOf returns the prefix of the same length that shares the parent
+// of p. The length of p must not be zero.
+func siblingOf(p iputil.Prefix) iputil.Prefix {
+	addr := p.Addr()
+	bit := uint128.One.Lsh(uint(addr.BitLen() - p.Bits()))
+
+	sibling, _ := iputil.AddrFromInt(addr.Int().Xor(bit), addr.Family())
+	q, _ := iputil.NewPrefix(sibling, p.Bits())
+
+	return q
+}
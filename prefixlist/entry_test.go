@@ -0,0 +1,106 @@
+package prefixlist
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ericyan/iputil"
+)
+
+func testPrefixes(t *testing.T, in ...string) []iputil.Prefix {
+	t.Helper()
+
+	var prefixes []iputil.Prefix
+	for _, s := range in {
+		p, err := iputil.ParsePrefix(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prefixes = append(prefixes, p)
+	}
+
+	return prefixes
+}
+
+func TestEntryString(t *testing.T) {
+	p := testPrefixes(t, "192.0.2.0/24", "2001:db8::/32")
+
+	cases := []struct {
+		in  Entry
+		out string
+	}{
+		{Entry{p[0], 24, 24}, "192.0.2.0/24"},
+		{Entry{p[0], 24, 26}, "192.0.2.0/24 le 26"},
+		{Entry{p[0], 25, 32}, "192.0.2.0/24 ge 25"},
+		{Entry{p[0], 25, 25}, "192.0.2.0/24 ge 25 le 25"},
+		{Entry{p[1], 32, 128}, "2001:db8::/32 le 128"},
+		{Entry{p[1], 48, 64}, "2001:db8::/32 ge 48 le 64"},
+	}
+
+	for _, c := range cases {
+		if got := c.in.String(); got != c.out {
+			t.Errorf("unexpected string: got %s, want %s", got, c.out)
+		}
+	}
+}
+
+func TestExact(t *testing.T) {
+	in := testPrefixes(t, "2001:db8::/32", "192.0.2.0/24", "10.0.0.0/8", "192.0.2.0/24")
+	in = append(in, iputil.Prefix{})
+
+	var got []string
+	for _, e := range Exact(in) {
+		got = append(got, e.String())
+	}
+
+	want := []string{"10.0.0.0/8", "192.0.2.0/24", "2001:db8::/32"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("unexpected entries: got %v, want %v", got, want)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	cases := []struct {
+		in  []string
+		out []string
+	}{
+		{nil, nil},
+		{[]string{"192.0.2.0/24"}, []string{"192.0.2.0/24"}},
+		{
+			[]string{"192.0.2.0/24", "192.0.2.0/25", "192.0.2.128/25"},
+			[]string{"192.0.2.0/24 le 25"},
+		},
+		{
+			[]string{"192.0.2.0/25", "192.0.2.128/25"},
+			[]string{"192.0.2.0/24 ge 25 le 25"},
+		},
+		{
+			// A missing subprefix leaves the rest as they are.
+			[]string{"192.0.2.0/24", "192.0.2.0/25", "192.0.2.128/26"},
+			[]string{"192.0.2.0/24", "192.0.2.0/25", "192.0.2.128/26"},
+		},
+		{
+			[]string{
+				"198.51.100.0/22",
+				"198.51.100.0/23", "198.51.102.0/23",
+				"198.51.100.0/24", "198.51.101.0/24", "198.51.102.0/24", "198.51.103.0/24",
+				"2001:db8::/32", "2001:db8:8000::/33",
+			},
+			[]string{"198.51.100.0/22 le 24", "2001:db8::/32", "2001:db8:8000::/33"},
+		},
+		{
+			[]string{"0.0.0.0/1", "128.0.0.0/1", "0.0.0.0/0"},
+			[]string{"0.0.0.0/0 le 1"},
+		},
+	}
+
+	for _, c := range cases {
+		var got []string
+		for _, e := range Aggregate(testPrefixes(t, c.in...)) {
+			got = append(got, e.String())
+		}
+		if fmt.Sprint(got) != fmt.Sprint(c.out) {
+			t.Errorf("unexpected aggregation of %v: got %v, want %v", c.in, got, c.out)
+		}
+	}
+}
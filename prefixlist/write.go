@@ -0,0 +1,175 @@
+package prefixlist
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/ericyan/iputil"
+)
+
+// A Syntax specifies the configuration language of a prefix filter.
+type Syntax int
+
+// Syntaxes supported by Write.
+const (
+	// Cisco is a Cisco IOS prefix-list, as "ip prefix-list" commands for
+	// IPv4 and "ipv6 prefix-list" commands for IPv6.
+	Cisco Syntax = iota
+
+	// CiscoXR is a Cisco IOS-XR prefix-set.
+	CiscoXR
+
+	// JuniperRouteFilter is a Junos route-filter-list stanza, which goes
+	// under policy-options.
+	JuniperRouteFilter
+
+	// JuniperPrefixList is a Junos prefix-list stanza, which goes under
+	// policy-options. It only matches prefixes exactly.
+	JuniperPrefixList
+
+	// BIRD is a BIRD prefix set constant. It holds prefixes of one
+	// address family.
+	BIRD
+
+	// FRR is an FRRouting prefix-list, which has the same syntax as a
+	// Cisco IOS prefix-list.
+	FRR
+)
+
+// Write writes a prefix filter of the given name, matching the entries,
+// in the given syntax. Entries are written in the order given.
+func Write(w io.Writer, syntax Syntax, name string, entries []Entry) error {
+	for _, e := range entries {
+		if err := e.validate(); err != nil {
+			return err
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	switch syntax {
+	case Cisco, FRR:
+		writeCisco(bw, name, entries)
+	case CiscoXR:
+		writeCiscoXR(bw, name, entries)
+	case JuniperRouteFilter:
+		writeJuniper(bw, "route-filter-list", name, entries, routeFilter)
+	case JuniperPrefixList:
+		for _, e := range entries {
+			if e.Min != e.Prefix.Bits() || e.Max != e.Prefix.Bits() {
+				return errors.New("prefixlist: juniper prefix-list cannot match prefix lengths of " + e.String())
+			}
+		}
+		writeJuniper(bw, "prefix-list", name, entries, Entry.String)
+	case BIRD:
+		if err := writeBIRD(bw, name, entries); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("prefixlist: unsupported syntax %d", syntax)
+	}
+
+	return bw.Flush()
+}
+
+func writeCisco(w io.Writer, name string, entries []Entry) {
+	// IPv4 and IPv6 prefix-lists are separate, with their own sequence
+	// numbers.
+	for _, af := range []uint{iputil.IPv4, iputil.IPv6} {
+		cmd := "ip prefix-list"
+		if af == iputil.IPv6 {
+			cmd = "ipv6 prefix-list"
+		}
+
+		seq := 0
+		for _, e := range entries {
+			if e.Prefix.Addr().Family() == af {
+				seq += 5
+				fmt.Fprintf(w, "%s %s seq %d permit %s\n", cmd, name, seq, e)
+			}
+		}
+	}
+}
+
+func writeCiscoXR(w io.Writer, name string, entries []Entry) {
+	fmt.Fprintf(w, "prefix-set %s\n", name)
+	for i, e := range entries {
+		sep := ","
+		if i == len(entries)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(w, "  %s%s\n", e, sep)
+	}
+	fmt.Fprintln(w, "end-set")
+}
+
+func writeJuniper(w io.Writer, stanza, name string, entries []Entry, format func(Entry) string) {
+	fmt.Fprintf(w, "%s %s {\n", stanza, name)
+	for _, e := range entries {
+		fmt.Fprintf(w, "    %s;\n", format(e))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// routeFilter returns e in the syntax of a Junos route filter, which
+// is the prefix followed by a match type.
+func routeFilter(e Entry) string {
+	bits, maxLen := e.Prefix.Bits(), e.Prefix.Addr().BitLen()
+
+	var match string
+	switch {
+	case e.Min == bits && e.Max == bits:
+		match = "exact"
+	case e.Min == bits && e.Max == maxLen:
+		match = "orlonger"
+	case e.Min == bits+1 && e.Max == maxLen:
+		match = "longer"
+	case e.Min == bits:
+		match = "upto /" + strconv.Itoa(e.Max)
+	default:
+		match = "prefix-length-range /" + strconv.Itoa(e.Min) + "-/" + strconv.Itoa(e.Max)
+	}
+
+	return e.Prefix.String() + " " + match
+}
+
+func writeBIRD(w io.Writer, name string, entries []Entry) error {
+	if len(entries) == 0 {
+		return errors.New("prefixlist: empty bird prefix set")
+	}
+	for _, e := range entries {
+		if e.Prefix.Addr().Family() != entries[0].Prefix.Addr().Family() {
+			return errors.New("prefixlist: bird prefix set with both IPv4 and IPv6 prefixes")
+		}
+	}
+
+	fmt.Fprintf(w, "define %s = [", name)
+	for i, e := range entries {
+		sep := ","
+		if i == len(entries)-1 {
+			sep = "\n"
+		}
+		fmt.Fprintf(w, "\n\t%s%s", birdPattern(e), sep)
+	}
+	fmt.Fprintln(w, "];")
+
+	return nil
+}
+
+// birdPattern returns e as a pattern of a BIRD prefix set: the prefix
+// alone, the prefix followed by "+" for it and all its subprefixes, or
+// the prefix followed by the range of lengths in braces.
+func birdPattern(e Entry) string {
+	bits, maxLen := e.Prefix.Bits(), e.Prefix.Addr().BitLen()
+
+	switch {
+	case e.Min == bits && e.Max == bits:
+		return e.Prefix.String()
+	case e.Min == bits && e.Max == maxLen:
+		return e.Prefix.String() + "+"
+	default:
+		return fmt.Sprintf("%s{%d,%d}", e.Prefix, e.Min, e.Max)
+	}
+}
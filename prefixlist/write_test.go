@@ -0,0 +1,112 @@
+package prefixlist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	p := testPrefixes(t, "192.0.2.0/24", "198.51.100.0/22", "2001:db8::/32")
+	entries := []Entry{
+		{p[0], 24, 24},
+		{p[1], 22, 24},
+		{p[2], 32, 128},
+	}
+
+	cases := []struct {
+		syntax  Syntax
+		entries []Entry
+		out     string
+	}{
+		{
+			Cisco,
+			entries,
+			"ip prefix-list AS-EXAMPLE seq 5 permit 192.0.2.0/24\n" +
+				"ip prefix-list AS-EXAMPLE seq 10 permit 198.51.100.0/22 le 24\n" +
+				"ipv6 prefix-list AS-EXAMPLE seq 5 permit 2001:db8::/32 le 128\n",
+		},
+		{
+			FRR,
+			entries[2:],
+			"ipv6 prefix-list AS-EXAMPLE seq 5 permit 2001:db8::/32 le 128\n",
+		},
+		{
+			CiscoXR,
+			entries,
+			"prefix-set AS-EXAMPLE\n" +
+				"  192.0.2.0/24,\n" +
+				"  198.51.100.0/22 le 24,\n" +
+				"  2001:db8::/32 le 128\n" +
+				"end-set\n",
+		},
+		{CiscoXR, nil, "prefix-set AS-EXAMPLE\nend-set\n"},
+		{
+			JuniperRouteFilter,
+			[]Entry{
+				{p[0], 24, 24},
+				{p[0], 24, 32},
+				{p[0], 25, 32},
+				{p[1], 22, 24},
+				{p[2], 48, 64},
+			},
+			"route-filter-list AS-EXAMPLE {\n" +
+				"    192.0.2.0/24 exact;\n" +
+				"    192.0.2.0/24 orlonger;\n" +
+				"    192.0.2.0/24 longer;\n" +
+				"    198.51.100.0/22 upto /24;\n" +
+				"    2001:db8::/32 prefix-length-range /48-/64;\n" +
+				"}\n",
+		},
+		{
+			JuniperPrefixList,
+			[]Entry{{p[0], 24, 24}, {p[2], 32, 32}},
+			"prefix-list AS-EXAMPLE {\n" +
+				"    192.0.2.0/24;\n" +
+				"    2001:db8::/32;\n" +
+				"}\n",
+		},
+		{
+			BIRD,
+			[]Entry{{p[0], 24, 24}, {p[0], 25, 32}, {p[1], 22, 32}, {p[1], 22, 24}},
+			"define AS-EXAMPLE = [\n" +
+				"\t192.0.2.0/24,\n" +
+				"\t192.0.2.0/24{25,32},\n" +
+				"\t198.51.100.0/22+,\n" +
+				"\t198.51.100.0/22{22,24}\n" +
+				"];\n",
+		},
+	}
+
+	for _, c := range cases {
+		var b strings.Builder
+		if err := Write(&b, c.syntax, "AS-EXAMPLE", c.entries); err != nil {
+			t.Errorf("unexpected error for syntax %d: %s", c.syntax, err)
+			continue
+		}
+
+		if b.String() != c.out {
+			t.Errorf("unexpected output for syntax %d: got %q, want %q", c.syntax, b.String(), c.out)
+		}
+	}
+
+	failures := []struct {
+		syntax  Syntax
+		entries []Entry
+	}{
+		{Cisco, []Entry{{p[0], 23, 24}}},
+		{Cisco, []Entry{{p[0], 25, 24}}},
+		{Cisco, []Entry{{p[0], 24, 33}}},
+		{Cisco, []Entry{{}}},
+		{JuniperPrefixList, []Entry{{p[0], 24, 25}}},
+		{BIRD, entries},
+		{BIRD, nil},
+		{Syntax(-1), nil},
+	}
+
+	for _, c := range failures {
+		var b strings.Builder
+		if err := Write(&b, c.syntax, "AS-EXAMPLE", c.entries); err == nil {
+			t.Errorf("expected error for syntax %d: %v", c.syntax, c.entries)
+		}
+	}
+}
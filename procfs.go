@@ -0,0 +1,435 @@
+package iputil
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A ProcfsSource is an InterfaceSource that reads the sysfs and procfs
+// file systems of Linux, which provide the same information as
+// "ip addr". As it only reads files under Root, it can be pointed at a
+// copy of these files to examine another host, or fixtures in tests. As
+// proc/net/route is written in the byte order of the host, ByteOrder
+// must be set if that host's differs from this one's.
+//
+// Interfaces are read from sys/class/net, and IPv6 addresses from
+// proc/net/if_inet6. As procfs does not list IPv4 addresses directly,
+// they are derived from the local routes in proc/net/fib_trie, and
+// attributed to interfaces by the connected routes in proc/net/route.
+// IPv4 addresses without a connected route, other than loopback
+// addresses, are therefore omitted. Address lifetimes are not available
+// in these files, so they are always zero.
+type ProcfsSource struct {
+	// Root is the directory containing sys and proc. If it is empty,
+	// "/" is used.
+	Root string
+
+	// ByteOrder is the byte order of the host the files are from. If it
+	// is nil, binary.NativeEndian is used.
+	ByteOrder binary.ByteOrder
+}
+
+// Linux interface flags, from linux/if.h.
+const (
+	iffUp           = 0x1
+	iffBroadcast    = 0x2
+	iffLoopback     = 0x8
+	iffPointToPoint = 0x10
+	iffRunning      = 0x40
+	iffMulticast    = 0x1000
+)
+
+// procfsInterface is a network interface read from sysfs.
+type procfsInterface struct {
+	name  string
+	index int
+	flags net.Flags
+	mtu   int
+	mac   net.HardwareAddr
+}
+
+// ifInet6Entry is an IPv6 interface address read from if_inet6.
+type ifInet6Entry struct {
+	addr  Addr
+	index int
+	bits  int
+	flags AddrFlags
+	name  string
+}
+
+// fibEntry is a route read from fib_trie.
+type fibEntry struct {
+	prefix Prefix
+	scope  string
+	typ    string
+}
+
+// path returns the path of name under s.Root.
+func (s ProcfsSource) path(name ...string) string {
+	root := s.Root
+	if root == "" {
+		root = "/"
+	}
+
+	return filepath.Join(append([]string{root}, name...)...)
+}
+
+// readFile returns the content of the file name under s.Root, without
+// the trailing newline.
+func (s ProcfsSource) readFile(name ...string) (string, error) {
+	b, err := os.ReadFile(s.path(name...))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+// InterfaceAddrs implements InterfaceSource. Addresses are ordered by
+// interface index, with IPv4 addresses before IPv6 addresses.
+func (s ProcfsSource) InterfaceAddrs() ([]*InterfaceAddr, error) {
+	ifaces, err := s.interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*procfsInterface, len(ifaces))
+	for _, iface := range ifaces {
+		byName[iface.name] = iface
+	}
+
+	addrs4, err := s.ipv4Addrs(ifaces)
+	if err != nil {
+		return nil, err
+	}
+	addrs6, err := s.ipv6Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	var ifAddrs []*InterfaceAddr
+	for _, addr := range append(addrs4, addrs6...) {
+		iface, ok := byName[addr.InterfaceName]
+		if !ok {
+			continue
+		}
+
+		addr.Flags = iface.flags
+		addr.InterfaceIndex = iface.index
+		addr.MTU = iface.mtu
+		addr.HardwareAddr = iface.mac
+		ifAddrs = append(ifAddrs, addr)
+	}
+
+	sort.SliceStable(ifAddrs, func(i, j int) bool {
+		a, b := byName[ifAddrs[i].InterfaceName], byName[ifAddrs[j].InterfaceName]
+		if a.index != b.index {
+			return a.index < b.index
+		}
+		return ifAddrs[i].Family() < ifAddrs[j].Family()
+	})
+
+	return ifAddrs, nil
+}
+
+// interfaces reads the network interfaces from sys/class/net.
+func (s ProcfsSource) interfaces() ([]*procfsInterface, error) {
+	entries, err := os.ReadDir(s.path("sys", "class", "net"))
+	if err != nil {
+		return nil, err
+	}
+
+	var ifaces []*procfsInterface
+	for _, e := range entries {
+		name := e.Name()
+
+		// Interfaces are symbolic links to directories. Other entries,
+		// such as the bonding_masters file of the bonding driver, are
+		// skipped.
+		if fi, err := os.Stat(s.path("sys", "class", "net", name)); err != nil || !fi.IsDir() {
+			continue
+		}
+		v, err := s.readFile("sys", "class", "net", name, "ifindex")
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		index, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, errors.New("invalid ifindex for " + name + ": " + v)
+		}
+
+		v, err = s.readFile("sys", "class", "net", name, "flags")
+		if err != nil {
+			return nil, err
+		}
+		flags, err := strconv.ParseUint(v, 0, 32)
+		if err != nil {
+			return nil, errors.New("invalid flags for " + name + ": " + v)
+		}
+
+		v, err = s.readFile("sys", "class", "net", name, "mtu")
+		if err != nil {
+			return nil, err
+		}
+		mtu, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, errors.New("invalid mtu for " + name + ": " + v)
+		}
+
+		// Interfaces without a hardware address, such as tunnels, have an
+		// empty address file. The loopback interface has an all-zero
+		// address, which is omitted as the net package does.
+		v, err = s.readFile("sys", "class", "net", name, "address")
+		if err != nil {
+			return nil, err
+		}
+		var mac net.HardwareAddr
+		if v != "" {
+			if mac, err = net.ParseMAC(v); err != nil {
+				return nil, errors.New("invalid address for " + name + ": " + v)
+			}
+			if isZero(mac) {
+				mac = nil
+			}
+		}
+
+		ifaces = append(ifaces, &procfsInterface{name, index, linuxFlags(flags), mtu, mac})
+	}
+
+	return ifaces, nil
+}
+
+// linuxFlags converts Linux interface flags to net.Flags.
+func linuxFlags(v uint64) net.Flags {
+	var flags net.Flags
+	if v&iffUp != 0 {
+		flags |= net.FlagUp
+	}
+	if v&iffBroadcast != 0 {
+		flags |= net.FlagBroadcast
+	}
+	if v&iffLoopback != 0 {
+		flags |= net.FlagLoopback
+	}
+	if v&iffPointToPoint != 0 {
+		flags |= net.FlagPointToPoint
+	}
+	if v&iffMulticast != 0 {
+		flags |= net.FlagMulticast
+	}
+	if v&iffRunning != 0 {
+		flags |= net.FlagRunning
+	}
+
+	return flags
+}
+
+// ipv4Addrs derives the IPv4 interface addresses from the local routes
+// in proc/net/fib_trie and the connected routes in proc/net/route.
+func (s ProcfsSource) ipv4Addrs(ifaces []*procfsInterface) ([]*InterfaceAddr, error) {
+	f, err := os.Open(s.path("proc", "net", "fib_trie"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fib, err := parseFibTrie(f)
+	if err != nil {
+		return nil, err
+	}
+
+	order := s.ByteOrder
+	if order == nil {
+		order = binary.NativeEndian
+	}
+	routes, err := ReadRoutes(s.path("proc", "net"), order)
+	if err != nil {
+		return nil, err
+	}
+
+	var loopback string
+	for _, iface := range ifaces {
+		if iface.flags&net.FlagLoopback != 0 {
+			loopback = iface.name
+			break
+		}
+	}
+
+	var addrs []*InterfaceAddr
+	seen := make(map[Addr]bool)
+	for _, e := range fib {
+		// Each local address has a host route of type LOCAL.
+		addr := e.prefix.Addr()
+		if e.typ != "LOCAL" || e.prefix.Bits() != IPv4BitLen || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+
+		// The prefix length of the address is that of the longest
+		// subnet route containing it, which is of type UNICAST for
+		// connected subnets or LOCAL for local ranges such as
+		// 127.0.0.0/8.
+		bits := 0
+		for _, r := range fib {
+			if (r.typ == "UNICAST" || r.typ == "LOCAL") && r.prefix.Bits() > bits &&
+				r.prefix.Bits() < IPv4BitLen && r.prefix.Contains(addr) {
+				bits = r.prefix.Bits()
+			}
+		}
+		if bits == 0 {
+			bits = IPv4BitLen
+		}
+
+		var name string
+		var connected *Route
+		for _, r := range routes {
+			if !r.Gateway.IsValid() && r.Dst.Contains(addr) && (connected == nil || r.better(connected)) {
+				connected = r
+			}
+		}
+		switch {
+		case connected != nil:
+			name = connected.InterfaceName
+		case addr.IP().IsLoopback() && loopback != "":
+			name = loopback
+		default:
+			continue
+		}
+
+		addrs = append(addrs, &InterfaceAddr{
+			IPNet:         &net.IPNet{IP: addr.IP(), Mask: net.CIDRMask(bits, IPv4BitLen)},
+			InterfaceName: name,
+		})
+	}
+
+	return addrs, nil
+}
+
+// parseFibTrie parses the format of /proc/net/fib_trie, returning the
+// routes listed under its leaf nodes. A leaf node is a line starting
+// with "|--" followed by an address, and is followed by a line for each
+// of its routes, with the prefix length, scope and type of the route.
+func parseFibTrie(r io.Reader) ([]fibEntry, error) {
+	var entries []fibEntry
+	var leaf Addr
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		switch {
+		case len(fields) == 2 && fields[0] == "|--":
+			ip := ParseIPv4(fields[1])
+			if ip == nil {
+				return nil, errors.New("invalid fib_trie address: " + fields[1])
+			}
+			leaf = AddrFromIP(ip)
+		case len(fields) >= 3 && strings.HasPrefix(fields[0], "/"):
+			bits, err := strconv.Atoi(fields[0][1:])
+			if err != nil || !leaf.IsValid() {
+				return nil, errors.New("invalid fib_trie route: " + s.Text())
+			}
+			prefix, err := NewPrefix(leaf, bits)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, fibEntry{prefix, fields[1], fields[2]})
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// ipv6Addrs reads the IPv6 interface addresses from proc/net/if_inet6.
+// A missing file, as on systems with IPv6 disabled, is ignored.
+func (s ProcfsSource) ipv6Addrs() ([]*InterfaceAddr, error) {
+	f, err := os.Open(s.path("proc", "net", "if_inet6"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := parseIfInet6(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var addrs []*InterfaceAddr
+	for _, e := range entries {
+		addrs = append(addrs, &InterfaceAddr{
+			IPNet:         &net.IPNet{IP: e.addr.IP(), Mask: net.CIDRMask(e.bits, IPv6BitLen)},
+			InterfaceName: e.name,
+			AddrFlags:     e.flags,
+		})
+	}
+
+	return addrs, nil
+}
+
+// parseIfInet6 parses the format of /proc/net/if_inet6. Each line has
+// six fields: the address in 32 hex digits, the interface index, prefix
+// length, scope and address flags in hex, and the interface name.
+func parseIfInet6(r io.Reader) ([]ifInet6Entry, error) {
+	var entries []ifInet6Entry
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 6 {
+			return nil, errors.New("invalid if_inet6 line: " + s.Text())
+		}
+
+		var b [16]byte
+		if len(fields[0]) != 2*len(b) {
+			return nil, errors.New("invalid if_inet6 address: " + fields[0])
+		}
+		if _, err := hex.Decode(b[:], []byte(fields[0])); err != nil {
+			return nil, errors.New("invalid if_inet6 address: " + fields[0])
+		}
+
+		var v [3]uint64
+		for i, field := range []string{fields[1], fields[2], fields[4]} {
+			var err error
+			if v[i], err = strconv.ParseUint(field, 16, 32); err != nil {
+				return nil, errors.New("invalid if_inet6 line: " + s.Text())
+			}
+		}
+		if v[1] > IPv6BitLen {
+			return nil, errors.New("invalid if_inet6 prefix length: " + fields[2])
+		}
+
+		entries = append(entries, ifInet6Entry{
+			addr:  AddrFrom16(b),
+			index: int(v[0]),
+			bits:  int(v[1]),
+			flags: AddrFlags(v[2]),
+			name:  fields[5],
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
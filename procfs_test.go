@@ -0,0 +1,184 @@
+package iputil
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProcfsSource(t *testing.T) {
+	addrs, err := ProcfsSource{Root: "testdata/host", ByteOrder: binary.LittleEndian}.InterfaceAddrs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out []string
+	for _, addr := range addrs {
+		out = append(out, addr.InterfaceName+" "+addr.IPNet.String())
+	}
+	want := []string{
+		"lo 127.0.0.1/8",
+		"lo ::1/128",
+		"eth0 192.168.1.10/24",
+		"eth0 2001:db8::10/64",
+		"eth0 2001:db8::1122:3344:aabb:ccdd/64",
+		"eth0 fe80::250:56ff:fe8a:1234/64",
+		"docker0 172.17.0.1/16",
+		"docker0 fe80::1/64",
+		"tun0 10.8.0.2/24",
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Fatalf("unexpected addresses: got %v, want %v", out, want)
+	}
+
+	cases := []struct {
+		i         int
+		flags     net.Flags
+		addrFlags AddrFlags
+	}{
+		{0, net.FlagUp | net.FlagLoopback, 0},
+		{1, net.FlagUp | net.FlagLoopback, AddrPermanent},
+		{2, net.FlagUp | net.FlagBroadcast | net.FlagMulticast, 0},
+		{4, net.FlagUp | net.FlagBroadcast | net.FlagMulticast, AddrTemporary},
+		{7, net.FlagBroadcast | net.FlagMulticast, AddrTentative | AddrPermanent},
+		{8, net.FlagUp | net.FlagPointToPoint | net.FlagRunning | net.FlagMulticast, 0},
+	}
+
+	for _, c := range cases {
+		addr := addrs[c.i]
+		if addr.Flags != c.flags || addr.AddrFlags != c.addrFlags {
+			t.Errorf("unexpected flags for %s: got %s, %#x", addr.IPNet, addr.Flags, addr.AddrFlags)
+		}
+	}
+
+	ifaces := []struct {
+		i     int
+		index int
+		mtu   int
+		mac   string
+	}{
+		{0, 1, 65536, ""},
+		{2, 2, 1500, "00:50:56:8a:12:34"},
+		{6, 3, 1500, "02:42:ac:11:00:01"},
+		{8, 4, 1420, ""},
+	}
+
+	for _, c := range ifaces {
+		addr := addrs[c.i]
+		if addr.InterfaceIndex != c.index || addr.MTU != c.mtu || addr.HardwareAddr.String() != c.mac {
+			t.Errorf("unexpected interface of %s: got #%d, mtu %d, %s", addr.IPNet, addr.InterfaceIndex, addr.MTU, addr.HardwareAddr)
+		}
+	}
+
+	if _, err := (ProcfsSource{Root: "testdata/missing"}).InterfaceAddrs(); err == nil {
+		t.Error("error expected for missing root")
+	}
+}
+
+func TestProcfsSourceDefaults(t *testing.T) {
+	addrs, err := ProcfsSource{Root: "testdata/host", ByteOrder: binary.LittleEndian}.InterfaceAddrs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		af  uint
+		out string
+	}{
+		{IPv4, "192.168.1.10"},
+		{IPv6, "2001:db8::10"},
+	}
+
+	for _, c := range cases {
+		addr, err := SelectAddr(FilterAddrs(addrs, Family(c.af)), DefaultPolicy)
+		if err != nil || addr.IP.String() != c.out {
+			t.Errorf("unexpected default address for family %d: got %v, %v", c.af, addr, err)
+		}
+	}
+}
+
+func TestParseIfInet6(t *testing.T) {
+	data := `00000000000000000000000000000001 01 80 10 80       lo
+fe80000000000000025056fffe8a1234 02 40 20 80     eth0
+20010db80000000011223344aabbccdd 02 40 00 21     eth0
+`
+
+	entries, err := parseIfInet6(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		addr  string
+		index int
+		bits  int
+		flags AddrFlags
+		name  string
+	}{
+		{"::1", 1, 128, AddrPermanent, "lo"},
+		{"fe80::250:56ff:fe8a:1234", 2, 64, AddrPermanent, "eth0"},
+		{"2001:db8::1122:3344:aabb:ccdd", 2, 64, AddrTemporary | AddrDeprecated, "eth0"},
+	}
+
+	if len(entries) != len(cases) {
+		t.Fatalf("unexpected number of entries: got %d, want %d", len(entries), len(cases))
+	}
+	for i, c := range cases {
+		e := entries[i]
+		if e.addr.String() != c.addr || e.index != c.index || e.bits != c.bits || e.flags != c.flags || e.name != c.name {
+			t.Errorf("unexpected entry %d: got %s %d %d %#x %s", i, e.addr, e.index, e.bits, e.flags, e.name)
+		}
+	}
+
+	for _, line := range []string{
+		"0000000000000000000000000000000 01 80 10 80 lo",
+		"0000000000000000000000000000000g 01 80 10 80 lo",
+		"00000000000000000000000000000001 01 80 10 zz lo",
+		"00000000000000000000000000000001 01 81 10 80 lo",
+		"00000000000000000000000000000001 01 80 10 80",
+	} {
+		if _, err := parseIfInet6(strings.NewReader(line)); err == nil {
+			t.Errorf("error expected for %q", line)
+		}
+	}
+}
+
+func TestParseFibTrie(t *testing.T) {
+	data := `Main:
+  +-- 0.0.0.0/0 3 0 5
+     |-- 0.0.0.0
+        /0 universe UNICAST
+     +-- 192.0.2.0/24 2 0 2
+        |-- 192.0.2.0
+           /24 link UNICAST
+        |-- 192.0.2.2
+           /32 host LOCAL
+`
+
+	entries, err := parseFibTrie(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.prefix.String()+" "+e.scope+" "+e.typ)
+	}
+	want := []string{"0.0.0.0/0 universe UNICAST", "192.0.2.0/24 link UNICAST", "192.0.2.2/32 host LOCAL"}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("unexpected entries: got %v, want %v", out, want)
+	}
+
+	for _, data := range []string{
+		"|-- 192.0.2.256\n",
+		"/24 link UNICAST\n",
+		"|-- 192.0.2.0\n/x link UNICAST\n",
+		"|-- 192.0.2.0\n/33 link UNICAST\n",
+	} {
+		if _, err := parseFibTrie(strings.NewReader(data)); err == nil {
+			t.Errorf("error expected for %q", data)
+		}
+	}
+}
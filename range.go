@@ -4,7 +4,7 @@ import (
 	"errors"
 	"net"
 
-	"github.com/ericyan/iputil/internal/uint128"
+	"github.com/ericyan/iputil/uint128"
 )
 
 // A Range represents an arbitrary IP address range.
@@ -14,7 +14,8 @@ type Range struct {
 	last  uint128.Int
 }
 
-// NewRange returns a new Range.
+// NewRange returns a new Range. The range may consist of a single
+// address, in which case first and last are equal.
 func NewRange(first, last net.IP) (*Range, error) {
 	if AddressFamily(first) != AddressFamily(last) {
 		return nil, errors.New("invalid range")
@@ -23,7 +24,7 @@ func NewRange(first, last net.IP) (*Range, error) {
 
 	r.first, _ = uint128.NewFromBytes(first)
 	r.last, _ = uint128.NewFromBytes(last)
-	if !r.first.IsLessThan(r.last) {
+	if r.last.IsLessThan(r.first) {
 		return nil, errors.New("invalid range")
 	}
 
@@ -74,15 +75,19 @@ func (r *Range) CIDR() []*net.IPNet {
 	}
 
 	cur := r.first
-	for cur.IsLessThan(r.last) || cur.IsEqualTo(r.last) {
+	for {
 		// Number of zeros in netmask to represent current IP.
 		zeros := cur.TrailingZeros()
+		if zeros > maxPrefix {
+			zeros = maxPrefix
+		}
 
-		// Number of remaining IPs.
+		// Number of remaining IPs, which wraps around to zero if the
+		// range covers the entire IPv6 address space.
 		n := r.last.Sub(cur).Add(uint128.One)
 
-		// log2n is the max k that 2**k < n. This ensures cidrLen < n.
-		if log2n := 128 - n.LeadingZeros() - 1; zeros > log2n {
+		// log2n is the max k that 2**k <= n. This ensures cidrLen <= n.
+		if log2n := 128 - n.LeadingZeros() - 1; n != uint128.Zero && zeros > log2n {
 			zeros = log2n
 		}
 
@@ -91,11 +96,14 @@ func (r *Range) CIDR() []*net.IPNet {
 			Mask: net.CIDRMask(maxPrefix-zeros, maxPrefix),
 		})
 
-		cidrLen, _ := uint128.Pow2(uint(zeros))
-		cur = cur.Add(cidrLen)
+		// Stop at the end of the range, rather than after it, which may
+		// overflow.
+		end := cur.Or(uint128.Max.Rsh(uint(128 - zeros)))
+		if !end.IsLessThan(r.last) {
+			return results
+		}
+		cur = end.Add(uint128.One)
 	}
-
-	return results
 }
 
 // Network returns the network name, "ip+net".
@@ -103,7 +111,9 @@ func (r *Range) Network() string {
 	return "ip+net"
 }
 
-// String returns the string form of range.
+// String returns the string form of range. Unlike net.IP.String, it
+// writes IPv4-mapped addresses in IPv6 ranges in the mixed notation,
+// such as "::ffff:192.0.2.1", so that they keep their address family.
 func (r *Range) String() string {
-	return r.First().String() + " - " + r.Last().String()
+	return formatCanonical(r.First()) + " - " + formatCanonical(r.Last())
 }
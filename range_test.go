@@ -2,6 +2,7 @@ package iputil
 
 import (
 	"net"
+	"strings"
 	"testing"
 )
 
@@ -25,6 +26,13 @@ func TestRange(t *testing.T) {
 	if _, err := NewRange(ParseIPv4("192.168.0.100"), ParseIPv4("192.168.0.99")); err == nil {
 		t.Error("error expected for invalid range")
 	}
+
+	r, err := NewRange(ParseIPv4("192.168.0.100"), ParseIPv4("192.168.0.100"))
+	if err != nil {
+		t.Errorf("unexpected error for single-address range: %v", err)
+	} else if cidr := r.CIDR(); len(cidr) != 1 || cidr[0].String() != "192.168.0.100/32" {
+		t.Errorf("unexpected CIDR for single-address range: %v", cidr)
+	}
 }
 
 func TestRangeContains(t *testing.T) {
@@ -89,4 +97,50 @@ func TestRangeCIDR(t *testing.T) {
 			t.Errorf("unexpected CDIR: got %s, want %s", cidr, ipv6CIDRs[i])
 		}
 	}
+
+	cases := []struct {
+		first, last net.IP
+		bits        int
+		cidrs       string
+	}{
+		{ParseIPv4("0.0.0.0"), ParseIPv4("255.255.255.255"), IPv4BitLen, "0.0.0.0/0"},
+		{ParseIPv4("255.255.255.254"), ParseIPv4("255.255.255.255"), IPv4BitLen, "255.255.255.254/31"},
+		{ParseIPv4("255.255.255.255"), ParseIPv4("255.255.255.255"), IPv4BitLen, "255.255.255.255/32"},
+		{ParseIPv4("0.0.0.1"), ParseIPv4("255.255.255.254"), IPv4BitLen, ""},
+		{ParseIPv6("::"), ParseIPv6("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"), IPv6BitLen, "::/0"},
+		{ParseIPv6("ffff::"), ParseIPv6("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"), IPv6BitLen, "ffff::/16"},
+		{ParseIPv6("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"), ParseIPv6("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"), IPv6BitLen, "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff/128"},
+		{ParseIPv6("::1"), ParseIPv6("ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe"), IPv6BitLen, ""},
+
+		// IPv4-mapped addresses are 16 bytes long, so they make an IPv6
+		// range. Its CIDR is ::ffff:0.0.0.0/96, which net.IPNet prints
+		// as 0.0.0.0/0.
+		{net.ParseIP("0.0.0.0"), net.ParseIP("255.255.255.255"), IPv6BitLen, "0.0.0.0/0"},
+	}
+	for _, c := range cases {
+		r, err := NewRange(c.first, c.last)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var cidrs []string
+		for _, cidr := range r.CIDR() {
+			cidrs = append(cidrs, cidr.String())
+			if _, bits := cidr.Mask.Size(); bits != c.bits {
+				t.Errorf("unexpected mask length of %s for %s: got %d, want %d", cidr, r, bits, c.bits)
+			}
+		}
+		if c.cidrs != "" && strings.Join(cidrs, " ") != c.cidrs {
+			t.Errorf("unexpected CIDR for %s: got %v, want %s", r, cidrs, c.cidrs)
+		}
+		if c.cidrs == "" && len(cidrs) != c.bits*2-2 {
+			t.Errorf("unexpected number of CIDRs for %s: got %d, want %d", r, len(cidrs), c.bits*2-2)
+		}
+	}
+}
+
+func BenchmarkRangeCIDR(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ipv6Range.CIDR()
+	}
 }
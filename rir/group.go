@@ -0,0 +1,60 @@
+package rir
+
+import (
+	"io"
+
+	"github.com/ericyan/iputil"
+)
+
+// A KeyFunc returns the key that a record is grouped under, or "" to
+// leave the record out.
+type KeyFunc func(*Record) string
+
+// ByCountry groups delegated records by country code.
+func ByCountry(rec *Record) string {
+	if !rec.Status.IsDelegated() {
+		return ""
+	}
+
+	return rec.Country
+}
+
+// ByOpaqueID groups delegated records by holder, as identified by their
+// opaque ID. Records without one are left out.
+func ByOpaqueID(rec *Record) string {
+	if !rec.Status.IsDelegated() {
+		return ""
+	}
+
+	return rec.OpaqueID
+}
+
+// Group reads the remaining records from r, and returns the prefixes of
+// the IPv4 and IPv6 records grouped by key, each group aggregated by
+// iputil.AggregatePrefixes. ASN records are ignored.
+func Group(r *Reader, key KeyFunc) (map[string][]iputil.Prefix, error) {
+	groups := make(map[string][]iputil.Prefix)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if k := key(rec); k != "" {
+			groups[k] = append(groups[k], rec.Prefixes()...)
+		}
+	}
+
+	for k, prefixes := range groups {
+		if len(prefixes) == 0 {
+			delete(groups, k)
+			continue
+		}
+		groups[k] = iputil.AggregatePrefixes(prefixes)
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,68 @@
+package rir
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	cases := []struct {
+		key    KeyFunc
+		groups map[string]string
+	}{
+		{
+			ByCountry,
+			map[string]string{
+				"AU": "1.0.0.0/24 2001:db8::/32",
+				"CN": "1.0.1.0/24 1.0.2.0/23",
+				"JP": "1.0.16.0/21 1.0.24.0/22 2001:200::/35",
+			},
+		},
+		{
+			ByOpaqueID,
+			map[string]string{
+				"A91872ED": "1.0.0.0/24 2001:db8::/32",
+				"A92E1062": "1.0.1.0/24 1.0.2.0/23",
+				"A92D9378": "1.0.16.0/21 1.0.24.0/22",
+				"A91A7381": "2001:200::/35",
+			},
+		},
+		{
+			func(rec *Record) string { return rec.Registry },
+			map[string]string{
+				"apnic": "1.0.0.0/22 1.0.16.0/20 2001:200::/35 2001:db8::/32",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		f, err := os.Open("testdata/delegated-apnic-extended-latest")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		groups, err := Group(NewReader(f), c.key)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(groups) != len(c.groups) {
+			t.Errorf("unexpected groups: got %v, want %v", groups, c.groups)
+		}
+		for k, want := range c.groups {
+			var prefixes []string
+			for _, p := range groups[k] {
+				prefixes = append(prefixes, p.String())
+			}
+			if got := strings.Join(prefixes, " "); got != want {
+				t.Errorf("unexpected prefixes for %s: got %s, want %s", k, got, want)
+			}
+		}
+	}
+
+	if _, err := Group(NewReader(strings.NewReader("apnic|JP|ipv4|x|1|20000101|allocated")), ByCountry); err == nil {
+		t.Error("error expected for invalid record")
+	}
+}
@@ -0,0 +1,299 @@
+// Package rir parses the delegated statistics files published by the
+// Regional Internet Registries, which list the IP address blocks and AS
+// numbers each registry has delegated, and to which country.
+//
+// Both the delegated-*-latest and the delegated-*-extended-latest
+// formats are supported. They are described at
+// https://www.nro.net/wp-content/uploads/nro-extended-stats-readme5.txt.
+package rir
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ericyan/iputil"
+	"github.com/ericyan/iputil/uint128"
+)
+
+// A Type is the type of the resource of a record.
+type Type string
+
+// Resource types.
+const (
+	ASN  Type = "asn"
+	IPv4 Type = "ipv4"
+	IPv6 Type = "ipv6"
+)
+
+// A Status is the delegation status of a resource.
+type Status string
+
+// Delegation statuses.
+const (
+	Allocated Status = "allocated"
+	Assigned  Status = "assigned"
+	Available Status = "available"
+	Reserved  Status = "reserved"
+)
+
+// IsDelegated reports whether s is Allocated or Assigned.
+func (s Status) IsDelegated() bool {
+	return s == Allocated || s == Assigned
+}
+
+// dateLayout is the layout of dates, which may also be all zeros or
+// empty when unknown.
+const dateLayout = "20060102"
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" || s == "00000000" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(dateLayout, s)
+}
+
+// A Header is the version line at the start of a file.
+type Header struct {
+	Version   string
+	Registry  string
+	Serial    string
+	Records   int
+	StartDate time.Time
+	EndDate   time.Time
+	UTCOffset string
+}
+
+// A Summary is a summary line, giving the number of records of a type.
+type Summary struct {
+	Registry string
+	Type     Type
+	Count    int
+}
+
+// A Record describes the delegation of a block of IP addresses or AS
+// numbers.
+type Record struct {
+	Registry string
+
+	// Country is the ISO 3166 code of the country the resource was
+	// delegated to. It is empty or "ZZ" for resources that are not
+	// delegated.
+	Country string
+
+	Type Type
+
+	// Start is the first address or AS number of the block, and Value
+	// is the number of addresses for IPv4, the prefix length for IPv6,
+	// and the number of AS numbers for ASN records.
+	Start string
+	Value uint64
+
+	// Date is the date of the delegation, or the zero Time if unknown.
+	Date time.Time
+
+	Status Status
+
+	// OpaqueID identifies the holder of the resource, and is consistent
+	// across the records of a registry. It is only present in the
+	// extended format.
+	OpaqueID string
+
+	// Extensions are any further fields.
+	Extensions []string
+
+	// Range is the block of an IPv4 record, which need not be aligned
+	// to a prefix.
+	Range *iputil.Range
+
+	// Prefix is the block of an IPv6 record.
+	Prefix iputil.Prefix
+}
+
+// Prefixes returns the prefixes covering the block of an IPv4 or IPv6
+// record, or nil for ASN records.
+func (rec *Record) Prefixes() []iputil.Prefix {
+	switch {
+	case rec.Range != nil:
+		var prefixes []iputil.Prefix
+		for _, n := range rec.Range.CIDR() {
+			p, _ := iputil.PrefixFromIPNet(n)
+			prefixes = append(prefixes, p)
+		}
+		return prefixes
+	case rec.Prefix.IsValid():
+		return []iputil.Prefix{rec.Prefix}
+	default:
+		return nil
+	}
+}
+
+// A Reader reads records from a delegated statistics file.
+type Reader struct {
+	// Header is the version line, or nil if it has not been read yet.
+	Header *Header
+
+	// Summaries are the summary lines read so far.
+	Summaries []Summary
+
+	s    *bufio.Scanner
+	line int
+}
+
+// NewReader returns a Reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{s: bufio.NewScanner(r)}
+}
+
+// Read returns the next record. Comments, blank lines, the header and
+// summary lines are skipped, with the latter two stored in the Reader.
+// At the end of the input, it returns io.EOF.
+func (r *Reader) Read() (*Record, error) {
+	for r.s.Scan() {
+		r.line++
+
+		line := strings.TrimSpace(r.s.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		fields := strings.Split(line, "|")
+		switch {
+		case r.Header == nil && len(fields) == 7 && fields[0] != "" && fields[0][0] >= '0' && fields[0][0] <= '9':
+			h, err := parseHeader(fields)
+			if err != nil {
+				return nil, r.error(err)
+			}
+			r.Header = h
+		case len(fields) == 6 && fields[5] == "summary":
+			count, err := strconv.Atoi(fields[4])
+			if err != nil {
+				return nil, r.error(fmt.Errorf("invalid count %q", fields[4]))
+			}
+			r.Summaries = append(r.Summaries, Summary{fields[0], Type(fields[2]), count})
+		default:
+			rec, err := parseRecord(fields)
+			if err != nil {
+				return nil, r.error(err)
+			}
+			return rec, nil
+		}
+	}
+	if err := r.s.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+// ReadAll reads all the remaining records.
+func (r *Reader) ReadAll() ([]*Record, error) {
+	var records []*Record
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+}
+
+func (r *Reader) error(err error) error {
+	return fmt.Errorf("rir: line %d: %w", r.line, err)
+}
+
+func parseHeader(fields []string) (*Header, error) {
+	h := &Header{
+		Version:   fields[0],
+		Registry:  fields[1],
+		Serial:    fields[2],
+		UTCOffset: fields[6],
+	}
+
+	var err error
+	if h.Records, err = strconv.Atoi(fields[3]); err != nil {
+		return nil, fmt.Errorf("invalid record count %q", fields[3])
+	}
+	if h.StartDate, err = parseDate(fields[4]); err != nil {
+		return nil, fmt.Errorf("invalid start date %q", fields[4])
+	}
+	if h.EndDate, err = parseDate(fields[5]); err != nil {
+		return nil, fmt.Errorf("invalid end date %q", fields[5])
+	}
+
+	return h, nil
+}
+
+func parseRecord(fields []string) (*Record, error) {
+	if len(fields) < 7 {
+		return nil, fmt.Errorf("invalid record with %d fields", len(fields))
+	}
+
+	rec := &Record{
+		Registry: fields[0],
+		Country:  fields[1],
+		Type:     Type(fields[2]),
+		Start:    fields[3],
+		Status:   Status(fields[6]),
+	}
+	if len(fields) > 7 {
+		rec.OpaqueID = fields[7]
+	}
+	if len(fields) > 8 {
+		rec.Extensions = fields[8:]
+	}
+
+	var err error
+	if rec.Value, err = strconv.ParseUint(fields[4], 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid value %q", fields[4])
+	}
+	if rec.Date, err = parseDate(fields[5]); err != nil {
+		return nil, fmt.Errorf("invalid date %q", fields[5])
+	}
+
+	switch rec.Type {
+	case IPv4:
+		first := iputil.ParseIPv4(rec.Start)
+		if first == nil {
+			return nil, fmt.Errorf("invalid ipv4 address %q", rec.Start)
+		}
+		if rec.Value == 0 || rec.Value > 1<<32 {
+			return nil, fmt.Errorf("invalid ipv4 address count %d", rec.Value)
+		}
+
+		x := iputil.AddrFromIP(first).Int().Add(uint128.NewFromUint64(rec.Value - 1))
+		last, err := iputil.AddrFromInt(x, iputil.IPv4)
+		if err != nil {
+			return nil, fmt.Errorf("ipv4 block %s+%d out of range", rec.Start, rec.Value)
+		}
+		if rec.Range, err = iputil.NewRange(first.To4(), last.IP()); err != nil {
+			return nil, err
+		}
+	case IPv6:
+		ip := net.ParseIP(rec.Start)
+		if ip == nil || ip.To4() != nil || rec.Value > 128 {
+			return nil, fmt.Errorf("invalid ipv6 block %s/%d", rec.Start, rec.Value)
+		}
+		addr := iputil.AddrFromIP(ip)
+		if rec.Prefix, err = iputil.NewPrefix(addr, int(rec.Value)); err != nil || rec.Prefix.Addr() != addr {
+			return nil, fmt.Errorf("invalid ipv6 block %s/%d", rec.Start, rec.Value)
+		}
+	case ASN:
+		if asn, err := strconv.ParseUint(rec.Start, 10, 32); err != nil || rec.Value == 0 || rec.Value > math.MaxUint32+1-asn {
+			return nil, fmt.Errorf("invalid asn block %s+%d", rec.Start, rec.Value)
+		}
+	default:
+		return nil, fmt.Errorf("invalid type %q", rec.Type)
+	}
+
+	return rec, nil
+}
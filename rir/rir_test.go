@@ -0,0 +1,135 @@
+package rir
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReader(t *testing.T) {
+	f, err := os.Open("testdata/delegated-apnic-extended-latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r := NewReader(f)
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Header{
+		Version:   "2.3",
+		Registry:  "apnic",
+		Serial:    "20240101",
+		Records:   9,
+		StartDate: time.Date(1983, 6, 13, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
+		UTCOffset: "+1000",
+	}
+	if r.Header == nil || *r.Header != want {
+		t.Errorf("unexpected header: got %+v, want %+v", r.Header, want)
+	}
+	if len(r.Summaries) != 3 || r.Summaries[1] != (Summary{"apnic", IPv4, 5}) {
+		t.Errorf("unexpected summaries: %+v", r.Summaries)
+	}
+	if len(records) != 9 {
+		t.Fatalf("unexpected number of records: got %d, want %d", len(records), 9)
+	}
+
+	cases := []struct {
+		rec      *Record
+		country  string
+		typ      Type
+		status   Status
+		opaqueID string
+		block    string
+	}{
+		{records[0], "JP", ASN, Allocated, "A91A7381", ""},
+		{records[2], "AU", IPv4, Assigned, "A91872ED", "1.0.0.0 - 1.0.0.255"},
+		{records[5], "JP", IPv4, Allocated, "A92D9378", "1.0.16.0 - 1.0.27.255"},
+		{records[6], "ZZ", IPv4, Available, "", "1.0.28.0 - 1.0.31.255"},
+		{records[7], "JP", IPv6, Allocated, "A91A7381", "2001:200::/35"},
+	}
+
+	for _, c := range cases {
+		var block string
+		switch {
+		case c.rec.Range != nil:
+			block = c.rec.Range.String()
+		case c.rec.Prefix.IsValid():
+			block = c.rec.Prefix.String()
+		}
+
+		if c.rec.Country != c.country || c.rec.Type != c.typ || c.rec.Status != c.status || c.rec.OpaqueID != c.opaqueID || block != c.block {
+			t.Errorf("unexpected record: %+v", c.rec)
+		}
+	}
+
+	if d := records[2].Date; !d.Equal(time.Date(2011, 8, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date: %s", d)
+	}
+	if !records[6].Date.IsZero() {
+		t.Errorf("unexpected date for available record: %s", records[6].Date)
+	}
+	if ext := records[8].Extensions; len(ext) != 1 || ext[0] != "extra" {
+		t.Errorf("unexpected extensions: %v", ext)
+	}
+}
+
+func TestRecordPrefixes(t *testing.T) {
+	cases := []struct {
+		line     string
+		prefixes string
+	}{
+		{"apnic|JP|ipv4|1.0.16.0|3072|20110412|allocated", "1.0.16.0/21 1.0.24.0/22"},
+		{"arin|US|ipv4|192.0.2.1|1|20000101|assigned", "192.0.2.1/32"},
+		{"ripencc|NL|ipv6|2001:db8::|32|20000101|allocated", "2001:db8::/32"},
+		{"lacnic|BR|asn|64512|10|20000101|allocated", ""},
+	}
+
+	for _, c := range cases {
+		rec, err := NewReader(strings.NewReader(c.line)).Read()
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", c.line, err)
+			continue
+		}
+
+		var prefixes []string
+		for _, p := range rec.Prefixes() {
+			prefixes = append(prefixes, p.String())
+		}
+		if s := strings.Join(prefixes, " "); s != c.prefixes {
+			t.Errorf("unexpected prefixes for %q: got %s, want %s", c.line, s, c.prefixes)
+		}
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	cases := []string{
+		"apnic|JP|ipv4|1.0.16.0|3072|20110412",
+		"apnic|JP|ipv4|1.0.16.0|0|20110412|allocated",
+		"apnic|JP|ipv4|255.255.255.0|512|20110412|allocated",
+		"apnic|JP|ipv4|2001:db8::|512|20110412|allocated",
+		"apnic|JP|ipv6|2001:db8::|129|20110412|allocated",
+		"apnic|JP|ipv6|2001:db8::1|32|20110412|allocated",
+		"apnic|JP|ipv6|1.0.16.0|32|20110412|allocated",
+		"apnic|JP|asn|4294967295|2|20110412|allocated",
+		"apnic|JP|asn|AS1|1|20110412|allocated",
+		"apnic|JP|ipv4|1.0.16.0|256|2011-04-12|allocated",
+		"apnic|JP|fqdn|example.com|1|20110412|allocated",
+		"2|apnic|20240101|many|19830613|20231231|+1000",
+		"apnic|*|ipv4|*|many|summary",
+	}
+
+	for _, c := range cases {
+		if _, err := NewReader(strings.NewReader("# comment\n\n" + c)).Read(); err == nil || err == io.EOF {
+			t.Errorf("error expected for %q", c)
+		} else if !strings.HasPrefix(err.Error(), "rir: line 3: ") {
+			t.Errorf("unexpected error for %q: %v", c, err)
+		}
+	}
+}
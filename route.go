@@ -0,0 +1,330 @@
+package iputil
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// RouteFlags are the flags of a route, using the values of the Linux
+// RTF_* constants.
+type RouteFlags uint32
+
+// Route flags.
+const (
+	RouteUp       RouteFlags = 0x0001     // route usable
+	RouteGateway  RouteFlags = 0x0002     // destination is a gateway
+	RouteHost     RouteFlags = 0x0004     // host entry
+	RouteDynamic  RouteFlags = 0x0010     // created by redirect
+	RouteModified RouteFlags = 0x0020     // modified by redirect
+	RouteReject   RouteFlags = 0x0200     // reject route
+	RouteAddrConf RouteFlags = 0x00040000 // created by autoconfiguration
+	RouteCache    RouteFlags = 0x01000000 // cached entry
+	RouteLocal    RouteFlags = 0x80000000 // route to a local address
+)
+
+// A Route is an entry in the system's routing table.
+type Route struct {
+	// Dst is the destination prefix.
+	Dst Prefix
+
+	// Gateway is the address of the next hop, or the zero Addr if the
+	// destination is directly connected.
+	Gateway Addr
+
+	// InterfaceName is the name of the outgoing interface.
+	InterfaceName string
+
+	Metric uint32
+	Flags  RouteFlags
+}
+
+// A RouteTable is a list of routes of either address family.
+type RouteTable []*Route
+
+// Routes returns the system's routing table. It is only supported on
+// Linux, where it reads the main routing table from /proc/net/route and
+// /proc/net/ipv6_route.
+func Routes() (RouteTable, error) {
+	return ReadRoutes("/proc/net", binary.NativeEndian)
+}
+
+// ReadRoutes reads the routing table from the route and ipv6_route files
+// in dir, which are in the format of /proc/net. A missing ipv6_route
+// file, as on systems with IPv6 disabled, is ignored. The route file is
+// decoded with order, the byte order of the host it was written by.
+func ReadRoutes(dir string, order binary.ByteOrder) (RouteTable, error) {
+	f, err := os.Open(filepath.Join(dir, "route"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	routes, err := ParseRoutes(f, order)
+	if err != nil {
+		return nil, err
+	}
+
+	f6, err := os.Open(filepath.Join(dir, "ipv6_route"))
+	if os.IsNotExist(err) {
+		return routes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f6.Close()
+
+	routes6, err := ParseIPv6Routes(f6)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(routes, routes6...), nil
+}
+
+// ParseRoutes parses IPv4 routes in the format of /proc/net/route. The
+// first line is a header, and each following line has the interface
+// name, then the destination, gateway, flags, reference count, use
+// count, metric and netmask. Addresses and netmasks are 32-bit hex
+// numbers in the byte order of the host, which is given by order, such
+// as binary.NativeEndian for the routes of this host.
+func ParseRoutes(r io.Reader, order binary.ByteOrder) (RouteTable, error) {
+	var routes RouteTable
+
+	s := bufio.NewScanner(r)
+	for i := 0; s.Scan(); i++ {
+		fields := strings.Fields(s.Text())
+		if i == 0 || len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 8 {
+			return nil, errors.New("invalid route line: " + s.Text())
+		}
+
+		dst, err := parseRouteIPv4(fields[1], order)
+		if err != nil {
+			return nil, err
+		}
+		gw, err := parseRouteIPv4(fields[2], order)
+		if err != nil {
+			return nil, err
+		}
+		mask, err := parseRouteIPv4(fields[7], order)
+		if err != nil {
+			return nil, err
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil {
+			return nil, errors.New("invalid route flags: " + fields[3])
+		}
+		metric, err := strconv.ParseUint(fields[6], 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid route metric: " + fields[6])
+		}
+
+		ones, bits := net.IPMask(mask.IP()).Size()
+		if bits == 0 {
+			return nil, errors.New("invalid route mask: " + fields[7])
+		}
+		prefix, _ := NewPrefix(dst, ones)
+
+		route := &Route{
+			Dst:           prefix,
+			InterfaceName: fields[0],
+			Metric:        uint32(metric),
+			Flags:         RouteFlags(flags),
+		}
+		if route.Flags&RouteGateway != 0 {
+			route.Gateway = gw
+		}
+
+		routes = append(routes, route)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+// parseRouteIPv4 parses an IPv4 address or netmask in the format of
+// /proc/net/route, in the given byte order.
+func parseRouteIPv4(s string, order binary.ByteOrder) (Addr, error) {
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || len(s) != 8 {
+		return Addr{}, errors.New("invalid route address: " + s)
+	}
+
+	var b [4]byte
+	order.PutUint32(b[:], uint32(v))
+	return AddrFrom4(b), nil
+}
+
+// ParseIPv6Routes parses IPv6 routes in the format of
+// /proc/net/ipv6_route. Each line has the destination and its prefix
+// length, the source and its prefix length, the next hop, and the
+// metric, reference count, use count and flags, all in hex, followed by
+// the interface name.
+func ParseIPv6Routes(r io.Reader) (RouteTable, error) {
+	var routes RouteTable
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 10 {
+			return nil, errors.New("invalid ipv6_route line: " + s.Text())
+		}
+
+		dst, err := parseRouteIPv6(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		plen, err := strconv.ParseUint(fields[1], 16, 8)
+		if err != nil {
+			return nil, errors.New("invalid ipv6_route prefix length: " + fields[1])
+		}
+		gw, err := parseRouteIPv6(fields[4])
+		if err != nil {
+			return nil, err
+		}
+		metric, err := strconv.ParseUint(fields[5], 16, 32)
+		if err != nil {
+			return nil, errors.New("invalid ipv6_route metric: " + fields[5])
+		}
+		flags, err := strconv.ParseUint(fields[8], 16, 32)
+		if err != nil {
+			return nil, errors.New("invalid ipv6_route flags: " + fields[8])
+		}
+
+		prefix, err := NewPrefix(dst, int(plen))
+		if err != nil {
+			return nil, err
+		}
+
+		route := &Route{
+			Dst:           prefix,
+			InterfaceName: fields[9],
+			Metric:        uint32(metric),
+			Flags:         RouteFlags(flags),
+		}
+		if route.Flags&RouteGateway != 0 {
+			route.Gateway = gw
+		}
+
+		routes = append(routes, route)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+// parseRouteIPv6 parses an IPv6 address in the format of
+// /proc/net/ipv6_route.
+func parseRouteIPv6(s string) (Addr, error) {
+	var b [16]byte
+	if len(s) != 2*len(b) {
+		return Addr{}, errors.New("invalid ipv6_route address: " + s)
+	}
+	if _, err := hex.Decode(b[:], []byte(s)); err != nil {
+		return Addr{}, errors.New("invalid ipv6_route address: " + s)
+	}
+
+	return AddrFrom16(b), nil
+}
+
+// usable reports whether packets can be routed using the route.
+func (r *Route) usable() bool {
+	return r.Flags&RouteUp != 0 && r.Flags&RouteReject == 0
+}
+
+// better reports whether r is preferred to s for the same destination,
+// having the longer prefix or, for prefixes of the same length, the
+// lower metric.
+func (r *Route) better(s *Route) bool {
+	if r.Dst.Bits() != s.Dst.Bits() {
+		return r.Dst.Bits() > s.Dst.Bits()
+	}
+
+	return r.Metric < s.Metric
+}
+
+// Lookup returns the route for dst, which is the usable route with the
+// longest prefix containing dst. Among routes with the same prefix, the
+// one with the lowest metric is returned. It returns nil if there is no
+// route to dst.
+func (t RouteTable) Lookup(dst net.IP) *Route {
+	addr := AddrFromIP(dst)
+
+	var best *Route
+	for _, r := range t {
+		if !r.usable() || !r.Dst.Contains(addr) {
+			continue
+		}
+		if best == nil || r.better(best) {
+			best = r
+		}
+	}
+
+	return best
+}
+
+// DefaultRoute returns the usable default route of family af, 1 for
+// IPv4 and 2 for IPv6, with the lowest metric. It returns nil if there
+// is no default route through a gateway.
+func (t RouteTable) DefaultRoute(af uint) *Route {
+	var best *Route
+	for _, r := range t {
+		if !r.usable() || r.Dst.Addr().Family() != af || r.Dst.Bits() != 0 || !r.Gateway.IsValid() {
+			continue
+		}
+		if best == nil || r.better(best) {
+			best = r
+		}
+	}
+
+	return best
+}
+
+// DefaultGateway returns the system's default route of family af, 1 for
+// IPv4 and 2 for IPv6, as found in the routing table returned by Routes.
+func DefaultGateway(af uint) (*Route, error) {
+	routes, err := Routes()
+	if err != nil {
+		return nil, err
+	}
+
+	route := routes.DefaultRoute(af)
+	if route == nil {
+		return nil, errors.New("default gateway unavailable")
+	}
+
+	return route, nil
+}
+
+// RouteFor returns the system's route for dst, as found in the routing
+// table returned by Routes.
+func RouteFor(dst net.IP) (*Route, error) {
+	routes, err := Routes()
+	if err != nil {
+		return nil, err
+	}
+
+	route := routes.Lookup(dst)
+	if route == nil {
+		return nil, errors.New("no route to host")
+	}
+
+	return route, nil
+}
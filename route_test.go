@@ -0,0 +1,162 @@
+package iputil
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestReadRoutes(t *testing.T) {
+	routes, err := ReadRoutes("testdata/proc/net", binary.LittleEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 15 {
+		t.Fatalf("unexpected number of routes: got %d, want 15", len(routes))
+	}
+
+	cases := []struct {
+		i       int
+		dst     string
+		gateway string
+		iface   string
+		metric  uint32
+		flags   RouteFlags
+	}{
+		{0, "0.0.0.0/0", "192.168.1.1", "eth0", 100, RouteUp | RouteGateway},
+		{3, "192.168.1.0/24", "<nil>", "eth0", 100, RouteUp},
+		{6, "192.168.2.2/32", "<nil>", "eth0", 0, RouteUp | RouteReject},
+		{7, "2001:db8::/64", "<nil>", "eth0", 256, RouteUp},
+		{9, "::/0", "fe80::1", "eth0", 1024, RouteUp | RouteGateway | RouteAddrConf},
+		{11, "::1/128", "<nil>", "lo", 0, RouteUp | RouteLocal | 0x00200000},
+	}
+
+	for _, c := range cases {
+		r := routes[c.i]
+		if r.Dst.String() != c.dst || r.Gateway.String() != c.gateway || r.InterfaceName != c.iface {
+			t.Errorf("unexpected route %d: got %s via %s dev %s", c.i, r.Dst, r.Gateway, r.InterfaceName)
+		}
+		if r.Metric != c.metric || r.Flags != c.flags {
+			t.Errorf("unexpected route %d: got metric %d, flags %#x", c.i, r.Metric, r.Flags)
+		}
+	}
+
+	routes, err = ReadRoutes("testdata/noipv6", binary.LittleEndian)
+	if err != nil || len(routes) != 7 {
+		t.Errorf("unexpected result without ipv6_route: got %d routes, %v", len(routes), err)
+	}
+	if _, err := ReadRoutes("testdata/missing", binary.LittleEndian); err == nil {
+		t.Error("error expected for missing directory")
+	}
+}
+
+func TestParseRoutesByteOrder(t *testing.T) {
+	header := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\n"
+	cases := []struct {
+		line  string
+		order binary.ByteOrder
+	}{
+		{"eth0\t0000A8C0\t00000000\t0001\t0\t0\t0\t00FFFFFF", binary.LittleEndian},
+		{"eth0\tC0A80000\t00000000\t0001\t0\t0\t0\tFFFFFF00", binary.BigEndian},
+	}
+
+	for _, c := range cases {
+		routes, err := ParseRoutes(strings.NewReader(header+c.line), c.order)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", c.line, err)
+			continue
+		}
+		if got := routes[0].Dst.String(); got != "192.168.0.0/24" {
+			t.Errorf("unexpected destination for %q: got %s, want 192.168.0.0/24", c.line, got)
+		}
+	}
+}
+
+func TestParseRoutesErrors(t *testing.T) {
+	header := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\n"
+	for _, line := range []string{
+		"eth0\t00000000\t0101A8C0\t0003\t0\t0\t100",
+		"eth0\t0000000\t0101A8C0\t0003\t0\t0\t100\t00000000",
+		"eth0\t00000000\t0101A8C0\tzzzz\t0\t0\t100\t00000000",
+		"eth0\t00000000\t0101A8C0\t0003\t0\t0\t-1\t00000000",
+		"eth0\t00000000\t0101A8C0\t0003\t0\t0\t100\t00FF00FF",
+	} {
+		if _, err := ParseRoutes(strings.NewReader(header+line), binary.LittleEndian); err == nil {
+			t.Errorf("error expected for %q", line)
+		}
+	}
+
+	for _, line := range []string{
+		"20010db8000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000001 00000000 00000001",
+		"20010db8000000000000000000000000 81 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000001 00000000 00000001 eth0",
+		"20010db800000000000000000000000g 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000001 00000000 00000001 eth0",
+	} {
+		if _, err := ParseIPv6Routes(strings.NewReader(line)); err == nil {
+			t.Errorf("error expected for %q", line)
+		}
+	}
+}
+
+func TestRouteTableLookup(t *testing.T) {
+	routes, err := ReadRoutes("testdata/proc/net", binary.LittleEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		dst   string
+		route string
+	}{
+		{"192.168.1.20", "192.168.1.0/24 eth0"},
+		{"10.0.5.1", "10.0.0.0/16 wlan0"},
+		{"10.8.1.1", "10.8.0.0/16 tun0"},
+		{"8.8.8.8", "0.0.0.0/0 eth0"},
+		{"192.168.2.2", "0.0.0.0/0 eth0"},
+		{"2001:db8::10", "2001:db8::10/128 eth0"},
+		{"2001:db8::20", "2001:db8::/64 eth0"},
+		{"2001:db8:1::1", "::/0 wlan0"},
+		{"ff02::1", "ff00::/8 eth0"},
+	}
+
+	for _, c := range cases {
+		r := routes.Lookup(net.ParseIP(c.dst))
+		if r == nil {
+			t.Errorf("unexpected nil route for %s", c.dst)
+			continue
+		}
+		if out := r.Dst.String() + " " + r.InterfaceName; out != c.route {
+			t.Errorf("unexpected route for %s: got %s, want %s", c.dst, out, c.route)
+		}
+	}
+
+	if r := routes[:2].Lookup(net.ParseIP("2001:db8::1")); r != nil {
+		t.Errorf("unexpected route: %s", r.Dst)
+	}
+}
+
+func TestRouteTableDefaultRoute(t *testing.T) {
+	routes, err := ReadRoutes("testdata/proc/net", binary.LittleEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		af      uint
+		gateway string
+	}{
+		{IPv4, "192.168.1.1"},
+		{IPv6, "fe80::2"},
+	}
+
+	for _, c := range cases {
+		r := routes.DefaultRoute(c.af)
+		if r == nil || r.Gateway.String() != c.gateway {
+			t.Errorf("unexpected default route for family %d: got %v", c.af, r)
+		}
+	}
+
+	if r := routes[2:7].DefaultRoute(IPv4); r != nil {
+		t.Errorf("unexpected default route: %s", r.Dst)
+	}
+}
@@ -0,0 +1,111 @@
+package iputil
+
+import "net"
+
+// An InterfaceSource provides the interface addresses of a host.
+type InterfaceSource interface {
+	// InterfaceAddrs returns the unicast interface IP addresses, ordered
+	// by interface.
+	InterfaceAddrs() ([]*InterfaceAddr, error)
+}
+
+// A SourceSelector is an InterfaceSource that also chooses the source
+// address for a destination. SourceAddrFor uses it if DefaultSource
+// implements it.
+type SourceSelector interface {
+	InterfaceSource
+
+	// SourceAddr returns the address that the host would use as the
+	// source address when sending packets to dst, or nil if the host has
+	// no route to dst.
+	SourceAddr(dst net.IP) (net.IP, error)
+}
+
+// DefaultSource is the InterfaceSource used by InterfaceAddrs, and in
+// turn by the other functions that select interface addresses. It can
+// be replaced to make these functions deterministic in tests.
+var DefaultSource InterfaceSource = OSSource{}
+
+// OSSource is an InterfaceSource that queries the operating system
+// through the net package. On Linux, the state flags of IPv6 addresses
+// are read from /proc/net/if_inet6, and address lifetimes are requested
+// from the kernel through netlink.
+type OSSource struct{}
+
+// InterfaceAddrs implements InterfaceSource.
+func (OSSource) InterfaceAddrs() ([]*InterfaceAddr, error) {
+	var ifAddrs []*InterfaceAddr
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	// The address state is best effort, as it is not available on every
+	// system.
+	infos, _ := addrInfos()
+
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, addr := range addrs {
+			ipnet := addr.(*net.IPNet)
+			info := infos[ifaceAddrKey{iface.Index, AddrFromIP(ipnet.IP)}]
+			ifAddrs = append(ifAddrs, &InterfaceAddr{
+				IPNet:             ipnet,
+				InterfaceName:     iface.Name,
+				Flags:             iface.Flags,
+				AddrFlags:         info.flags,
+				InterfaceIndex:    iface.Index,
+				MTU:               iface.MTU,
+				HardwareAddr:      iface.HardwareAddr,
+				PreferredLifetime: info.preferred,
+				ValidLifetime:     info.valid,
+			})
+		}
+	}
+
+	return ifAddrs, nil
+}
+
+// SourceAddr implements SourceSelector. It connects an unbound UDP
+// socket to dst to have the kernel choose the source address by
+// consulting the routing table, which sends no traffic.
+func (OSSource) SourceAddr(dst net.IP) (net.IP, error) {
+	// The port is irrelevant, as no packets are sent, but must be non-zero
+	// on some systems.
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: dst, Port: 9})
+	if err != nil {
+		return nil, nil
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+// A StaticSource is an InterfaceSource that always provides the same
+// addresses, for use in tests.
+type StaticSource []*InterfaceAddr
+
+// InterfaceAddrs implements InterfaceSource. It returns a copy of s, so
+// that callers may modify the slice.
+func (s StaticSource) InterfaceAddrs() ([]*InterfaceAddr, error) {
+	return append([]*InterfaceAddr(nil), s...), nil
+}
+
+// SourceAddr implements SourceSelector. As if the only routes were those
+// to the subnets of its addresses, it returns the first address of an
+// interface that is up whose subnet contains dst, and nil if there is
+// none.
+func (s StaticSource) SourceAddr(dst net.IP) (net.IP, error) {
+	for _, addr := range s {
+		if addr.IsUp() && addr.Contains(dst) {
+			return addr.IP, nil
+		}
+	}
+
+	return nil, nil
+}
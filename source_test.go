@@ -0,0 +1,39 @@
+package iputil
+
+import "testing"
+
+func TestStaticSource(t *testing.T) {
+	src := StaticSource(testAddrs)
+
+	addrs, err := src.InterfaceAddrs()
+	if err != nil || len(addrs) != len(testAddrs) {
+		t.Fatalf("unexpected result: got %d addresses, %v", len(addrs), err)
+	}
+
+	addrs[0] = nil
+	if src[0] == nil {
+		t.Error("unexpected modification of source")
+	}
+}
+
+func TestDefaultSource(t *testing.T) {
+	defer func(src InterfaceSource) { DefaultSource = src }(DefaultSource)
+	DefaultSource = StaticSource(testAddrs)
+
+	addrs, err := InterfaceAddrs()
+	if err != nil || len(addrs) != len(testAddrs) {
+		t.Errorf("unexpected result: got %d addresses, %v", len(addrs), err)
+	}
+
+	if addr, err := DefaultIPv4(); err != nil || addr.IP.String() != "192.168.1.10" {
+		t.Errorf("unexpected default ipv4: got %v, %v", addr, err)
+	}
+	if addr, err := DefaultIPv6(); err != nil || addr.IP.String() != "2001:db8::10" {
+		t.Errorf("unexpected default ipv6: got %v, %v", addr, err)
+	}
+
+	DefaultSource = StaticSource(FilterAddrs(testAddrs, (*InterfaceAddr).IsLoopback))
+	if _, err := DefaultIPv4(); err == nil {
+		t.Error("error expected without non-loopback ipv4 address")
+	}
+}
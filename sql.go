@@ -0,0 +1,269 @@
+package iputil
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// scanString returns src as a string, as received from a database
+// driver. It reports false if src is NULL.
+func scanString(src interface{}) (string, bool, error) {
+	switch v := src.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return v, true, nil
+	case []byte:
+		return string(v), true, nil
+	default:
+		return "", false, fmt.Errorf("cannot scan %T", src)
+	}
+}
+
+// parseInet parses s in the text format of the PostgreSQL inet and cidr
+// types, "address/prefix-length", where the prefix length defaults to
+// the full address width. The returned IP is not masked.
+func parseInet(s string) (net.IP, net.IPMask, error) {
+	addr, plen := s, ""
+	i := strings.IndexByte(s, '/')
+	if i >= 0 {
+		addr, plen = s[:i], s[i+1:]
+	}
+
+	ip, err := Parse(addr, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bits := len(ip) * 8
+	ones := bits
+	if i >= 0 {
+		ones, err = strconv.Atoi(plen)
+		if err != nil || ones < 0 || ones > bits || plen[0] == '+' {
+			return nil, nil, errors.New("invalid prefix length: " + strconv.Quote(plen))
+		}
+	}
+
+	return ip, net.CIDRMask(ones, bits), nil
+}
+
+// Value implements driver.Valuer. A range is stored in the ip4r range
+// syntax, "first-last", with IPv4-mapped addresses of IPv6 ranges in the
+// mixed notation. The zero Range is stored as NULL.
+func (r *Range) Value() (driver.Value, error) {
+	if r.af == 0 {
+		return nil, nil
+	}
+
+	return formatCanonical(r.First()) + "-" + formatCanonical(r.Last()), nil
+}
+
+// Scan implements sql.Scanner. It accepts the ip4r range syntax, either
+// "first-last", a prefix in CIDR notation or a single address, as well as
+// the text form of Range. NULL is scanned as the zero Range.
+func (r *Range) Scan(src interface{}) error {
+	s, ok, err := scanString(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*r = Range{}
+		return nil
+	}
+
+	if strings.IndexByte(s, '-') >= 0 {
+		return r.UnmarshalText([]byte(s))
+	}
+
+	// A prefix, or a single address as a prefix of full length.
+	ip, mask, err := parseInet(s)
+	if err != nil {
+		return err
+	}
+
+	subnet := &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	v, err := NewRange(NetworkAddr(subnet), BroadcastAddr(subnet))
+	if err != nil {
+		return err
+	}
+
+	*r = *v
+	return nil
+}
+
+// Value implements driver.Valuer. A prefix is stored in CIDR notation,
+// as used by the PostgreSQL cidr type. Unlike net.IPNet.String, IPv6
+// prefixes of IPv4-mapped addresses retain their address family. A nil
+// *net.IPNet is stored as NULL.
+func (n IPNet) Value() (driver.Value, error) {
+	if n.IPNet == nil {
+		return nil, nil
+	}
+
+	ip, ones, ok := n.prefix()
+	if !ok {
+		return nil, errors.New("invalid prefix")
+	}
+
+	return formatCanonical(ip) + "/" + strconv.Itoa(ones), nil
+}
+
+// Scan implements sql.Scanner. It accepts the text format of the
+// PostgreSQL inet and cidr types; host bits are cleared, and a missing
+// prefix length means a single-address prefix.
+func (n *IPNet) Scan(src interface{}) error {
+	s, ok, err := scanString(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		n.IPNet = nil
+		return nil
+	}
+
+	ip, mask, err := parseInet(s)
+	if err != nil {
+		return err
+	}
+
+	n.IPNet = &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	return nil
+}
+
+// Value implements driver.Valuer, using the text format of the
+// PostgreSQL inet type. The zero Addr is stored as NULL.
+func (a Addr) Value() (driver.Value, error) {
+	if !a.IsValid() {
+		return nil, nil
+	}
+
+	return a.String(), nil
+}
+
+// Scan implements sql.Scanner. It accepts the text format of the
+// PostgreSQL inet type. The prefix length of an inet value, if any, is
+// discarded, and NULL results in the zero Addr.
+func (a *Addr) Scan(src interface{}) error {
+	s, ok, err := scanString(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*a = Addr{}
+		return nil
+	}
+
+	ip, _, err := parseInet(s)
+	if err != nil {
+		return err
+	}
+
+	*a = addrFromSlice(ip)
+	return nil
+}
+
+// An Inet wraps a net.IP to implement sql.Scanner and driver.Valuer,
+// using the text format of the PostgreSQL inet type.
+type Inet net.IP
+
+// Value implements driver.Valuer. A nil address is stored as NULL. As
+// with IPNet and Range, IPv4-mapped addresses in 16-byte form retain
+// their address family and are stored in the mixed notation.
+func (a Inet) Value() (driver.Value, error) {
+	if len(a) == 0 {
+		return nil, nil
+	}
+	if AddressFamily(net.IP(a)) == 0 {
+		return nil, errors.New("invalid address")
+	}
+
+	return formatCanonical(net.IP(a)), nil
+}
+
+// Scan implements sql.Scanner. It accepts the text format of the
+// PostgreSQL inet type. The prefix length of an inet value, if any, is
+// discarded, and NULL results in a nil Inet.
+func (a *Inet) Scan(src interface{}) error {
+	s, ok, err := scanString(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*a = nil
+		return nil
+	}
+
+	ip, _, err := parseInet(s)
+	if err != nil {
+		return err
+	}
+
+	*a = Inet(ip)
+	return nil
+}
+
+// A Decimal wraps a net.IP to implement sql.Scanner and driver.Valuer,
+// storing the address as a decimal integer as returned by DecimalString.
+// This is suitable for NUMERIC(39) columns in databases without native
+// IP address types.
+type Decimal struct {
+	IP net.IP
+
+	// Family is the address family of values to scan, IPv4 or IPv6. If
+	// it is 0, values that fit in 32 bits are scanned as IPv4 addresses.
+	Family uint
+}
+
+// Value implements driver.Valuer. A nil address is stored as NULL.
+func (d Decimal) Value() (driver.Value, error) {
+	if len(d.IP) == 0 {
+		return nil, nil
+	}
+	if AddressFamily(d.IP) == 0 {
+		return nil, errors.New("invalid address")
+	}
+
+	return DecimalString(d.IP), nil
+}
+
+// Scan implements sql.Scanner. It accepts decimal strings as well as
+// integers, as returned by drivers for NUMERIC and BIGINT columns.
+func (d *Decimal) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case int64:
+		if v < 0 {
+			return errors.New("negative address")
+		}
+		s = strconv.FormatInt(v, 10)
+	default:
+		var ok bool
+		var err error
+		s, ok, err = scanString(src)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			d.IP = nil
+			return nil
+		}
+	}
+
+	var ip net.IP
+	var err error
+	if d.Family != 0 {
+		ip, err = ParseInt(s, d.Family)
+	} else if ip, err = ParseInt(s, IPv4); errors.Is(err, ErrOverflow) {
+		ip, err = ParseInt(s, IPv6)
+	}
+	if err != nil {
+		return err
+	}
+
+	d.IP = ip
+	return nil
+}
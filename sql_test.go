@@ -0,0 +1,208 @@
+package iputil
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"net"
+	"testing"
+)
+
+var (
+	_ sql.Scanner   = (*Range)(nil)
+	_ driver.Valuer = (*Range)(nil)
+	_ sql.Scanner   = (*IPNet)(nil)
+	_ driver.Valuer = IPNet{}
+	_ sql.Scanner   = (*Addr)(nil)
+	_ driver.Valuer = Addr{}
+	_ sql.Scanner   = (*Inet)(nil)
+	_ driver.Valuer = Inet(nil)
+	_ sql.Scanner   = (*Decimal)(nil)
+	_ driver.Valuer = Decimal{}
+)
+
+func TestRangeSQL(t *testing.T) {
+	if v, _ := ipv4Range.Value(); v != "192.168.0.100-192.168.0.199" {
+		t.Errorf("unexpected value: %v", v)
+	}
+	if v, _ := (&Range{}).Value(); v != nil {
+		t.Errorf("unexpected value: %v", v)
+	}
+
+	mapped, _ := NewRange(net.ParseIP("192.168.0.100"), net.ParseIP("192.168.0.199"))
+	v, _ := mapped.Value()
+	if v != "::ffff:192.168.0.100-::ffff:192.168.0.199" {
+		t.Errorf("unexpected value: %v", v)
+	}
+	var r Range
+	if err := r.Scan(v); err != nil || r != *mapped {
+		t.Errorf("unexpected round trip for %s: got %s, %v", mapped, &r, err)
+	}
+	if err := r.Scan(nil); err != nil || r != (Range{}) {
+		t.Errorf("unexpected range for NULL: got %s, %v", &r, err)
+	}
+
+	cases := []struct {
+		src interface{}
+		out string
+		err bool
+	}{
+		{"192.168.0.100-192.168.0.199", "192.168.0.100 - 192.168.0.199", false},
+		{[]byte("2001:db8::1234:0-2001:db8::5678:0"), "2001:db8::1234:0 - 2001:db8::5678:0", false},
+		{"192.168.0.0/24", "192.168.0.0 - 192.168.0.255", false},
+		{"192.168.0.1", "192.168.0.1 - 192.168.0.1", false},
+		{"2001:db8::1", "2001:db8::1 - 2001:db8::1", false},
+		{"2001:db8::/32", "2001:db8:: - 2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", false},
+		{"192.168.0.0/33", "", true},
+		{42, "", true},
+	}
+
+	for _, c := range cases {
+		var r Range
+		err := r.Scan(c.src)
+		if (err != nil) != c.err {
+			t.Errorf("unexpected error for %v: %v", c.src, err)
+		}
+		if err == nil && r.String() != c.out {
+			t.Errorf("unexpected range for %v: got %s, want %s", c.src, &r, c.out)
+		}
+	}
+}
+
+func TestIPNetSQL(t *testing.T) {
+	cases := []struct {
+		src interface{}
+		out string
+	}{
+		{"192.168.0.0/24", "192.168.0.0/24"},
+		{"192.168.0.1/24", "192.168.0.0/24"},
+		{[]byte("192.168.0.1"), "192.168.0.1/32"},
+		{"2001:db8::/32", "2001:db8::/32"},
+		{"::ffff:1.2.3.4/128", "::ffff:1.2.3.4/128"},
+		{nil, ""},
+	}
+
+	for _, c := range cases {
+		var n IPNet
+		if err := n.Scan(c.src); err != nil {
+			t.Errorf("unexpected error for %v: %v", c.src, err)
+		}
+
+		v, _ := n.Value()
+		if c.src == nil && v != nil || c.src != nil && v != c.out {
+			t.Errorf("unexpected value for %v: %v", c.src, v)
+		}
+	}
+
+	var n IPNet
+	for _, src := range []interface{}{"192.168.0.0/+24", "192.168.0.0/", "foo/24", 1.5} {
+		if err := n.Scan(src); err == nil {
+			t.Errorf("error expected for %v", src)
+		}
+	}
+}
+
+func TestAddrSQL(t *testing.T) {
+	cases := []struct {
+		src interface{}
+		ip  net.IP
+	}{
+		{"192.168.0.1", ParseIPv4("192.168.0.1")},
+		{"192.168.0.1/24", ParseIPv4("192.168.0.1")},
+		{[]byte("2001:db8::1"), ParseIPv6("2001:db8::1")},
+		{nil, nil},
+	}
+
+	for _, c := range cases {
+		var a Addr
+		if err := a.Scan(c.src); err != nil {
+			t.Errorf("unexpected error for %v: %v", c.src, err)
+		}
+		if !a.IP().Equal(c.ip) {
+			t.Errorf("unexpected address for %v: got %s, want %s", c.src, a, c.ip)
+		}
+	}
+
+	if v, _ := AddrFromIP(ParseIPv4("192.168.0.1")).Value(); v != "192.168.0.1" {
+		t.Errorf("unexpected value: %v", v)
+	}
+	if v, _ := (Addr{}).Value(); v != nil {
+		t.Errorf("unexpected value: %v", v)
+	}
+}
+
+func TestInetSQL(t *testing.T) {
+	cases := []struct {
+		src interface{}
+		ip  net.IP
+	}{
+		{"192.168.0.1", ParseIPv4("192.168.0.1")},
+		{"192.168.0.1/24", ParseIPv4("192.168.0.1")},
+		{[]byte("2001:db8::1"), ParseIPv6("2001:db8::1")},
+		{"::ffff:192.168.0.1", net.ParseIP("192.168.0.1")},
+		{nil, nil},
+	}
+
+	for _, c := range cases {
+		var a Inet
+		if err := a.Scan(c.src); err != nil {
+			t.Errorf("unexpected error for %v: %v", c.src, err)
+		}
+		if !net.IP(a).Equal(c.ip) || len(a) != len(c.ip) {
+			t.Errorf("unexpected address for %v: got %s, want %s", c.src, net.IP(a), c.ip)
+		}
+	}
+
+	values := []struct {
+		in  Inet
+		out driver.Value
+	}{
+		{Inet(ParseIPv4("192.168.0.1")), "192.168.0.1"},
+		{Inet(net.ParseIP("192.168.0.1")), "::ffff:192.168.0.1"},
+		{Inet(ParseIPv6("2001:db8::1")), "2001:db8::1"},
+		{nil, nil},
+	}
+	for _, c := range values {
+		if v, err := c.in.Value(); err != nil || v != c.out {
+			t.Errorf("unexpected value for %s: got %v, %v, want %v", net.IP(c.in), v, err, c.out)
+		}
+	}
+	if _, err := Inet([]byte{1, 2, 3}).Value(); err == nil {
+		t.Error("error expected for invalid address")
+	}
+}
+
+func TestDecimalSQL(t *testing.T) {
+	cases := []struct {
+		src interface{}
+		af  uint
+		ip  net.IP
+		err bool
+	}{
+		{"3232235521", 0, ParseIPv4("192.168.0.1"), false},
+		{[]byte("3232235521"), IPv6, ParseIPv6("::c0a8:1"), false},
+		{int64(3232235521), 0, ParseIPv4("192.168.0.1"), false},
+		{"42540766411282592856903984951653826561", 0, ParseIPv6("2001:db8::1"), false},
+		{"42540766411282592856903984951653826561", IPv4, nil, true},
+		{int64(-1), 0, nil, true},
+		{"1.5", 0, nil, true},
+		{nil, 0, nil, false},
+	}
+
+	for _, c := range cases {
+		d := Decimal{Family: c.af}
+		err := d.Scan(c.src)
+		if (err != nil) != c.err {
+			t.Errorf("unexpected error for %v: %v", c.src, err)
+		}
+		if !d.IP.Equal(c.ip) || len(d.IP) != len(c.ip) {
+			t.Errorf("unexpected address for %v: got %v, want %v", c.src, d.IP, c.ip)
+		}
+	}
+
+	if v, _ := (Decimal{IP: ParseIPv6("2001:db8::1")}).Value(); v != "42540766411282592856903984951653826561" {
+		t.Errorf("unexpected value: %v", v)
+	}
+	if v, _ := (Decimal{}).Value(); v != nil {
+		t.Errorf("unexpected value: %v", v)
+	}
+}
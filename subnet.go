@@ -3,7 +3,7 @@ package iputil
 import (
 	"net"
 
-	"github.com/ericyan/iputil/internal/uint128"
+	"github.com/ericyan/iputil/uint128"
 )
 
 // NetworkAddr returns the network address, which is also the beginning
@@ -0,0 +1,121 @@
+package uint128
+
+import (
+	"math"
+	"math/big"
+	"net/netip"
+)
+
+// NewFromUint64 creates a new Int from v.
+func NewFromUint64(v uint64) Int {
+	return Int{0, v}
+}
+
+// IsUint64 reports whether x can be represented as a uint64.
+func (x Int) IsUint64() bool {
+	return x.Hi == 0
+}
+
+// Uint64 returns the low 64 bits of x. The result is undefined if x
+// cannot be represented as a uint64.
+func (x Int) Uint64() uint64 {
+	return x.Lo
+}
+
+// NewFromBig creates a new Int from b. It returns an error if b is
+// negative or does not fit in 128 bits.
+func NewFromBig(b *big.Int) (Int, error) {
+	if b.Sign() < 0 {
+		return Zero, ErrNegative
+	}
+	if b.BitLen() > 128 {
+		return Max, ErrOverflow
+	}
+
+	var buf [16]byte
+	b.FillBytes(buf[:])
+
+	return NewFromBytes(buf[:])
+}
+
+// Big returns x as a *big.Int.
+func (x Int) Big() *big.Int {
+	return new(big.Int).SetBytes(x.Bytes())
+}
+
+// NewFromFloat64 creates a new Int from f, truncated towards zero. It
+// returns an error if f is negative, NaN, or not less than 2**128.
+func NewFromFloat64(f float64) (Int, error) {
+	switch {
+	case math.IsNaN(f):
+		return Zero, ErrNaN
+	case f < 0:
+		return Zero, ErrNegative
+	case f >= 0x1p128:
+		return Max, ErrOverflow
+	case f < 1:
+		return Zero, nil
+	}
+
+	// f = frac * 2**exp, where frac is in [0.5, 1) and has at most 53
+	// significant bits, so frac * 2**64 is an exact integer.
+	frac, exp := math.Frexp(f)
+	x := Int{0, uint64(math.Ldexp(frac, 64))}
+	if exp >= 64 {
+		return x.Lsh(uint(exp - 64)), nil
+	}
+
+	return x.Rsh(uint(64 - exp)), nil
+}
+
+// Float64 returns the float64 value nearest to x.
+func (x Int) Float64() float64 {
+	if x.Hi == 0 {
+		return float64(x.Lo)
+	}
+
+	// Keep the 64 most significant bits, folding the discarded ones into
+	// the least significant bit so that rounding stays correct.
+	shift := uint(x.BitLen() - 64)
+	top := x.Rsh(shift).Lo
+	if !x.Lsh(128 - shift).IsEqualTo(Zero) {
+		top |= 1
+	}
+
+	return math.Ldexp(float64(top), int(shift))
+}
+
+// NewFromAddr creates a new Int from the IP address a. IPv4 addresses
+// result in values that fit in 32 bits.
+func NewFromAddr(a netip.Addr) Int {
+	if a.Is4() {
+		b := a.As4()
+		x, _ := NewFromBytes(b[:])
+		return x
+	}
+
+	b := a.As16()
+	x, _ := NewFromBytes(b[:])
+	return x
+}
+
+// Addr4 returns x as an IPv4 address. It returns an error if x does not
+// fit in 32 bits.
+func (x Int) Addr4() (netip.Addr, error) {
+	if x.BitLen() > 32 {
+		return netip.Addr{}, ErrOverflow
+	}
+
+	var b [4]byte
+	copy(b[:], x.Bytes()[12:])
+
+	return netip.AddrFrom4(b), nil
+}
+
+// Addr16 returns x as an IPv6 address.
+func (x Int) Addr16() netip.Addr {
+	var b [16]byte
+	copy(b[:], x.Bytes())
+
+	return netip.AddrFrom16(b)
+}
@@ -0,0 +1,99 @@
+package uint128
+
+import (
+	"math"
+	"math/big"
+	"net/netip"
+	"testing"
+)
+
+func TestBig(t *testing.T) {
+	for _, x := range randInts(1000) {
+		if got, want := x.Big().String(), x.String(); got != want {
+			t.Fatalf("unexpected big.Int: got %s, want %s", got, want)
+		}
+
+		y, err := NewFromBig(x.Big())
+		if err != nil || y != x {
+			t.Fatalf("unexpected round trip for %s: got %s, %v", x, y, err)
+		}
+	}
+
+	if _, err := NewFromBig(big.NewInt(-1)); err != ErrNegative {
+		t.Errorf("unexpected error for negative value: %v", err)
+	}
+	if _, err := NewFromBig(two128); err != ErrOverflow {
+		t.Errorf("unexpected error for 2**128: %v", err)
+	}
+}
+
+func TestUint64(t *testing.T) {
+	x := NewFromUint64(42)
+	if !x.IsUint64() || x.Uint64() != 42 {
+		t.Errorf("unexpected uint64 conversion: %s", x)
+	}
+	if Max.IsUint64() {
+		t.Errorf("%s should not fit in uint64", Max)
+	}
+}
+
+func TestFloat64(t *testing.T) {
+	for _, x := range randInts(1000) {
+		want, _ := new(big.Float).SetInt(x.Big()).Float64()
+		if got := x.Float64(); got != want {
+			t.Fatalf("unexpected float64 for %s: got %g, want %g", x, got, want)
+		}
+
+		y, err := NewFromFloat64(want)
+		if err != nil && !(err == ErrOverflow && want == 0x1p128) {
+			t.Fatal(err)
+		}
+		wantInt, _ := new(big.Float).SetFloat64(want).Int(nil)
+		if wantInt.Cmp(two128) < 0 && y.Big().Cmp(wantInt) != 0 {
+			t.Fatalf("unexpected Int for %g: got %s, want %s", want, y, wantInt)
+		}
+	}
+
+	cases := []struct {
+		f   float64
+		x   Int
+		err error
+	}{
+		{0, Zero, nil},
+		{0.9, Zero, nil},
+		{1.5, One, nil},
+		{0x1p64, Int{1, 0}, nil},
+		{0x1p127, Int{1 << 63, 0}, nil},
+		{0x1p128, Max, ErrOverflow},
+		{-1, Zero, ErrNegative},
+		{math.NaN(), Zero, ErrNaN},
+	}
+	for _, c := range cases {
+		if x, err := NewFromFloat64(c.f); x != c.x || err != c.err {
+			t.Errorf("unexpected result for %g: got %s, %v, want %s, %v", c.f, x, err, c.x, c.err)
+		}
+	}
+}
+
+func TestAddr(t *testing.T) {
+	a4 := netip.MustParseAddr("192.168.0.1")
+	x := NewFromAddr(a4)
+	if x.String() != "3232235521" {
+		t.Errorf("unexpected Int for %s: %s", a4, x)
+	}
+	if got, err := x.Addr4(); err != nil || got != a4 {
+		t.Errorf("unexpected address for %s: got %s, %v", x, got, err)
+	}
+
+	a6 := netip.MustParseAddr("2001:db8::1")
+	x = NewFromAddr(a6)
+	if x.String() != "42540766411282592856903984951653826561" {
+		t.Errorf("unexpected Int for %s: %s", a6, x)
+	}
+	if got := x.Addr16(); got != a6 {
+		t.Errorf("unexpected address for %s: got %s", x, got)
+	}
+	if _, err := x.Addr4(); err != ErrOverflow {
+		t.Errorf("unexpected error for %s: %v", x, err)
+	}
+}
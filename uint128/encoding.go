@@ -0,0 +1,83 @@
+package uint128
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+var errInvalidJSON = errors.New("invalid json value")
+
+// MarshalText implements encoding.TextMarshaler. The text form of x is
+// its decimal representation.
+func (x Int) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the
+// syntax of Parse with base 0, so prefixed non-decimal values such as
+// "0xff" are also accepted.
+func (x *Int) UnmarshalText(text []byte) error {
+	v, err := Parse(string(text), 0)
+	if err != nil {
+		return err
+	}
+
+	*x = v
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. As 128-bit values cannot be
+// represented exactly by most JSON implementations, x is encoded as a
+// string of decimal digits.
+func (x Int) MarshalJSON() ([]byte, error) {
+	return json.Marshal(x.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts a string in any
+// form accepted by UnmarshalText, or a non-negative integer number.
+func (x *Int) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return x.UnmarshalText([]byte(s))
+	}
+
+	v, err := Atoi(string(data))
+	if err != nil {
+		return errInvalidJSON
+	}
+
+	*x = v
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The binary form of
+// x is its big-endian representation without leading zero bytes, so
+// that zero is encoded as an empty slice.
+func (x Int) MarshalBinary() ([]byte, error) {
+	b := x.Bytes()
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It accepts any
+// big-endian byte slice of at most 16 bytes.
+func (x *Int) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		*x = Zero
+		return nil
+	}
+
+	v, err := NewFromBytes(data)
+	if err != nil {
+		return err
+	}
+
+	*x = v
+	return nil
+}
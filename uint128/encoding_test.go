@@ -0,0 +1,90 @@
+package uint128
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTextEncoding(t *testing.T) {
+	for _, x := range randInts(100) {
+		text, err := x.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var y Int
+		if err := y.UnmarshalText(text); err != nil || y != x {
+			t.Fatalf("unexpected round trip for %s: got %s, %v", x, y, err)
+		}
+	}
+
+	var x Int
+	if err := x.UnmarshalText([]byte("0xff")); err != nil || x != (Int{0, 255}) {
+		t.Errorf("unexpected result for hex text: %s, %v", x, err)
+	}
+	if err := x.UnmarshalText([]byte("-1")); err == nil {
+		t.Error("error expected for negative text")
+	}
+}
+
+func TestJSONEncoding(t *testing.T) {
+	type record struct {
+		N Int `json:"n"`
+	}
+
+	for _, x := range randInts(100) {
+		data, err := json.Marshal(record{x})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"n":"` + x.String() + `"}`; string(data) != want {
+			t.Fatalf("unexpected json: got %s, want %s", data, want)
+		}
+
+		var r record
+		if err := json.Unmarshal(data, &r); err != nil || r.N != x {
+			t.Fatalf("unexpected round trip for %s: got %s, %v", x, r.N, err)
+		}
+	}
+
+	var r record
+	if err := json.Unmarshal([]byte(`{"n":340282366920938463463374607431768211455}`), &r); err != nil || r.N != Max {
+		t.Errorf("unexpected result for json number: %s, %v", r.N, err)
+	}
+	if err := json.Unmarshal([]byte(`{"n":1.5}`), &r); err == nil {
+		t.Error("error expected for fractional json number")
+	}
+}
+
+func TestBinaryEncoding(t *testing.T) {
+	cases := []struct {
+		x   Int
+		len int
+	}{
+		{Zero, 0},
+		{One, 1},
+		{Int{0, 0x1ff}, 2},
+		{Int{1, 0}, 9},
+		{Max, 16},
+	}
+
+	for _, c := range cases {
+		data, err := c.x.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != c.len {
+			t.Errorf("unexpected length for %s: got %d, want %d", c.x, len(data), c.len)
+		}
+
+		y := Max
+		if err := y.UnmarshalBinary(data); err != nil || y != c.x {
+			t.Errorf("unexpected round trip for %s: got %s, %v", c.x, y, err)
+		}
+	}
+
+	var x Int
+	if err := x.UnmarshalBinary(make([]byte, 17)); err != ErrOverflow {
+		t.Errorf("unexpected error for 17-byte input: %v", err)
+	}
+}
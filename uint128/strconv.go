@@ -0,0 +1,252 @@
+package uint128
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// cutoff is the smallest n that n*10 will overlow an Int.
+var cutoff = Int{1844674407370955161, 11068046444225730970}
+
+// bigBase returns the largest power of base that fits in a uint64, and
+// the number of digits it has in that base.
+func bigBase(base int) (uint64, int) {
+	b, n := uint64(base), 1
+	for {
+		hi, lo := bits.Mul64(b, uint64(base))
+		if hi != 0 {
+			return b, n
+		}
+		b, n = lo, n+1
+	}
+}
+
+// Itoa returns the string representation of x in base 10.
+func Itoa(x Int) string {
+	return x.Text(10)
+}
+
+// Text returns the string representation of x in the given base, which
+// must be between 2 and 36 inclusive. Digits above 9 are in lower case,
+// and no prefix is added.
+func (x Int) Text(base int) string {
+	if base < 2 || base > len(digits) {
+		panic("uint128: illegal base " + strconv.Itoa(base))
+	}
+
+	if x.Hi == 0 {
+		return strconv.FormatUint(x.Lo, base)
+	}
+
+	// Convert x in chunks of as many digits as fit in a uint64, so that
+	// at most three 128-by-64-bit divisions are needed.
+	d, n := bigBase(base)
+	chunks := make([]uint64, 0, 3)
+	for x.Hi != 0 {
+		var r uint64
+		x, r = quoRem64(x, d)
+		chunks = append(chunks, r)
+	}
+
+	buf := make([]byte, 0, 128)
+	buf = strconv.AppendUint(buf, x.Lo, base)
+	for i := len(chunks) - 1; i >= 0; i-- {
+		s := strconv.FormatUint(chunks[i], base)
+		for j := len(s); j < n; j++ {
+			buf = append(buf, '0')
+		}
+		buf = append(buf, s...)
+	}
+
+	return string(buf)
+}
+
+// Atoi creates a new Int from s, interpreted in base 10.
+func Atoi(s string) (Int, error) {
+	if len(s) == 0 {
+		return Zero, ErrInvalidString
+	}
+
+	x := Zero
+	for _, c := range []byte(s) {
+		if c < '0' || c > '9' {
+			return Max, ErrInvalidString
+		}
+		digit := Int{0, uint64(c - '0')}
+
+		if x.IsGreaterThan(cutoff) || x.IsEqualTo(cutoff) {
+			return Max, ErrOverflow
+		}
+		x = x.Mul(Int{0, 10})
+
+		x1 := x.Add(digit)
+		if x1.IsLessThan(x) {
+			return Max, ErrOverflow
+		}
+		x = x1
+	}
+
+	return x, nil
+}
+
+// Parse creates a new Int from s, interpreted in the given base, which
+// must be 0 or between 2 and 36 inclusive. Letters of either case are
+// accepted as digits above 9.
+//
+// If base is 0, it is implied by the prefix of s, following the Go
+// syntax for integer literals: "0b" for base 2, "0o" or "0" for base 8,
+// "0x" for base 16, and base 10 otherwise. Underscores may then be used
+// to separate digits, as in "0xffff_ffff".
+func Parse(s string, base int) (Int, error) {
+	if base != 0 && (base < 2 || base > len(digits)) {
+		return Zero, ErrInvalidString
+	}
+
+	if base == 0 {
+		base = 10
+		if len(s) > 1 && s[0] == '0' {
+			switch s[1] {
+			case 'b', 'B':
+				base, s = 2, s[2:]
+			case 'o', 'O':
+				base, s = 8, s[2:]
+			case 'x', 'X':
+				base, s = 16, s[2:]
+			default:
+				base, s = 8, s[1:]
+			}
+			s = strings.TrimPrefix(s, "_")
+		}
+
+		if !underscoreOK(s) {
+			return Zero, ErrInvalidString
+		}
+		s = strings.ReplaceAll(s, "_", "")
+	}
+
+	if len(s) == 0 {
+		return Zero, ErrInvalidString
+	}
+
+	b := Int{0, uint64(base)}
+	x := Zero
+	for i := 0; i < len(s); i++ {
+		d := digitVal(s[i])
+		if d >= base {
+			return Zero, ErrInvalidString
+		}
+
+		var overflow bool
+		if x, overflow = x.MulOverflow(b); overflow {
+			return Max, ErrOverflow
+		}
+		if x, overflow = x.AddOverflow(Int{0, uint64(d)}); overflow {
+			return Max, ErrOverflow
+		}
+	}
+
+	return x, nil
+}
+
+// digitVal returns the value of the digit c, or a value larger than any
+// valid base if c is not a digit.
+func digitVal(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'z':
+		return int(c - 'a' + 10)
+	case 'A' <= c && c <= 'Z':
+		return int(c - 'A' + 10)
+	default:
+		return len(digits)
+	}
+}
+
+// underscoreOK reports whether every underscore in s separates two
+// digits.
+func underscoreOK(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(s)-1 || s[i-1] == '_' || s[i+1] == '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Format implements fmt.Formatter. It accepts the verbs 'd', 's' and
+// 'v' for decimal, 'b' for binary, 'o' and 'O' for octal, and 'x' and
+// 'X' for hexadecimal output. The '#' flag adds a base prefix ("0b",
+// "0", "0x" or "0X"; 'O' always adds "0o"), and width, precision and the
+// '-' and '0' flags behave as they do for big.Int.
+func (x Int) Format(s fmt.State, verb rune) {
+	var base int
+	var prefix string
+	switch verb {
+	case 'd', 's', 'v':
+		base = 10
+	case 'b':
+		base, prefix = 2, "0b"
+	case 'o':
+		base, prefix = 8, "0"
+	case 'O':
+		base, prefix = 8, "0o"
+	case 'x':
+		base, prefix = 16, "0x"
+	case 'X':
+		base, prefix = 16, "0X"
+	default:
+		fmt.Fprintf(s, "%%!%c(uint128.Int=%s)", verb, x.String())
+		return
+	}
+
+	if verb != 'O' && !s.Flag('#') {
+		prefix = ""
+	}
+
+	text := x.Text(base)
+	if verb == 'X' {
+		text = strings.ToUpper(text)
+	}
+	if verb == 'o' && text[0] == '0' {
+		prefix = ""
+	}
+
+	// The precision is the minimum number of digits to print.
+	if prec, ok := s.Precision(); ok {
+		if prec == 0 && x == Zero {
+			text = ""
+		}
+		if len(text) < prec {
+			text = strings.Repeat("0", prec-len(text)) + text
+		}
+	}
+
+	var pad string
+	if width, ok := s.Width(); ok {
+		if n := width - len(prefix) - len(text); n > 0 {
+			pad = strings.Repeat(" ", n)
+			_, hasPrec := s.Precision()
+			if s.Flag('0') && !s.Flag('-') && !hasPrec {
+				pad = strings.Repeat("0", n)
+				text = pad + text
+				pad = ""
+			}
+		}
+	}
+
+	if s.Flag('-') {
+		io.WriteString(s, prefix+text+pad)
+		return
+	}
+	io.WriteString(s, pad+prefix+text)
+}
@@ -0,0 +1,160 @@
+package uint128
+
+import (
+	"fmt"
+	"testing"
+)
+
+// itoaReference is the original base 10 conversion, which performs a
+// bit-by-bit long division per digit. It serves as a reference for
+// correctness and performance.
+func itoaReference(x Int) string {
+	s := make([]byte, 0)
+	for !x.IsLessThan(Int{0, 10}) {
+		quo, mod := divReference(x, Int{0, 10})
+
+		s = append(s, digits[int(mod.Lo)])
+		x = quo
+	}
+	s = append(s, digits[int(x.Lo)])
+
+	for l, r := 0, len(s)-1; l < r; l, r = l+1, r-1 {
+		s[l], s[r] = s[r], s[l]
+	}
+
+	return string(s)
+}
+
+func TestStrconv(t *testing.T) {
+	cases := []struct {
+		in  string
+		out string
+		err error
+	}{
+		{"", "0", ErrInvalidString},
+		{"0", "0", nil},
+		{"10", "10", nil},
+		{"100", "100", nil},
+		{"0123456", "123456", nil},
+		{"4294967295", "4294967295", nil},
+		{"340282366920938463463374607431768211455", "340282366920938463463374607431768211455", nil},
+		{"340282366920938463463374607431768211456", "340282366920938463463374607431768211455", ErrOverflow},
+	}
+
+	for _, c := range cases {
+		x, err := Atoi(c.in)
+		if err != c.err {
+			t.Errorf("unexpected error for %v: got %s, want %s", c.in, err, c.err)
+		}
+		if out := Itoa(x); out != c.out {
+			t.Errorf("unexpected result: got %s, want %s", out, c.out)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	for _, x := range randInts(500) {
+		if got, want := Itoa(x), itoaReference(x); got != want {
+			t.Fatalf("unexpected decimal string: got %s, want %s", got, want)
+		}
+
+		for base := 2; base <= 36; base++ {
+			s := x.Text(base)
+			if want := x.Big().Text(base); s != want {
+				t.Fatalf("unexpected base %d string for %s: got %s, want %s", base, x, s, want)
+			}
+
+			y, err := Parse(s, base)
+			if err != nil || y != x {
+				t.Fatalf("unexpected round trip for %s in base %d: got %s, %v", s, base, y, err)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		in   string
+		base int
+		out  string
+		err  error
+	}{
+		{"", 10, "0", ErrInvalidString},
+		{"ff", 16, "255", nil},
+		{"FF", 16, "255", nil},
+		{"0xff", 16, "0", ErrInvalidString},
+		{"0xff", 0, "255", nil},
+		{"0XFF", 0, "255", nil},
+		{"0b1010", 0, "10", nil},
+		{"0o17", 0, "15", nil},
+		{"017", 0, "15", nil},
+		{"0", 0, "0", nil},
+		{"0x", 0, "0", ErrInvalidString},
+		{"0xffff_ffff", 0, "4294967295", nil},
+		{"0x_ff", 0, "255", nil},
+		{"1_000_000", 0, "1000000", nil},
+		{"1__0", 0, "0", ErrInvalidString},
+		{"10_", 0, "0", ErrInvalidString},
+		{"1_0", 10, "0", ErrInvalidString},
+		{"z", 36, "35", nil},
+		{"2", 2, "0", ErrInvalidString},
+		{"1", 1, "0", ErrInvalidString},
+		{"1", 37, "0", ErrInvalidString},
+		{"0xffffffffffffffffffffffffffffffff", 0, "340282366920938463463374607431768211455", nil},
+		{"0x100000000000000000000000000000000", 0, "340282366920938463463374607431768211455", ErrOverflow},
+	}
+
+	for _, c := range cases {
+		x, err := Parse(c.in, c.base)
+		if err != c.err {
+			t.Errorf("unexpected error for %q in base %d: got %v, want %v", c.in, c.base, err, c.err)
+		}
+		if out := x.String(); out != c.out {
+			t.Errorf("unexpected result for %q in base %d: got %s, want %s", c.in, c.base, out, c.out)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	formats := []string{
+		"%d", "%s", "%v", "%b", "%o", "%O", "%x", "%X",
+		"%#b", "%#o", "%#x", "%#X",
+		"%40d", "%-40d|", "%040d", "%#040x", "%-#40x|", "%.45d", "%50.45x", "%.0d",
+	}
+
+	for _, x := range []Int{Zero, One, {0, 255}, {1, 0}, Max, {0xdeadbeef, 0xcafebabe}} {
+		for _, f := range formats {
+			got := fmt.Sprintf(f, x)
+			want := fmt.Sprintf(f, x.Big())
+
+			// Unlike built-in integer types, big.Int prints a redundant
+			// octal prefix for zero.
+			if f == "%#o" && x == Zero {
+				want = "0"
+			}
+			if got != want {
+				t.Errorf("unexpected result for %q with %s: got %q, want %q", f, x, got, want)
+			}
+		}
+	}
+
+	if got := fmt.Sprintf("%q", One); got != "%!q(uint128.Int=1)" {
+		t.Errorf("unexpected result for unsupported verb: %s", got)
+	}
+}
+
+func BenchmarkItoa(b *testing.B) {
+	x := Max
+
+	b.Run("Chunked", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Itoa(x)
+		}
+	})
+
+	b.Run("Reference", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			itoaReference(x)
+		}
+	})
+}
@@ -1,3 +1,5 @@
+// Package uint128 implements unsigned 128-bit integer arithmetic, as
+// needed for working with IPv6 addresses.
 package uint128
 
 import (
@@ -6,12 +8,18 @@ import (
 	"math/bits"
 )
 
+// Commonly used values.
 var (
 	Zero = Int{0x0, 0x0}
 	One  = Int{0x0, 0x1}
 	Max  = Int{0xffffffffffffffff, 0xffffffffffffffff}
+)
 
+// Errors returned when creating an Int from other types.
+var (
 	ErrOverflow      = errors.New("overflow")
+	ErrNegative      = errors.New("negative value")
+	ErrNaN           = errors.New("not a number")
 	ErrEmptySlice    = errors.New("empty byte slice")
 	ErrInvalidString = errors.New("invalid string")
 )
@@ -51,90 +59,109 @@ func Pow2(n uint) (Int, error) {
 
 // Add returns the sum x+y as a new Int.
 func (x Int) Add(y Int) Int {
-	lo := x.Lo + y.Lo
-	hi := x.Hi + y.Hi
-	if x.Lo > lo {
-		hi++
-	}
+	lo, carry := bits.Add64(x.Lo, y.Lo, 0)
+	hi, _ := bits.Add64(x.Hi, y.Hi, carry)
 
 	return Int{hi, lo}
 }
 
 // Sub returns the difference x-y as a new Int.
 func (x Int) Sub(y Int) Int {
-	lo := x.Lo - y.Lo
-	hi := x.Hi - y.Hi
-	if x.Lo < lo {
-		hi--
-	}
+	lo, borrow := bits.Sub64(x.Lo, y.Lo, 0)
+	hi, _ := bits.Sub64(x.Hi, y.Hi, borrow)
 
 	return Int{hi, lo}
 }
 
-// mul64 returns the product x*y as two uint64.
-//
-// Overflow is handled by breaking the multiplication into (x1<<32 + x0)*(y1<<32 + y0).
-// Adapted from http://www.hackersdelight.org/hdcodetxt/muldwu.c.txt
-func mul64(x, y uint64) (hi, lo uint64) {
-	x0, x1 := x&0xffffffff, x>>32
-	y0, y1 := y&0xffffffff, y>>32
+// AddOverflow returns the sum x+y as a new Int, and reports whether the
+// addition overflowed.
+func (x Int) AddOverflow(y Int) (Int, bool) {
+	lo, carry := bits.Add64(x.Lo, y.Lo, 0)
+	hi, carry := bits.Add64(x.Hi, y.Hi, carry)
 
-	w0 := x0 * y0
-	t := x1*y0 + w0>>32
-	w1 := t & 0xffffffff
-	w2 := t >> 32
-	w1 += x0 * y1
+	return Int{hi, lo}, carry != 0
+}
+
+// SubOverflow returns the difference x-y as a new Int, and reports
+// whether the subtraction underflowed.
+func (x Int) SubOverflow(y Int) (Int, bool) {
+	lo, borrow := bits.Sub64(x.Lo, y.Lo, 0)
+	hi, borrow := bits.Sub64(x.Hi, y.Hi, borrow)
 
-	return x1*y1 + w2 + w1>>32, x * y
+	return Int{hi, lo}, borrow != 0
 }
 
 // Mul returns the product x*y as a new Int.
 func (x Int) Mul(y Int) Int {
-	hi, lo := mul64(x.Lo, y.Lo)
+	hi, lo := bits.Mul64(x.Lo, y.Lo)
 	hi += x.Hi*y.Lo + x.Lo*y.Hi
 
 	return Int{hi, lo}
 }
 
-// getBit returns the n-th bit of x, where 0 is the least-significant bit.
-// The returned value will be either Zero or One.
-func getBit(x Int, n uint) Int {
-	return x.Rsh(n).And(One)
-}
+// MulOverflow returns the product x*y as a new Int, and reports whether
+// the multiplication overflowed.
+func (x Int) MulOverflow(y Int) (Int, bool) {
+	if x.Hi != 0 && y.Hi != 0 {
+		return x.Mul(y), true
+	}
 
-// setBit set the n-th bit of x to bit and returns the result as a net Int.
-func setBit(x Int, n uint, bit Int) Int {
-	mask := One.Lsh(n)
+	hi, lo := bits.Mul64(x.Lo, y.Lo)
+	h1, l1 := bits.Mul64(x.Hi, y.Lo)
+	h2, l2 := bits.Mul64(x.Lo, y.Hi)
 
-	if bit == One {
-		return x.Or(mask)
-	}
+	hi, c1 := bits.Add64(hi, l1, 0)
+	hi, c2 := bits.Add64(hi, l2, 0)
 
-	return x.And(mask.Not())
+	return Int{hi, lo}, h1 != 0 || h2 != 0 || c1 != 0 || c2 != 0
+}
+
+// quoRem64 returns the quotient and remainder of x divided by the 64-bit
+// divisor d. If d == 0, a division-by-zero run-time panic occurs.
+func quoRem64(x Int, d uint64) (Int, uint64) {
+	hi, r := x.Hi/d, x.Hi%d
+	lo, r := bits.Div64(r, x.Lo, d)
+
+	return Int{hi, lo}, r
 }
 
 // div returns the quotient and modulus for y != 0.
+//
+// If y fits in 64 bits, two hardware divisions suffice. Otherwise the
+// quotient fits in 64 bits, and is estimated from the top 64 bits of y
+// as described in section 9-5 of Hacker's Delight, which is off by at
+// most one.
 func div(x, y Int) (quo, mod Int) {
-	if y.IsEqualTo(Zero) {
-		panic("division by zero")
+	if y.Hi == 0 {
+		q, r := quoRem64(x, y.Lo)
+		return q, Int{0, r}
 	}
 
-	quo, mod = Zero, Zero
-	for i := x.BitLen() - 1; i >= 0; i-- {
-		// Left-shift mode by 1 bit, then set the least-significant bit of mod
-		// equal to bit i of x
-		mod = mod.Lsh(1)
-		mod = setBit(mod, 0, getBit(x, uint(i)))
-
-		if mod.IsGreaterThan(y) || mod.IsEqualTo(y) {
-			mod = mod.Sub(y)
-			quo = setBit(quo, uint(i), One)
-		}
+	n := uint(bits.LeadingZeros64(y.Hi))
+	y1 := y.Lsh(n)
+	x1 := x.Rsh(1)
+	q, _ := bits.Div64(x1.Hi, x1.Lo, y1.Hi)
+	q >>= 63 - n
+	if q != 0 {
+		q--
+	}
+
+	quo = Int{0, q}
+	mod = x.Sub(y.Mul(quo))
+	if !mod.IsLessThan(y) {
+		quo = quo.Add(One)
+		mod = mod.Sub(y)
 	}
 
 	return quo, mod
 }
 
+// QuoRem returns the quotient x/y and the remainder x%y for y != 0. If
+// y == 0, a division-by-zero run-time panic occurs.
+func (x Int) QuoRem(y Int) (quo, rem Int) {
+	return div(x, y)
+}
+
 // Div returns the quotient x/y for y != 0. If y == 0, a division-by-zero run-time panic occurs.
 func (x Int) Div(y Int) Int {
 	quo, _ := div(x, y)
@@ -168,32 +195,42 @@ func (x Int) Not() Int {
 }
 
 // Lsh moves each bit of x to the left by n bits and returns result as a new Int.
+//
+// Shifting a uint64 by 64 or more bits yields zero in Go, and n-64 and
+// 64-n wrap around to large values, so no branches are needed.
 func (x Int) Lsh(n uint) Int {
-	if n >= 128 {
-		return Zero
-	}
-
-	if n >= 64 {
-		return Int{x.Lo << (n - 64), 0}
-	}
-
-	return Int{(x.Hi << n) | (x.Lo >> (64 - n)), x.Lo << n}
+	return Int{x.Hi<<n | x.Lo<<(n-64) | x.Lo>>(64-n), x.Lo << n}
 }
 
 // Rsh moves each bit of x to the right by n bits and returns result as a new Int.
 func (x Int) Rsh(n uint) Int {
-	if n >= 128 {
-		return Zero
-	}
+	return Int{x.Hi >> n, x.Lo>>n | x.Hi>>(n-64) | x.Hi<<(64-n)}
+}
 
-	if n >= 64 {
-		return Int{0, x.Hi >> (n - 64)}
-	}
+// RotateLeft returns the value of x rotated left by (k mod 128) bits. To
+// rotate x right by k bits, call RotateLeft(-k).
+func (x Int) RotateLeft(k int) Int {
+	n := uint(k) & 127
 
-	return Int{x.Hi >> n, (x.Lo >> n) | (x.Hi << (64 - n))}
+	return x.Lsh(n).Or(x.Rsh(128 - n))
 }
 
-// Len returns the minimum number of bits required to represent x.
+// Reverse returns the value of x with its bits in reversed order.
+func (x Int) Reverse() Int {
+	return Int{bits.Reverse64(x.Lo), bits.Reverse64(x.Hi)}
+}
+
+// ReverseBytes returns the value of x with its bytes in reversed order.
+func (x Int) ReverseBytes() Int {
+	return Int{bits.ReverseBytes64(x.Lo), bits.ReverseBytes64(x.Hi)}
+}
+
+// OnesCount returns the number of one bits ("population count") in x.
+func (x Int) OnesCount() int {
+	return bits.OnesCount64(x.Hi) + bits.OnesCount64(x.Lo)
+}
+
+// BitLen returns the minimum number of bits required to represent x.
 func (x Int) BitLen() int {
 	if x.Hi == 0 {
 		return bits.Len64(x.Lo)
@@ -204,7 +241,11 @@ func (x Int) BitLen() int {
 
 // LeadingZeros returns the number of leading zero bits in x.
 func (x Int) LeadingZeros() int {
-	return 128 - x.BitLen()
+	if x.Hi == 0 {
+		return 64 + bits.LeadingZeros64(x.Lo)
+	}
+
+	return bits.LeadingZeros64(x.Hi)
 }
 
 // TrailingZeros returns the number of trailing zero bits in x.
@@ -2,11 +2,42 @@ package uint128
 
 import (
 	"bytes"
+	"math/big"
+	"math/rand"
 	"testing"
 )
 
 const maxUint64 = (1<<64 - 1)
 
+// mulReference is the original multiplication, which computes the low
+// 128 bits of the product in 32-bit halves.
+func mulReference(x, y Int) Int {
+	x0, x1 := x.Lo&0xffffffff, x.Lo>>32
+	y0, y1 := y.Lo&0xffffffff, y.Lo>>32
+
+	w0 := x0 * y0
+	t := x1*y0 + w0>>32
+	w1 := t & 0xffffffff
+	w2 := t >> 32
+	w1 += x0 * y1
+
+	hi := x1*y1 + w2 + w1>>32 + x.Hi*y.Lo + x.Lo*y.Hi
+	return Int{hi, x.Lo * y.Lo}
+}
+
+// divReference is the original bit-by-bit long division.
+func divReference(x, y Int) (quo, mod Int) {
+	for i := x.BitLen() - 1; i >= 0; i-- {
+		mod = mod.Lsh(1).Or(x.Rsh(uint(i)).And(One))
+		if !mod.IsLessThan(y) {
+			mod = mod.Sub(y)
+			quo = quo.Or(One.Lsh(uint(i)))
+		}
+	}
+
+	return quo, mod
+}
+
 func TestComparison(t *testing.T) {
 	cases := []struct {
 		x   Int
@@ -270,3 +301,188 @@ func TestBitCounting(t *testing.T) {
 		}
 	}
 }
+
+// randInts returns n pseudo-random Ints, biased towards edge cases such
+// as zero halves and values close to powers of two.
+func randInts(n int) []Int {
+	r := rand.New(rand.NewSource(1))
+	xs := []Int{Zero, One, Max, {0, maxUint64}, {1, 0}, {maxUint64, 0}}
+	for len(xs) < n {
+		x := Int{r.Uint64(), r.Uint64()}
+		switch r.Intn(4) {
+		case 0:
+			x.Hi = 0
+		case 1:
+			x = x.Rsh(uint(r.Intn(128)))
+		case 2:
+			x = x.Lsh(uint(r.Intn(128)))
+		}
+		xs = append(xs, x)
+	}
+
+	return xs
+}
+
+var two128 = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// mod128 reduces b modulo 2**128 and reports whether it was out of range.
+func mod128(b *big.Int) (*big.Int, bool) {
+	overflow := b.Sign() < 0 || b.Cmp(two128) >= 0
+	return new(big.Int).Mod(b, two128), overflow
+}
+
+func TestArithmeticProperties(t *testing.T) {
+	xs := randInts(200)
+
+	for _, x := range xs {
+		bx := x.Big()
+		for _, y := range xs {
+			by := y.Big()
+
+			want, wantOverflow := mod128(new(big.Int).Add(bx, by))
+			if got, overflow := x.AddOverflow(y); got.Big().Cmp(want) != 0 || overflow != wantOverflow || x.Add(y) != got {
+				t.Fatalf("%s + %s: got %s %t, want %s %t", x, y, got, overflow, want, wantOverflow)
+			}
+
+			want, wantOverflow = mod128(new(big.Int).Sub(bx, by))
+			if got, overflow := x.SubOverflow(y); got.Big().Cmp(want) != 0 || overflow != wantOverflow || x.Sub(y) != got {
+				t.Fatalf("%s - %s: got %s %t, want %s %t", x, y, got, overflow, want, wantOverflow)
+			}
+
+			want, wantOverflow = mod128(new(big.Int).Mul(bx, by))
+			if got, overflow := x.MulOverflow(y); got.Big().Cmp(want) != 0 || overflow != wantOverflow || x.Mul(y) != got {
+				t.Fatalf("%s * %s: got %s %t, want %s %t", x, y, got, overflow, want, wantOverflow)
+			}
+
+			if y != Zero {
+				wantQuo, wantRem := new(big.Int).QuoRem(bx, by, new(big.Int))
+				if quo, rem := x.QuoRem(y); quo.Big().Cmp(wantQuo) != 0 || rem.Big().Cmp(wantRem) != 0 {
+					t.Fatalf("%s / %s: got %s ... %s, want %s ... %s", x, y, quo, rem, wantQuo, wantRem)
+				}
+			}
+
+			if cmp := x.Cmp(y); cmp != bx.Cmp(by) {
+				t.Fatalf("cmp(%s, %s): got %d, want %d", x, y, cmp, bx.Cmp(by))
+			}
+		}
+	}
+}
+
+func TestBitProperties(t *testing.T) {
+	for _, x := range randInts(1000) {
+		bx := x.Big()
+
+		for n := uint(0); n <= 130; n++ {
+			want, _ := mod128(new(big.Int).Lsh(bx, n))
+			if got := x.Lsh(n); got.Big().Cmp(want) != 0 {
+				t.Fatalf("%s << %d: got %s, want %s", x, n, got, want)
+			}
+
+			if got, want := x.Rsh(n), new(big.Int).Rsh(bx, n); got.Big().Cmp(want) != 0 {
+				t.Fatalf("%s >> %d: got %s, want %s", x, n, got, want)
+			}
+
+			k := int(n)
+			if got := x.RotateLeft(k).RotateLeft(-k); got != x {
+				t.Fatalf("rotate %s by %d and back: got %s", x, k, got)
+			}
+			if got, want := x.RotateLeft(k), x.Lsh(n%128).Or(x.Rsh(128-n%128)); got != want {
+				t.Fatalf("rotate %s by %d: got %s, want %s", x, k, got, want)
+			}
+		}
+
+		ones := 0
+		for i := 0; i < 128; i++ {
+			ones += int(bx.Bit(i))
+			if x.Reverse().Big().Bit(127-i) != bx.Bit(i) {
+				t.Fatalf("reverse %s: bit %d mismatch", x, i)
+			}
+		}
+		if x.OnesCount() != ones {
+			t.Fatalf("ones count of %s: got %d, want %d", x, x.OnesCount(), ones)
+		}
+
+		b := x.Bytes()
+		for l, r := 0, len(b)-1; l < r; l, r = l+1, r-1 {
+			b[l], b[r] = b[r], b[l]
+		}
+		if got := x.ReverseBytes().Bytes(); !bytes.Equal(got, b) {
+			t.Fatalf("reverse bytes of %s: got %x, want %x", x, got, b)
+		}
+
+		if got, want := x.BitLen(), bx.BitLen(); got != want {
+			t.Fatalf("bit len of %s: got %d, want %d", x, got, want)
+		}
+		if got, want := x.TrailingZeros(), int(bx.TrailingZeroBits()); x != Zero && got != want {
+			t.Fatalf("trailing zeros of %s: got %d, want %d", x, got, want)
+		}
+	}
+}
+
+func TestReference(t *testing.T) {
+	xs := randInts(100)
+	for _, x := range xs {
+		for _, y := range xs {
+			if got, want := x.Mul(y), mulReference(x, y); got != want {
+				t.Fatalf("%s * %s: got %s, want %s", x, y, got, want)
+			}
+
+			if y == Zero {
+				continue
+			}
+			quo, mod := x.QuoRem(y)
+			if wantQuo, wantMod := divReference(x, y); quo != wantQuo || mod != wantMod {
+				t.Fatalf("%s / %s: got %s ... %s, want %s ... %s", x, y, quo, mod, wantQuo, wantMod)
+			}
+		}
+	}
+}
+
+func BenchmarkMul(b *testing.B) {
+	x, y := Int{0xdeadbeef, 0xcafebabecafebabe}, Int{0, 0xfeedfacefeedface}
+
+	b.Run("Bits", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			x.Mul(y)
+		}
+	})
+
+	b.Run("Reference", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			mulReference(x, y)
+		}
+	})
+}
+
+func BenchmarkQuoRem(b *testing.B) {
+	x := Max
+	for _, y := range []Int{{0, 10}, {0xdeadbeef, 0xcafebabe}} {
+		b.Run("Bits/"+y.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				x.QuoRem(y)
+			}
+		})
+
+		b.Run("Reference/"+y.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				divReference(x, y)
+			}
+		})
+	}
+}
+
+func BenchmarkBitCounting(b *testing.B) {
+	x := Int{0, 1984}
+
+	b.Run("LeadingZeros", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			x.LeadingZeros()
+		}
+	})
+
+	b.Run("TrailingZeros", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			x.TrailingZeros()
+		}
+	})
+}
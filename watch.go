@@ -0,0 +1,178 @@
+package iputil
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultWatchInterval is the polling interval of a Watcher whose
+// Interval is zero.
+const DefaultWatchInterval = 5 * time.Second
+
+// An AddrEventType is the type of an AddrEvent.
+type AddrEventType int
+
+// Address event types.
+const (
+	AddrAdded AddrEventType = iota + 1
+	AddrRemoved
+)
+
+// String returns the name of the event type.
+func (t AddrEventType) String() string {
+	switch t {
+	case AddrAdded:
+		return "added"
+	case AddrRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// An AddrEvent reports that an interface address has been added or
+// removed.
+type AddrEvent struct {
+	Type AddrEventType
+	Addr *InterfaceAddr
+}
+
+// A Watcher watches the system's interface addresses for changes.
+//
+// By default, it polls its Source periodically and reports the
+// differences between consecutive results. On Linux, it can instead be
+// notified of address changes by the kernel through netlink, so that
+// changes are reported without delay.
+type Watcher struct {
+	// Interval is the polling interval. If it is zero,
+	// DefaultWatchInterval is used. It is ignored when Netlink is set.
+	Interval time.Duration
+
+	// Netlink enables the netlink backend, which is only supported on
+	// Linux.
+	Netlink bool
+
+	// Source provides the interface addresses. If it is nil,
+	// DefaultSource is used.
+	Source InterfaceSource
+}
+
+// Watch starts watching interface addresses until ctx is done. The
+// addresses present when Watch is called are reported as added first.
+// Failures to list the addresses after that are ignored, and the
+// changes are picked up by the next successful attempt.
+//
+// The returned channel is closed when ctx is done, or when the netlink
+// backend fails. Events must be received promptly, as the watcher does
+// not look for changes while an event is pending.
+func (w *Watcher) Watch(ctx context.Context) (<-chan AddrEvent, error) {
+	src := w.Source
+	if src == nil {
+		src = DefaultSource
+	}
+	list := src.InterfaceAddrs
+
+	// The netlink backend is released when ctx is done, which must
+	// happen even if Watch fails.
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Either notify or ticker is used to wake up the goroutine below. A
+	// nil channel is never ready, so it can be selected unconditionally.
+	var notify <-chan struct{}
+	var tick <-chan time.Time
+	var ticker *time.Ticker
+	if w.Netlink {
+		// Subscribe before listing the current addresses, so that no
+		// change can be missed in between.
+		var err error
+		if notify, err = watchNetlink(ctx); err != nil {
+			cancel()
+			return nil, err
+		}
+	} else {
+		interval := w.Interval
+		if interval <= 0 {
+			interval = DefaultWatchInterval
+		}
+
+		ticker = time.NewTicker(interval)
+		tick = ticker.C
+	}
+
+	addrs, err := list()
+	if err != nil {
+		if ticker != nil {
+			ticker.Stop()
+		}
+		cancel()
+		return nil, err
+	}
+
+	events := make(chan AddrEvent)
+	go func() {
+		defer close(events)
+		defer cancel()
+		if ticker != nil {
+			defer ticker.Stop()
+		}
+
+		var prev []*InterfaceAddr
+		for {
+			for _, ev := range diffAddrs(prev, addrs) {
+				select {
+				case events <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+			prev = addrs
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick:
+			case _, ok := <-notify:
+				if !ok {
+					return
+				}
+			}
+
+			if cur, err := list(); err == nil {
+				addrs = cur
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// diffAddrs returns the events that turn the address list prev into
+// cur. Addresses are identified by interface name, address and prefix
+// length, so a change of prefix length results in the address being
+// removed and added again.
+func diffAddrs(prev, cur []*InterfaceAddr) []AddrEvent {
+	key := func(addr *InterfaceAddr) string {
+		return addr.InterfaceName + " " + addr.IPNet.String()
+	}
+
+	seen := make(map[string]bool, len(cur))
+	for _, addr := range cur {
+		seen[key(addr)] = true
+	}
+
+	var events []AddrEvent
+	known := make(map[string]bool, len(prev))
+	for _, addr := range prev {
+		known[key(addr)] = true
+		if !seen[key(addr)] {
+			events = append(events, AddrEvent{AddrRemoved, addr})
+		}
+	}
+	for _, addr := range cur {
+		if !known[key(addr)] {
+			events = append(events, AddrEvent{AddrAdded, addr})
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,70 @@
+package iputil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Netlink multicast groups of address changes, from linux/rtnetlink.h.
+const (
+	rtmgrpIPv4IfAddr = 0x10
+	rtmgrpIPv6IfAddr = 0x100
+)
+
+// watchNetlink subscribes to the kernel's IPv4 and IPv6 address change
+// notifications. A value is sent on the returned channel whenever
+// notifications arrive, and the channel is closed when ctx is done or
+// reading fails. Notifications that arrive while a value is pending are
+// coalesced, as only the fact that addresses changed matters.
+func watchNetlink(ctx context.Context) (<-chan struct{}, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+
+	sa := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: rtmgrpIPv4IfAddr | rtmgrpIPv6IfAddr,
+	}
+	if err := syscall.Bind(fd, sa); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("bind", err)
+	}
+
+	// A non-blocking socket is managed by the runtime poller, so that
+	// closing the file interrupts a pending read.
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("setnonblock", err)
+	}
+	f := os.NewFile(uintptr(fd), "netlink")
+
+	go func() {
+		<-ctx.Done()
+		f.Close()
+	}()
+
+	notify := make(chan struct{}, 1)
+	go func() {
+		defer close(notify)
+
+		buf := make([]byte, os.Getpagesize())
+		for {
+			// The content of the messages is irrelevant. An overrun of the
+			// socket buffer (ENOBUFS) means messages were lost, which is
+			// also a notification.
+			if _, err := f.Read(buf); err != nil && !errors.Is(err, syscall.ENOBUFS) {
+				return
+			}
+
+			select {
+			case notify <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return notify, nil
+}
@@ -0,0 +1,31 @@
+package iputil
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatcherNetlink(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := &Watcher{Netlink: true}
+	events, err := w.Watch(ctx)
+	if err != nil {
+		t.Skipf("netlink unavailable: %v", err)
+	}
+
+	// The current addresses, including loopback, are reported first.
+	select {
+	case ev := <-events:
+		if ev.Type != AddrAdded {
+			t.Errorf("unexpected event type: %s", ev.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for initial addresses")
+	}
+
+	cancel()
+	for range events {
+	}
+}
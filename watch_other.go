@@ -0,0 +1,13 @@
+//go:build !linux
+
+package iputil
+
+import (
+	"context"
+	"errors"
+)
+
+// watchNetlink is not supported on this system.
+func watchNetlink(ctx context.Context) (<-chan struct{}, error) {
+	return nil, errors.New("netlink unavailable")
+}
@@ -0,0 +1,123 @@
+package iputil
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDiffAddrs(t *testing.T) {
+	eth0 := newTestAddr("eth0", "192.168.1.10/24", net.FlagUp, 0)
+	eth0v6 := newTestAddr("eth0", "2001:db8::10/64", net.FlagUp, 0)
+	eth0New := newTestAddr("eth0", "192.168.1.10/16", net.FlagUp, 0)
+	wlan0 := newTestAddr("wlan0", "192.168.1.10/24", net.FlagUp, 0)
+
+	cases := []struct {
+		prev, cur []*InterfaceAddr
+		out       []string
+	}{
+		{nil, []*InterfaceAddr{eth0, eth0v6}, []string{"added eth0 192.168.1.10", "added eth0 2001:db8::10"}},
+		{[]*InterfaceAddr{eth0, eth0v6}, []*InterfaceAddr{eth0v6, eth0}, nil},
+		{[]*InterfaceAddr{eth0, eth0v6}, []*InterfaceAddr{eth0v6}, []string{"removed eth0 192.168.1.10"}},
+		{[]*InterfaceAddr{eth0}, []*InterfaceAddr{eth0New}, []string{"removed eth0 192.168.1.10", "added eth0 192.168.1.10"}},
+		{[]*InterfaceAddr{eth0}, []*InterfaceAddr{wlan0}, []string{"removed eth0 192.168.1.10", "added wlan0 192.168.1.10"}},
+	}
+
+	for i, c := range cases {
+		var out []string
+		for _, ev := range diffAddrs(c.prev, c.cur) {
+			out = append(out, ev.Type.String()+" "+addrStrings([]*InterfaceAddr{ev.Addr})[0])
+		}
+
+		if len(out) != len(c.out) {
+			t.Errorf("unexpected events for case %d: got %v, want %v", i, out, c.out)
+			continue
+		}
+		for j := range out {
+			if out[j] != c.out[j] {
+				t.Errorf("unexpected events for case %d: got %v, want %v", i, out, c.out)
+				break
+			}
+		}
+	}
+}
+
+// fakeAddrs is an address list that can be changed by tests.
+type fakeAddrs struct {
+	mu    sync.Mutex
+	addrs []*InterfaceAddr
+	err   error
+}
+
+func (f *fakeAddrs) set(addrs []*InterfaceAddr, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.addrs, f.err = addrs, err
+}
+
+func (f *fakeAddrs) InterfaceAddrs() ([]*InterfaceAddr, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.addrs, f.err
+}
+
+func TestWatcher(t *testing.T) {
+	eth0 := newTestAddr("eth0", "192.168.1.10/24", net.FlagUp, 0)
+	tun0 := newTestAddr("tun0", "10.8.0.2/32", net.FlagUp, 0)
+
+	src := &fakeAddrs{addrs: []*InterfaceAddr{eth0}}
+	w := &Watcher{Interval: time.Millisecond, Source: src}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := w.Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := func(typ AddrEventType, addr *InterfaceAddr) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.Type != typ || ev.Addr != addr {
+				t.Errorf("unexpected event: got %s %s, want %s %s", ev.Type, ev.Addr.IPNet, typ, addr.IPNet)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %s %s", typ, addr.IPNet)
+		}
+	}
+
+	expect(AddrAdded, eth0)
+
+	src.set([]*InterfaceAddr{eth0, tun0}, nil)
+	expect(AddrAdded, tun0)
+
+	// Errors are ignored until the next successful attempt.
+	src.set(nil, errors.New("temporary failure"))
+	time.Sleep(10 * time.Millisecond)
+	src.set([]*InterfaceAddr{tun0}, nil)
+	expect(AddrRemoved, eth0)
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("unexpected event after cancellation")
+		}
+	case <-time.After(time.Second):
+		t.Error("channel not closed after cancellation")
+	}
+}
+
+func TestWatcherError(t *testing.T) {
+	src := &fakeAddrs{err: errors.New("failure")}
+	w := &Watcher{Source: src}
+
+	if _, err := w.Watch(context.Background()); err == nil {
+		t.Error("error expected")
+	}
+}